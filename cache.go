@@ -35,7 +35,8 @@ type Cache interface {
 	Stat() Stats
 }
 
-// Stats provides statistics for cache
+// Stats provides statistics for cache. Counters are int, unlike uint64 counters of v3, and are kept so
+// intentionally, as changing their type would break code using this module.
 type Stats struct {
 	Hits, Misses   int // cache effectiveness
 	Added, Evicted int // number of added and evicted records
@@ -201,7 +202,7 @@ func (c *cacheImpl) DeleteExpired() {
 	}
 }
 
-// Purge clears the cache completely.
+// Purge clears the cache completely. Stats are kept, with purged entries counted in Evicted.
 func (c *cacheImpl) Purge() {
 	c.Lock()
 	defer c.Unlock()
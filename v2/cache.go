@@ -36,7 +36,8 @@ type Cache[K comparable, V any] interface {
 	Stat() Stats
 }
 
-// Stats provides statistics for cache
+// Stats provides statistics for cache. Counters are int, unlike uint64 counters of v3, and are kept so
+// intentionally, as changing their type would break code using this module.
 type Stats struct {
 	Hits, Misses   int // cache effectiveness
 	Added, Evicted int // number of added and evicted records
@@ -197,7 +198,7 @@ func (c *cacheImpl[K, V]) DeleteExpired() {
 	}
 }
 
-// Purge clears the cache completely.
+// Purge clears the cache completely. Stats are kept, with purged entries counted in Evicted.
 func (c *cacheImpl[K, V]) Purge() {
 	c.Lock()
 	defer c.Unlock()
@@ -0,0 +1,72 @@
+package cache
+
+import "time"
+
+// reuseWeight is a weight of the last reuse interval in the moving average of intervals
+const reuseWeight = 0.25
+
+// adaptiveTTL defines bounds of TTL suggested by reuse intervals, set by WithAdaptiveTTL
+type adaptiveTTL struct {
+	enabled  bool
+	min, max time.Duration
+	apply    bool // use suggested TTL for writes with default TTL
+}
+
+// SuggestedTTL returns TTL suggested for the key by its reuse interval, i.e. time between Get calls,
+// as set up by WithAdaptiveTTL. Keys reused often within the default TTL get longer TTL, and keys reused
+// rarely get shorter one. Returns false if adaptive TTL is not set or the key is not reused yet.
+func (c *cacheImpl[K, V]) SuggestedTTL(key K) (time.Duration, bool) {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	ent, ok := c.items[key]
+	if !ok {
+		return 0, false
+	}
+	return c.suggestTTL(ent.Value.(*cacheItem[K, V]))
+}
+
+// observeReuse updates moving average of the item reuse interval. Has to be called with lock!
+func (c *cacheImpl[K, V]) observeReuse(item *cacheItem[K, V], now time.Time) {
+	if !c.adaptive.enabled {
+		return
+	}
+	if !item.lastAccess.IsZero() {
+		interval := now.Sub(item.lastAccess)
+		if item.reuse == 0 {
+			item.reuse = interval
+		} else {
+			item.reuse = time.Duration(reuseWeight*float64(interval) + (1-reuseWeight)*float64(item.reuse))
+		}
+	}
+	item.lastAccess = now
+}
+
+// suggestTTL returns the default TTL scaled by the number of reuses within it, halved, so the key reused
+// twice within the default TTL keeps it, limited by adaptive TTL bounds. Has to be called with lock!
+func (c *cacheImpl[K, V]) suggestTTL(item *cacheItem[K, V]) (time.Duration, bool) {
+	if !c.adaptive.enabled || item.reuse <= 0 {
+		return 0, false
+	}
+	ttl := float64(c.ttl) * float64(c.ttl) / float64(2*item.reuse)
+	switch {
+	case ttl > float64(c.adaptive.max):
+		return c.adaptive.max, true
+	case ttl < float64(c.adaptive.min):
+		return c.adaptive.min, true
+	}
+	return time.Duration(ttl), true
+}
+
+// defaultTTL returns TTL for the key written with default TTL, suggested one in case
+// adaptive TTL is set to be applied. Has to be called with lock!
+func (c *cacheImpl[K, V]) defaultTTL(key K) time.Duration {
+	if !c.adaptive.apply {
+		return c.ttl
+	}
+	if ent, ok := c.items[key]; ok {
+		if ttl, ok := c.suggestTTL(ent.Value.(*cacheItem[K, V])); ok {
+			return ttl
+		}
+	}
+	return c.ttl
+}
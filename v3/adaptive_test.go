@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_AdaptiveTTL(t *testing.T) {
+	lc := NewCache[string, string]().WithTTL(time.Minute).WithAdaptiveTTL(10*time.Second, 10*time.Minute, false)
+	impl := lc.(*cacheImpl[string, string])
+	reusedAgo := func(key string, d time.Duration) {
+		impl.items[key].Value.(*cacheItem[string, string]).lastAccess = time.Now().Add(-d)
+		lc.Get(key)
+	}
+
+	lc.Set("hot", "val", 0)
+	lc.Set("cold", "val", 0)
+	_, ok := lc.SuggestedTTL("hot")
+	assert.False(t, ok, "not reused yet")
+	lc.Get("hot")
+	_, ok = lc.SuggestedTTL("hot")
+	assert.False(t, ok, "single Get is not a reuse")
+
+	reusedAgo("hot", 10*time.Second)
+	ttl, ok := lc.SuggestedTTL("hot")
+	assert.True(t, ok)
+	assert.InDelta(t, float64(3*time.Minute), float64(ttl), float64(time.Second), "reused 6 times within TTL")
+
+	reusedAgo("hot", 2*time.Second)
+	ttl, _ = lc.SuggestedTTL("hot")
+	assert.InDelta(t, float64(225*time.Second), float64(ttl), float64(time.Second), "moving average of 10s and 2s is 8s")
+
+	lc.Get("cold")
+	reusedAgo("cold", 10*time.Minute)
+	ttl, _ = lc.SuggestedTTL("cold")
+	assert.Equal(t, 10*time.Second, ttl, "limited by min TTL")
+	reusedAgo("hot", 0)
+	reusedAgo("hot", 0)
+	reusedAgo("hot", 0)
+	reusedAgo("hot", 0)
+	reusedAgo("hot", 0)
+	ttl, _ = lc.SuggestedTTL("hot")
+	assert.Equal(t, 10*time.Minute, ttl, "limited by max TTL")
+
+	lc.Set("cold", "new", 0)
+	exp, _ := lc.GetExpiration("cold")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "suggested TTL not applied")
+
+	_, ok = lc.SuggestedTTL("no-such-key")
+	assert.False(t, ok)
+	_, ok = NewCache[string, string]().SuggestedTTL("key")
+	assert.False(t, ok)
+}
+
+func TestCache_AdaptiveTTLApply(t *testing.T) {
+	lc := NewCache[string, string]().WithTTL(time.Minute).WithAdaptiveTTL(10*time.Second, 10*time.Minute, true)
+	impl := lc.(*cacheImpl[string, string])
+	lc.Set("key", "val", 0)
+	lc.Get("key")
+	impl.items["key"].Value.(*cacheItem[string, string]).lastAccess = time.Now().Add(-10 * time.Minute)
+	lc.Get("key")
+
+	lc.Set("key", "new", 0)
+	exp, _ := lc.GetExpiration("key")
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), exp, time.Second, "suggested TTL applied")
+	lc.Set("key", "new", time.Hour)
+	exp, _ = lc.GetExpiration("key")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second, "explicit TTL kept")
+	lc.Set("new-key", "val", 0)
+	exp, _ = lc.GetExpiration("new-key")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "default TTL for new key")
+}
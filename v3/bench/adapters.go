@@ -0,0 +1,18 @@
+package bench
+
+import cache "github.com/go-pkgz/expirable-cache/v3"
+
+// cacheAdapter adapts expirable-cache Cache to CacheAdapter
+type cacheAdapter struct {
+	c cache.Cache[int64, int64]
+}
+
+func (a cacheAdapter) Get(key int64) (int64, bool) { return a.c.Get(key) }
+func (a cacheAdapter) Set(key, value int64)        { a.c.Set(key, value, 0) }
+
+// ExpirableCache returns factory of expirable-cache made by newCache, with size limit set by WithMaxKeys
+func ExpirableCache(name string, newCache func() cache.Cache[int64, int64]) Factory {
+	return Factory{Name: name, New: func(size int) CacheAdapter {
+		return cacheAdapter{c: newCache().WithMaxKeys(size)}
+	}}
+}
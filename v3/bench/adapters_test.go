@@ -0,0 +1,40 @@
+package bench
+
+import (
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+// hashicorpAdapter adapts hashicorp/golang-lru caches to CacheAdapter
+type hashicorpAdapter struct {
+	get func(key int64) (int64, bool)
+	add func(key, value int64) bool
+}
+
+func (a hashicorpAdapter) Get(key int64) (int64, bool) { return a.get(key) }
+func (a hashicorpAdapter) Set(key, value int64)        { a.add(key, value) }
+
+// factories returns factories of expirable-cache in LRC and LRU modes and hashicorp/golang-lru caches,
+// all with one hour TTL if they support it
+func factories() []Factory {
+	return []Factory{
+		ExpirableCache("expirable-cache/LRC", func() cache.Cache[int64, int64] {
+			return cache.NewCache[int64, int64]().WithTTL(time.Hour)
+		}),
+		ExpirableCache("expirable-cache/LRU", func() cache.Cache[int64, int64] {
+			return cache.NewCache[int64, int64]().WithLRU().WithTTL(time.Hour)
+		}),
+		{Name: "hashicorp/expirable", New: func(size int) CacheAdapter {
+			c := expirable.NewLRU[int64, int64](size, nil, time.Hour)
+			return hashicorpAdapter{get: c.Get, add: c.Add}
+		}},
+		{Name: "hashicorp/lru", New: func(size int) CacheAdapter {
+			c, _ := lru.New[int64, int64](size)
+			return hashicorpAdapter{get: c.Get, add: c.Add}
+		}},
+	}
+}
@@ -0,0 +1,184 @@
+// Package bench is a harness for comparing caches under the same scenarios. A cache is plugged in
+// with a Factory returning CacheAdapter, Run executes scenarios against each cache and returns results,
+// which can be written as CSV or JSON for charting.
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CacheAdapter is a cache under test, keyed and valued by int64
+type CacheAdapter interface {
+	Get(key int64) (int64, bool)
+	Set(key, value int64)
+}
+
+// Factory makes a new cache limited to size entries
+type Factory struct {
+	Name string
+	New  func(size int) CacheAdapter
+}
+
+// Scenario describes workload run against each cache
+type Scenario struct {
+	Name       string
+	Size       int      // cache size limit, also the number of entries set before the run
+	Keys       int64    // number of distinct keys, Size*2 if not set
+	Workload   Workload // distribution of the keys, Uniform if not set
+	Ops        int      // number of operations per goroutine
+	ReadRatio  float64  // part of operations which are Get, the rest are Set
+	Goroutines int      // number of concurrent goroutines, 1 if not set
+	Seed       int64    // random seed, the same workload is generated for each cache
+}
+
+// Result is a result of a scenario run against a cache
+type Result struct {
+	Cache          string        `json:"cache"`
+	Scenario       string        `json:"scenario"`
+	Ops            int           `json:"ops"`
+	Duration       time.Duration `json:"duration_ns"`
+	NsPerOp        float64       `json:"ns_per_op"`
+	HitRatio       float64       `json:"hit_ratio"`
+	HeapPerEntry   float64       `json:"heap_bytes_per_entry"` // live heap after the cache is filled
+	AllocsPerEntry float64       `json:"allocs_per_entry"`     // allocations made while the cache is filled
+	AllocsPerOp    float64       `json:"allocs_per_op"`
+	GCPauseTotal   time.Duration `json:"gc_pause_ns"` // GC pauses during fill and workload
+	NumGC          uint32        `json:"num_gc"`
+	EntriesFilled  int           `json:"entries_filled"`
+}
+
+// op is a single operation of the workload
+type op struct {
+	key  int64
+	read bool
+}
+
+// Run runs all the scenarios against a new cache made by each factory, in order
+func Run(factories []Factory, scenarios []Scenario) []Result {
+	res := make([]Result, 0, len(factories)*len(scenarios))
+	for _, sc := range scenarios {
+		for _, f := range factories {
+			res = append(res, runScenario(f, sc))
+		}
+	}
+	return res
+}
+
+// runScenario fills a new cache, measuring memory it holds, then runs the workload measuring time and hits
+func runScenario(f Factory, sc Scenario) Result {
+	if sc.Keys <= 0 {
+		sc.Keys = int64(sc.Size) * 2
+	}
+	if sc.Goroutines <= 0 {
+		sc.Goroutines = 1
+	}
+	if sc.Workload == nil {
+		sc.Workload = Uniform()
+	}
+	res := Result{Cache: f.Name, Scenario: sc.Name, Ops: sc.Ops * sc.Goroutines, EntriesFilled: sc.Size}
+
+	var initial, filled, live runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&initial)
+	c := f.New(sc.Size)
+	for i := 0; i < sc.Size; i++ {
+		c.Set(int64(i), int64(i))
+	}
+	runtime.ReadMemStats(&filled)
+	runtime.GC() // count only memory still in use by the cache
+	runtime.ReadMemStats(&live)
+	if sc.Size > 0 {
+		res.HeapPerEntry = float64(live.HeapAlloc-min(live.HeapAlloc, initial.HeapAlloc)) / float64(sc.Size)
+		res.AllocsPerEntry = float64(filled.Mallocs-initial.Mallocs) / float64(sc.Size)
+	}
+	fillPause, fillGC := filled.PauseTotalNs-initial.PauseTotalNs, filled.NumGC-initial.NumGC
+
+	traces := make([][]op, sc.Goroutines)
+	for g := range traces {
+		r := rand.New(rand.NewSource(sc.Seed + int64(g))) //nolint:gosec // no need for crypto rand in benchmarks
+		next := sc.Workload(r, sc.Keys)
+		traces[g] = make([]op, sc.Ops)
+		for i := range traces[g] {
+			traces[g][i] = op{key: next(), read: r.Float64() < sc.ReadRatio}
+		}
+	}
+
+	var hits, gets int64
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for g := 0; g < sc.Goroutines; g++ {
+		wg.Add(1)
+		go func(trace []op) {
+			defer wg.Done()
+			var h, n int64
+			for _, o := range trace {
+				if !o.read {
+					c.Set(o.key, o.key)
+					continue
+				}
+				n++
+				if _, ok := c.Get(o.key); ok {
+					h++
+				}
+			}
+			mu.Lock()
+			hits, gets = hits+h, gets+n
+			mu.Unlock()
+		}(traces[g])
+	}
+	wg.Wait()
+	res.Duration = time.Since(start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(c)
+
+	if res.Ops > 0 {
+		res.NsPerOp = float64(res.Duration.Nanoseconds()) / float64(res.Ops)
+		res.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(res.Ops)
+	}
+	if gets > 0 {
+		res.HitRatio = float64(hits) / float64(gets)
+	}
+	res.GCPauseTotal = time.Duration(fillPause + after.PauseTotalNs - before.PauseTotalNs)
+	res.NumGC = fillGC + after.NumGC - before.NumGC
+	return res
+}
+
+// WriteJSON writes results as JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	return nil
+}
+
+// WriteCSV writes results as CSV with a header line
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"cache", "scenario", "ops", "duration_ns", "ns_per_op", "hit_ratio",
+		"heap_bytes_per_entry", "allocs_per_entry", "allocs_per_op", "gc_pause_ns", "num_gc", "entries_filled"}}
+	for _, r := range results {
+		rows = append(rows, []string{r.Cache, r.Scenario, strconv.Itoa(r.Ops),
+			strconv.FormatInt(r.Duration.Nanoseconds(), 10), strconv.FormatFloat(r.NsPerOp, 'f', 2, 64),
+			strconv.FormatFloat(r.HitRatio, 'f', 4, 64), strconv.FormatFloat(r.HeapPerEntry, 'f', 1, 64),
+			strconv.FormatFloat(r.AllocsPerEntry, 'f', 3, 64), strconv.FormatFloat(r.AllocsPerOp, 'f', 3, 64), strconv.FormatInt(r.GCPauseTotal.Nanoseconds(), 10),
+			strconv.FormatUint(uint64(r.NumGC), 10), strconv.Itoa(r.EntriesFilled)})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	scenarios := []Scenario{
+		{Name: "read-heavy", Size: 1000, Ops: 10000, ReadRatio: 0.9, Goroutines: 4, Seed: 1},
+		{Name: "all-hits", Size: 1000, Keys: 1000, Ops: 1000, ReadRatio: 1},
+	}
+	results := Run(factories(), scenarios)
+	require.Len(t, results, 8)
+	for _, r := range results {
+		assert.Positive(t, r.NsPerOp, r.Cache)
+		assert.Positive(t, r.HeapPerEntry, r.Cache)
+		assert.Equal(t, 1000, r.EntriesFilled)
+	}
+	assert.Equal(t, "read-heavy", results[0].Scenario)
+	assert.Equal(t, 40000, results[0].Ops)
+	assert.InDelta(t, 0.5, results[0].HitRatio, 0.1, "half of the keys are in the cache")
+	for _, r := range results[4:] {
+		assert.Equal(t, "all-hits", r.Scenario)
+		assert.InDelta(t, 1, r.HitRatio, 0.001, r.Cache)
+	}
+
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteCSV(&buf, results))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 9)
+	assert.True(t, strings.HasPrefix(lines[0], "cache,scenario,ops,"))
+	assert.True(t, strings.HasPrefix(lines[1], "expirable-cache/LRC,read-heavy,40000,"))
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, results))
+	var decoded []Result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, results, decoded)
+}
+
+// BenchmarkMemory_1M fills caches of different libraries with 1M entries and reports memory footprint
+// per entry, allocations per entry and GC pause time, which matter more than ns/op for large caches.
+// Run with -benchtime=1x, as each iteration fills a new cache.
+func BenchmarkMemory_1M(b *testing.B) {
+	for _, f := range factories() {
+		b.Run(f.Name, func(b *testing.B) {
+			var heap, allocs, pause float64
+			for i := 0; i < b.N; i++ {
+				r := runScenario(f, Scenario{Name: "fill", Size: 1_000_000})
+				heap, allocs, pause = heap+r.HeapPerEntry, allocs+r.AllocsPerEntry, pause+float64(r.GCPauseTotal)
+			}
+			n := float64(b.N)
+			b.ReportMetric(heap/n, "heap-B/entry")
+			b.ReportMetric(allocs/n, "allocs/entry")
+			b.ReportMetric(pause/n/1e6, "gc-pause-ms/op")
+		})
+	}
+}
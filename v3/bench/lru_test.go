@@ -0,0 +1,174 @@
+package bench
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+func getRand(tb testing.TB) int64 {
+	out, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return out.Int64()
+}
+
+func BenchmarkLRU_Rand_NoExpire(b *testing.B) {
+	l := cache.NewCache[int64, int64]().WithLRU().WithMaxKeys(8192)
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		trace[i] = getRand(b) % 32768
+	}
+
+	b.ResetTimer()
+
+	var hit, miss int
+	for i := 0; i < 2*b.N; i++ {
+		if i%2 == 0 {
+			l.Add(trace[i], trace[i])
+		} else {
+			if _, ok := l.Get(trace[i]); ok {
+				hit++
+			} else {
+				miss++
+			}
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
+
+func BenchmarkLRU_Freq_NoExpire(b *testing.B) {
+	l := cache.NewCache[int64, int64]().WithLRU().WithMaxKeys(8192)
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		if i%2 == 0 {
+			trace[i] = getRand(b) % 16384
+		} else {
+			trace[i] = getRand(b) % 32768
+		}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.Add(trace[i], trace[i])
+	}
+	var hit, miss int
+	for i := 0; i < b.N; i++ {
+		if _, ok := l.Get(trace[i]); ok {
+			hit++
+		} else {
+			miss++
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
+
+func BenchmarkLRU_Rand_WithExpire(b *testing.B) {
+	l := cache.NewCache[int64, int64]().WithLRU().WithMaxKeys(8192).WithTTL(time.Millisecond * 10)
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		trace[i] = getRand(b) % 32768
+	}
+
+	b.ResetTimer()
+
+	var hit, miss int
+	for i := 0; i < 2*b.N; i++ {
+		if i%2 == 0 {
+			l.Add(trace[i], trace[i])
+		} else {
+			if _, ok := l.Get(trace[i]); ok {
+				hit++
+			} else {
+				miss++
+			}
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
+
+func BenchmarkLRU_Freq_WithExpire(b *testing.B) {
+	l := cache.NewCache[int64, int64]().WithLRU().WithMaxKeys(8192).WithTTL(time.Millisecond * 10)
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		if i%2 == 0 {
+			trace[i] = getRand(b) % 16384
+		} else {
+			trace[i] = getRand(b) % 32768
+		}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.Add(trace[i], trace[i])
+	}
+	var hit, miss int
+	for i := 0; i < b.N; i++ {
+		if _, ok := l.Get(trace[i]); ok {
+			hit++
+		} else {
+			miss++
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
+
+// BenchmarkLRU_Large_Get measures lookups in a large cache, where the map access and the entry pointers
+// don't fit into CPU caches. The index case makes the same lookups in a bare map of the same size,
+// showing the part of the lookup cost taken by the map itself.
+func BenchmarkLRU_Large_Get(b *testing.B) {
+	for _, size := range []int{1 << 16, 1 << 20} {
+		trace := func(b *testing.B) []int64 {
+			res := make([]int64, b.N)
+			for i := 0; i < b.N; i++ {
+				res[i] = getRand(b) % int64(2*size) // half of the lookups miss
+			}
+			return res
+		}
+		b.Run(fmt.Sprintf("cache/size=%d", size), func(b *testing.B) {
+			l := cache.NewCache[int64, int64]().WithLRU().WithMaxKeys(size)
+			for i := 0; i < size; i++ {
+				l.Add(int64(i), int64(i))
+			}
+			keys := trace(b)
+
+			b.ResetTimer()
+			var hit int
+			for i := 0; i < b.N; i++ {
+				if _, ok := l.Get(keys[i]); ok {
+					hit++
+				}
+			}
+			b.ReportMetric(float64(hit)/float64(b.N), "hit-ratio")
+		})
+		b.Run(fmt.Sprintf("index/size=%d", size), func(b *testing.B) {
+			m := make(map[int64]*int64, size)
+			for i := 0; i < size; i++ {
+				v := int64(i)
+				m[v] = &v
+			}
+			keys := trace(b)
+
+			b.ResetTimer()
+			var hit int
+			for i := 0; i < b.N; i++ {
+				if _, ok := m[keys[i]]; ok {
+					hit++
+				}
+			}
+			b.ReportMetric(float64(hit)/float64(b.N), "hit-ratio")
+		})
+	}
+}
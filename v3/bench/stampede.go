@@ -0,0 +1,151 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+// StampedeMode is a way of protecting a hot key from cache stampede on its expiration
+type StampedeMode string
+
+// Stampede protection modes compared by RunStampede
+const (
+	StampedeNone         StampedeMode = "none"                   // Get, then load and Set on miss
+	StampedeSingleflight StampedeMode = "singleflight"           // GetCtx sharing a single loader call
+	StampedeXFetch       StampedeMode = "xfetch"                 // probabilistic early recomputation before expiration
+	StampedeStale        StampedeMode = "stale-while-revalidate" // stale value returned while a single refresh runs
+)
+
+// StampedeScenario describes goroutines reading a single hot key, which expires every TTL and takes LoadTime to load
+type StampedeScenario struct {
+	Goroutines int
+	TTL        time.Duration
+	LoadTime   time.Duration
+	Duration   time.Duration // time the goroutines read the key for
+	Interval   time.Duration // pause between reads of each goroutine, which sets the request rate
+	Beta       float64       // XFetch beta, higher values recompute earlier, 1 if not set
+	Seed       int64         // random seed of XFetch decisions
+}
+
+// StampedeResult is a result of a stampede scenario run with a protection mode
+type StampedeResult struct {
+	Mode           StampedeMode  `json:"mode"`
+	Gets           int64         `json:"gets"`
+	Loads          int64         `json:"loads"`           // loader calls, the first load before the run is not counted
+	DuplicateLoads int64         `json:"duplicate_loads"` // loads started while another load of the key was running
+	MaxConcurrent  int64         `json:"max_concurrent_loads"`
+	MaxWait        time.Duration `json:"max_wait_ns"` // the longest time a goroutine waited for the value
+}
+
+// RunStampede runs the scenario with each mode, in order, counting loader calls of the hot key
+func RunStampede(sc StampedeScenario, modes ...StampedeMode) []StampedeResult {
+	if sc.Goroutines <= 0 {
+		sc.Goroutines = 1
+	}
+	if sc.Beta <= 0 {
+		sc.Beta = 1
+	}
+	res := make([]StampedeResult, 0, len(modes))
+	for _, mode := range modes {
+		res = append(res, runStampede(sc, mode))
+	}
+	return res
+}
+
+// stampedeLoader is a slow loader counting concurrent calls
+type stampedeLoader struct {
+	delay                           time.Duration
+	loads, duplicates, running, max int64
+}
+
+func (l *stampedeLoader) load(context.Context, int64) (int64, error) {
+	atomic.AddInt64(&l.loads, 1)
+	n := atomic.AddInt64(&l.running, 1)
+	defer atomic.AddInt64(&l.running, -1)
+	if n > 1 {
+		atomic.AddInt64(&l.duplicates, 1)
+	}
+	for {
+		m := atomic.LoadInt64(&l.max)
+		if n <= m || atomic.CompareAndSwapInt64(&l.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(l.delay)
+	return time.Now().UnixNano(), nil
+}
+
+// runStampede reads the hot key from goroutines for the scenario duration, getting it the way the mode does
+func runStampede(sc StampedeScenario, mode StampedeMode) StampedeResult {
+	const key = 1
+	l := &stampedeLoader{delay: sc.LoadTime}
+	c := cache.NewCache[int64, int64]().WithTTL(sc.TTL)
+	switch mode {
+	case StampedeSingleflight:
+		c = c.WithLoader(l.load)
+	case StampedeStale:
+		c = c.WithLoader(l.load).WithBurstExtension(1, sc.TTL, sc.TTL)
+	}
+	c.Set(key, 0, 0)
+
+	// fetch returns the key value, loading it on miss the way the mode does
+	fetch := func(r *rand.Rand) {
+		switch mode {
+		case StampedeSingleflight, StampedeStale:
+			_, _ = c.GetCtx(context.Background(), key)
+			return
+		case StampedeXFetch:
+			// recompute early with probability growing as expiration nears, see "Optimal Probabilistic Cache
+			// Stampede Prevention" by Vattani et al.
+			if _, ok := c.Get(key); ok {
+				exp, _ := c.GetExpiration(key)
+				early := time.Duration(float64(sc.LoadTime) * sc.Beta * -math.Log(1-r.Float64()))
+				if time.Now().Add(early).Before(exp) {
+					return
+				}
+			}
+		default:
+			if _, ok := c.Get(key); ok {
+				return
+			}
+		}
+		v, _ := l.load(context.Background(), key)
+		c.Set(key, v, 0)
+	}
+
+	res := StampedeResult{Mode: mode}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(sc.Duration)
+	for g := 0; g < sc.Goroutines; g++ {
+		wg.Add(1)
+		go func(r *rand.Rand) {
+			defer wg.Done()
+			var gets int64
+			var maxWait time.Duration
+			for time.Now().Before(deadline) {
+				start := time.Now()
+				fetch(r)
+				maxWait = max(maxWait, time.Since(start))
+				gets++
+				if sc.Interval > 0 {
+					time.Sleep(sc.Interval)
+				}
+			}
+			mu.Lock()
+			res.Gets, res.MaxWait = res.Gets+gets, max(res.MaxWait, maxWait)
+			mu.Unlock()
+		}(rand.New(rand.NewSource(sc.Seed + int64(g)))) //nolint:gosec // no need for crypto rand in benchmarks
+	}
+	wg.Wait()
+	_ = c.Close() // refresh started by the last Get may be still running, counters are read atomically
+	res.Loads, res.DuplicateLoads = atomic.LoadInt64(&l.loads), atomic.LoadInt64(&l.duplicates)
+	res.MaxConcurrent = atomic.LoadInt64(&l.max)
+	return res
+}
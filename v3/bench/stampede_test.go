@@ -0,0 +1,29 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStampede(t *testing.T) {
+	sc := StampedeScenario{Goroutines: 16, TTL: 20 * time.Millisecond, LoadTime: 5 * time.Millisecond,
+		Duration: 200 * time.Millisecond, Interval: time.Millisecond, Seed: 1}
+	results := RunStampede(sc, StampedeNone, StampedeSingleflight, StampedeXFetch, StampedeStale)
+	require.Len(t, results, 4)
+	byMode := map[StampedeMode]StampedeResult{}
+	for _, r := range results {
+		byMode[r.Mode] = r
+		assert.Positive(t, r.Gets, r.Mode)
+		assert.Positive(t, r.Loads, r.Mode)
+	}
+
+	assert.Positive(t, byMode[StampedeNone].DuplicateLoads, "every goroutine loads on expiration")
+	assert.Greater(t, byMode[StampedeNone].MaxConcurrent, int64(1))
+	assert.Zero(t, byMode[StampedeSingleflight].DuplicateLoads, "single load per expiration")
+	assert.Zero(t, byMode[StampedeStale].DuplicateLoads, "single refresh per expiration")
+	assert.Less(t, byMode[StampedeXFetch].Loads, byMode[StampedeNone].Loads, "early recompute avoids most stampedes")
+	assert.Less(t, byMode[StampedeStale].MaxWait, byMode[StampedeSingleflight].MaxWait, "stale value doesn't wait for load")
+}
@@ -0,0 +1,72 @@
+package bench
+
+import "math/rand"
+
+// Workload makes key generator of a single goroutine, for keys in [0, keys) range unless stated otherwise.
+// Each goroutine gets its own random source, seeded by the scenario seed.
+type Workload func(r *rand.Rand, keys int64) func() int64
+
+// Uniform returns workload with all keys equally likely
+func Uniform() Workload {
+	return func(r *rand.Rand, keys int64) func() int64 {
+		return func() int64 { return r.Int63n(keys) }
+	}
+}
+
+// Zipf returns workload with zipfian distribution of keys, where key k is requested proportionally to 1/(k+1)^s.
+// Typical web traffic has s around 1.01-1.2, values of s not greater than 1 are replaced by 1.01.
+func Zipf(s float64) Workload {
+	if s <= 1 {
+		s = 1.01
+	}
+	return func(r *rand.Rand, keys int64) func() int64 {
+		z := rand.NewZipf(r, s, 1, uint64(keys-1))
+		return func() int64 { return int64(z.Uint64()) } //nolint:gosec // keys is int64, so is the result
+	}
+}
+
+// Hotspot returns workload with hotRatio of requests going to hotKeys part of the keys, e.g. 0.2, 0.8
+// sends 80% of requests to 20% of the keys, the rest of the requests are spread uniformly over other keys
+func Hotspot(hotKeys, hotRatio float64) Workload {
+	return func(r *rand.Rand, keys int64) func() int64 {
+		hot := max(1, min(keys-1, int64(float64(keys)*hotKeys)))
+		return func() int64 {
+			if r.Float64() < hotRatio {
+				return r.Int63n(hot)
+			}
+			return hot + r.Int63n(keys-hot)
+		}
+	}
+}
+
+// Scan returns workload requesting all the keys sequentially, wrapping around, which defeats LRU caches
+// smaller than the key range. Each goroutine starts from a random key.
+func Scan() Workload {
+	return func(r *rand.Rand, keys int64) func() int64 {
+		next := r.Int63n(keys)
+		return func() int64 {
+			key := next
+			next = (next + 1) % keys
+			return key
+		}
+	}
+}
+
+// Churn returns workload with uniform distribution over a window of keys which slides by one key
+// every shiftEvery requests, so the working set changes over time and old keys are never requested again.
+// Keys are not limited by the range, the window starts as [0, keys).
+func Churn(shiftEvery int) Workload {
+	if shiftEvery < 1 {
+		shiftEvery = 1
+	}
+	return func(r *rand.Rand, keys int64) func() int64 {
+		var offset int64
+		n := 0
+		return func() int64 {
+			if n++; n%shiftEvery == 0 {
+				offset++
+			}
+			return offset + r.Int63n(keys)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package bench
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkloads(t *testing.T) {
+	sample := func(w Workload, n int) []int64 {
+		next := w(rand.New(rand.NewSource(1)), 1000)
+		res := make([]int64, n)
+		for i := range res {
+			res[i] = next()
+		}
+		return res
+	}
+	share := func(keys []int64, below int64) float64 {
+		cnt := 0
+		for _, k := range keys {
+			if k < below {
+				cnt++
+			}
+		}
+		return float64(cnt) / float64(len(keys))
+	}
+
+	for _, k := range sample(Uniform(), 10000) {
+		assert.True(t, k >= 0 && k < 1000)
+	}
+	assert.InDelta(t, 0.1, share(sample(Uniform(), 10000), 100), 0.02)
+	assert.Greater(t, share(sample(Zipf(1.1), 10000), 10), 0.4, "1% of keys get a large part of requests")
+	assert.InDelta(t, 0.8, share(sample(Hotspot(0.2, 0.8), 10000), 200), 0.02)
+
+	scan := sample(Scan(), 2000)
+	for i := 1; i < len(scan); i++ {
+		assert.Equal(t, (scan[i-1]+1)%1000, scan[i])
+	}
+
+	churn := sample(Churn(10), 10000)
+	assert.Zero(t, share(churn[9000:], 900), "old keys are not requested")
+}
+
+func TestRunWorkloads(t *testing.T) {
+	fs := factories()[:1]
+	results := Run(fs, []Scenario{
+		{Name: "uniform", Size: 1000, Keys: 10000, Ops: 20000, ReadRatio: 0.8, Seed: 1},
+		{Name: "zipf", Size: 1000, Keys: 10000, Ops: 20000, ReadRatio: 0.8, Seed: 1, Workload: Zipf(1.1)},
+	})
+	assert.Greater(t, results[1].HitRatio, results[0].HitRatio+0.2, "skewed traffic hits more")
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// burstExtension extends just expired entries on a burst of misses, set by WithBurstExtension
+type burstExtension struct {
+	misses    int           // misses of the expired entry triggering the extension
+	extension time.Duration // time the entry is extended by, misses are counted within it after expiration
+	limit     time.Duration // total extension limit until the entry is refreshed
+}
+
+// extendOnBurst counts miss of the expired item, extending it and starting the refresh once the burst is detected.
+// Returns true if the item is extended and its value can be returned. Has to be called with lock!
+func (c *cacheImpl[K, V]) extendOnBurst(item *cacheItem[K, V], now time.Time) bool {
+	if c.burst.misses <= 0 || c.loader == nil || c.closed || c.staleEpoch(item) {
+		return false
+	}
+	if now.Sub(c.expiration(item)) > c.burst.extension {
+		item.staleMisses = 0 // expired long ago, not a burst for a just expired entry
+		return false
+	}
+	item.staleMisses++
+	if item.staleMisses < c.burst.misses {
+		return false
+	}
+	ext := min(c.burst.extension, c.burst.limit-item.extended)
+	if ext <= 0 {
+		return false
+	}
+	item.staleMisses = 0
+	item.extended += ext
+	item.expiresAt = now.Add(ext)
+	c.updateReadView(item.key)
+	c.refresh(item.key)
+	c.logDebug("expired entry extended on burst of misses", slog.Any("key", item.key),
+		slog.Duration("extension", ext), slog.Duration("extended", item.extended))
+	return true
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_BurstExtension(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	lc := NewCache[string, string]().WithBurstExtension(3, time.Second, 2*time.Second).
+		WithLoader(func(_ context.Context, key string) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return "new", nil
+		})
+
+	lc.Set("key1", "old", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		_, ok := lc.Get("key1")
+		assert.False(t, ok, "not a burst yet")
+	}
+	for i := 0; i < 10; i++ {
+		v, ok := lc.Get("key1")
+		assert.True(t, ok, "extended on burst")
+		assert.Equal(t, "old", v)
+	}
+	exp, ok := lc.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), exp, 100*time.Millisecond)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, Stats{Hits: 10, Misses: 2, Added: 1}, lc.Stat(), "extended gets counted as hits")
+
+	close(release)
+	require.Eventually(t, func() bool {
+		v, _ := lc.Peek("key1")
+		return v == "new"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "single refresh for the whole burst")
+}
+
+func TestCache_BurstExtensionLimit(t *testing.T) {
+	var calls int32
+	lc := NewCache[string, string]().WithBurstExtension(1, 100*time.Millisecond, 150*time.Millisecond).
+		WithLoader(func(_ context.Context, key string) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", errors.New("backend is down")
+		})
+
+	lc.Set("key1", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	v, ok := lc.Get("key1")
+	assert.True(t, ok, "extended by 100ms")
+	assert.Equal(t, "old", v)
+	time.Sleep(120 * time.Millisecond)
+	_, ok = lc.Get("key1")
+	assert.True(t, ok, "extended by remaining 50ms")
+	time.Sleep(70 * time.Millisecond)
+	_, ok = lc.Get("key1")
+	assert.False(t, ok, "extension limit reached")
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, time.Millisecond)
+
+	lc.Set("key2", "old", time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	_, ok = lc.Get("key2")
+	assert.False(t, ok, "expired long ago, not extended")
+
+	lc = NewCache[string, string]().WithBurstExtension(1, time.Second, time.Second)
+	lc.Set("key1", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = lc.Get("key1")
+	assert.False(t, ok, "not extended without loader")
+}
+
+func TestCache_BurstExtensionLockFree(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	lc := NewCache[string, string]().WithLockFreeReads().WithBurstExtension(2, time.Second, 2*time.Second).
+		WithLoader(func(_ context.Context, key string) (string, error) {
+			<-release
+			return "new", nil
+		})
+	lc.Set("key1", "old", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_, ok := lc.Peek("key1")
+	assert.False(t, ok, "expired in read view")
+	lc.Get("key1")
+	_, ok = lc.Get("key1")
+	assert.True(t, ok, "extended on burst")
+	v, ok := lc.Peek("key1")
+	assert.True(t, ok, "extension visible in read view")
+	assert.Equal(t, "old", v)
+}
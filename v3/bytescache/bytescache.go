@@ -0,0 +1,213 @@
+// Package bytescache provides cache of byte slices keyed by strings, specialized for proxy-like use cases:
+// values are returned without copying, the size limit is in bytes of keys and values, and large values
+// can be stored in anonymous memory maps outside the Go heap, so they don't add to GC work.
+package bytescache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+// DefaultMmapThreshold is the smallest size of values stored in memory maps, if they are enabled
+const DefaultMmapThreshold = 64 * 1024
+
+// ErrMmapUnsupported is returned by New in case Mmap is set on a platform without memory maps support
+var ErrMmapUnsupported = errors.New("memory maps are not supported on this platform")
+
+// Options of Cache
+type Options struct {
+	MaxBytes      int64         // limit of keys and values size in bytes, 0 for no limit
+	MaxEntryBytes int64         // limit of a single key and value size, larger values are not stored, 0 for no limit
+	TTL           time.Duration // TTL of entries, entries don't expire if 0
+	LRU           bool          // evict the least recently used entries instead of the least recently created ones
+	Mmap          bool          // store values of MmapThreshold bytes and larger in anonymous memory maps
+	MmapThreshold int           // DefaultMmapThreshold if not set
+
+	// Checksum of values, e.g. cache.CRC32, validated on each Get, so corrupted values are treated
+	// as misses and removed rather than served. It's stored with the value, taking 8 bytes.
+	Checksum cache.Checksum
+}
+
+// checksumSize is a size of the checksum stored after the value
+const checksumSize = 8
+
+// Cache is a cache of byte slices keyed by strings. Values are copied on Set, and Get returns the cached
+// slice without copying, which must not be modified. In case Mmap is set, Get returns a copy instead,
+// as memory maps of values are released on eviction, while the returned slice may be still in use.
+type Cache struct {
+	c             cache.Cache[string, []byte]
+	mmapThreshold int // 0 if memory maps are disabled
+	maxEntryBytes int64
+	checksum      cache.Checksum
+	corrupted     atomic.Uint64
+
+	writeMu sync.Mutex // serializes writes with memory maps, so mapped values are never overwritten in place
+	closed  bool
+	mapped  atomic.Int64 // size of values in memory maps
+}
+
+// New makes a new Cache with options. Returns ErrMmapUnsupported if Mmap is set on unsupported platform.
+func New(opts Options) (*Cache, error) {
+	if opts.Mmap && !mmapSupported {
+		return nil, ErrMmapUnsupported
+	}
+	res := &Cache{checksum: opts.Checksum, maxEntryBytes: opts.MaxEntryBytes}
+	c := cache.NewCache[string, []byte]().WithMaxCost(opts.MaxBytes, func(key string, value []byte) int64 {
+		return int64(len(key) + len(value))
+	})
+	if opts.TTL > 0 {
+		c = c.WithTTL(opts.TTL)
+	}
+	if opts.LRU {
+		c = c.WithLRU()
+	}
+	if opts.MaxEntryBytes > 0 {
+		// the inner cost includes the checksum stored with the value, which isn't counted against the limit
+		limit := opts.MaxEntryBytes
+		if opts.Checksum != nil {
+			limit += checksumSize
+		}
+		c = c.WithMaxEntryCost(limit)
+	}
+	if opts.Mmap {
+		res.mmapThreshold = opts.MmapThreshold
+		if res.mmapThreshold <= 0 {
+			res.mmapThreshold = DefaultMmapThreshold
+		}
+		// copy is made under the cache lock, so the value can't be released while it's copied
+		c = c.WithCopyOnGet(bytes.Clone).WithOnEvicted(func(_ string, value []byte) { res.release(value) })
+	}
+	res.c = c
+	return res, nil
+}
+
+// Get returns the key value if it's in the cache and not expired. Value failing checksum validation
+// is removed and reported as a miss.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	value, ok := c.c.Get(key)
+	if !ok || c.checksum == nil {
+		return value, ok
+	}
+	n := len(value) - checksumSize
+	if n < 0 || binary.LittleEndian.Uint64(value[n:]) != c.checksum(value[:n]) {
+		c.corrupted.Add(1)
+		c.c.Remove(key)
+		return nil, false
+	}
+	return value[:n:n], true // capacity limited, so append doesn't overwrite the checksum
+}
+
+// Set copies the value into the cache with ttl, the cache-wide TTL is used if ttl is 0.
+// Value larger than MaxEntryBytes is not copied, the key is removed instead and the write is counted
+// in RejectedTooLarge stats.
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
+	if c.maxEntryBytes > 0 && int64(len(key)+len(value)) > c.maxEntryBytes {
+		c.reject(key, value, ttl)
+		return
+	}
+	if c.mmapThreshold == 0 {
+		c.c.Set(key, c.alloc(value), ttl)
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return
+	}
+	c.c.Remove(key) // releases the old value with OnEvicted, as overwrite doesn't call it
+	c.c.Set(key, c.alloc(value), ttl)
+}
+
+// reject passes the value larger than MaxEntryBytes to the inner cache without copying, which rejects it,
+// counting the write and removing the outdated value. Value less than the checksum size above the limit
+// would fit the inner limit without the checksum, so it's replaced by a placeholder of its stored size.
+func (c *Cache) reject(key string, value []byte, ttl time.Duration) {
+	if c.checksum != nil && int64(len(key)+len(value)) <= c.maxEntryBytes+checksumSize {
+		value = make([]byte, len(value)+checksumSize)
+	}
+	c.c.Set(key, value, ttl)
+}
+
+// Remove removes the key, returning if it was in the cache
+func (c *Cache) Remove(key string) bool {
+	return c.c.Remove(key)
+}
+
+// Len returns number of entries in the cache, including expired ones not deleted yet
+func (c *Cache) Len() int {
+	return c.c.Len()
+}
+
+// Corrupted returns number of values failed checksum validation
+func (c *Cache) Corrupted() uint64 {
+	return c.corrupted.Load()
+}
+
+// MappedBytes returns size of values stored in memory maps
+func (c *Cache) MappedBytes() int64 {
+	return c.mapped.Load()
+}
+
+// Stat returns the cache stats
+func (c *Cache) Stat() cache.Stats {
+	return c.c.Stat()
+}
+
+// DeleteExpired deletes expired entries, it should be called periodically to release memory they hold
+func (c *Cache) DeleteExpired() {
+	c.c.DeleteExpired()
+}
+
+// Purge deletes all the entries
+func (c *Cache) Purge() {
+	c.c.Purge()
+}
+
+// Close deletes all the entries, releasing memory maps, and makes further Set calls no-op
+func (c *Cache) Close() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.closed = true
+	return c.c.Close()
+}
+
+// alloc copies the value followed by its checksum if set, into a new memory map in case it's large enough.
+// Falls back to the heap copy in case the memory map can't be made.
+func (c *Cache) alloc(value []byte) []byte {
+	size := len(value)
+	if c.checksum != nil {
+		size += checksumSize
+	}
+	var buf []byte
+	if c.mmapThreshold > 0 && size >= c.mmapThreshold {
+		var err error
+		if buf, err = mmap(size); err == nil {
+			c.mapped.Add(int64(size))
+		}
+	}
+	if buf == nil {
+		buf = make([]byte, size)
+	}
+	copy(buf, value)
+	if c.checksum != nil {
+		binary.LittleEndian.PutUint64(buf[len(value):], c.checksum(value))
+	}
+	return buf
+}
+
+// release unmaps the value in case it's stored in a memory map, called by OnEvicted under the cache lock
+func (c *Cache) release(value []byte) {
+	if len(value) < c.mmapThreshold {
+		return
+	}
+	// munmap fails for heap copies made after mmap failure, which have nothing to release
+	if err := munmap(value[:cap(value)]); err == nil {
+		c.mapped.Add(-int64(len(value)))
+	}
+}
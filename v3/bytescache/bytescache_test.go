@@ -0,0 +1,137 @@
+package bytescache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+func TestCache(t *testing.T) {
+	c, err := New(Options{MaxBytes: 100})
+	require.NoError(t, err)
+	value := []byte("val1")
+	c.Set("key1", value, 0)
+	value[0] = 'X'
+	v1, ok := c.Get("key1")
+	require.True(t, ok)
+	assert.Equal(t, []byte("val1"), v1, "value copied on set")
+	v2, _ := c.Get("key1")
+	assert.Same(t, &v1[0], &v2[0], "no copy on get")
+
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key%d", i+2), bytes.Repeat([]byte("x"), 15), 0) // 19-20 bytes each
+	}
+	assert.Equal(t, 5, c.Len(), "limited by bytes")
+	_, ok = c.Get("key1")
+	assert.False(t, ok)
+
+	assert.True(t, c.Remove("key11"))
+	assert.Equal(t, 4, c.Len())
+	c.Purge()
+	assert.Zero(t, c.Len())
+	require.NoError(t, c.Close())
+}
+
+func TestCacheTTL(t *testing.T) {
+	c, err := New(Options{TTL: 10 * time.Millisecond, LRU: true})
+	require.NoError(t, err)
+	c.Set("key1", []byte("val1"), 0)
+	c.Set("key2", []byte("val2"), time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	_, ok := c.Get("key1")
+	assert.False(t, ok)
+	c.DeleteExpired()
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(1), c.Stat().Misses)
+}
+
+func TestCacheMmap(t *testing.T) {
+	if !mmapSupported {
+		_, err := New(Options{Mmap: true})
+		assert.ErrorIs(t, err, ErrMmapUnsupported)
+		t.Skip("memory maps are not supported")
+	}
+	c, err := New(Options{Mmap: true, MmapThreshold: 1024, MaxBytes: 10000})
+	require.NoError(t, err)
+	large := bytes.Repeat([]byte("x"), 4000)
+	c.Set("small", []byte("val"), 0)
+	c.Set("large1", large, 0)
+	assert.Equal(t, int64(4000), c.MappedBytes())
+
+	v1, ok := c.Get("large1")
+	require.True(t, ok)
+	assert.Equal(t, large, v1)
+	v2, _ := c.Get("large1")
+	assert.NotSame(t, &v1[0], &v2[0], "mapped values copied on get")
+
+	c.Set("large1", bytes.Repeat([]byte("y"), 3000), 0)
+	assert.Equal(t, int64(3000), c.MappedBytes(), "old value released on overwrite")
+	c.Set("large2", large, 0)
+	c.Set("large3", large, 0) // evicts small and large1
+	assert.Equal(t, int64(8000), c.MappedBytes(), "evicted value released")
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, large, v1, "copy is still valid after release")
+
+	assert.True(t, c.Remove("large2"))
+	assert.Equal(t, int64(4000), c.MappedBytes())
+	require.NoError(t, c.Close())
+	assert.Zero(t, c.MappedBytes(), "all maps released on close")
+	c.Set("large4", large, 0)
+	assert.Zero(t, c.MappedBytes(), "set after close is no-op")
+}
+
+func TestCacheChecksum(t *testing.T) {
+	for _, mmap := range []bool{false, mmapSupported} {
+		c, err := New(Options{Checksum: cache.CRC32, Mmap: mmap, MmapThreshold: 16})
+		require.NoError(t, err)
+		c.Set("key1", []byte("val1"), 0)
+		c.Set("key2", []byte("a longer value, stored in a memory map"), 0)
+		v, ok := c.Get("key1")
+		require.True(t, ok)
+		assert.Equal(t, []byte("val1"), v)
+		assert.Equal(t, 4, cap(v), "checksum is not exposed")
+		v, ok = c.Get("key2")
+		require.True(t, ok)
+		assert.Equal(t, []byte("a longer value, stored in a memory map"), v)
+
+		stored, _ := c.c.Peek("key1")
+		stored[0] = 'X' // corrupt value in place, Peek returns a copy with mmap, so it is written back
+		if mmap {
+			c.c.Set("key1", stored, 0)
+		}
+		_, ok = c.Get("key1")
+		assert.False(t, ok, "corrupted value is a miss")
+		assert.Equal(t, uint64(1), c.Corrupted())
+		assert.Equal(t, 1, c.Len(), "corrupted value removed")
+		require.NoError(t, c.Close())
+	}
+}
+
+func TestCacheMaxEntryBytes(t *testing.T) {
+	c, err := New(Options{MaxBytes: 100, MaxEntryBytes: 20})
+	require.NoError(t, err)
+	c.Set("key1", []byte("small value"), 0)
+	c.Set("key2", bytes.Repeat([]byte("x"), 20), 0)
+	assert.Equal(t, 1, c.Len(), "too large value rejected")
+
+	c.Set("key1", bytes.Repeat([]byte("x"), 20), 0)
+	_, ok := c.Get("key1")
+	assert.False(t, ok, "outdated value removed")
+	assert.Equal(t, uint64(2), c.Stat().RejectedTooLarge)
+
+	c, err = New(Options{MaxBytes: 100, MaxEntryBytes: 20, Checksum: cache.CRC32})
+	require.NoError(t, err)
+	c.Set("key1", bytes.Repeat([]byte("x"), 16), 0)
+	_, ok = c.Get("key1")
+	assert.True(t, ok, "value at the limit stored, checksum is not counted")
+	c.Set("key1", bytes.Repeat([]byte("x"), 17), 0)
+	_, ok = c.Get("key1")
+	assert.False(t, ok, "value just above the limit rejected")
+	assert.Equal(t, uint64(1), c.Stat().RejectedTooLarge)
+}
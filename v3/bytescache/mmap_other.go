@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package bytescache
+
+import "errors"
+
+const mmapSupported = false
+
+func mmap(int) ([]byte, error) { return nil, errors.New("mmap is not supported") }
+
+func munmap([]byte) error { return errors.New("munmap is not supported") }
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package bytescache
+
+import "syscall"
+
+const mmapSupported = true
+
+// mmap makes anonymous private memory map of size bytes, outside the Go heap
+func mmap(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+// munmap releases memory map made by mmap
+func munmap(b []byte) error {
+	return syscall.Munmap(b)
+}
@@ -1,7 +1,9 @@
 // Package cache implements Cache similar to hashicorp/golang-lru
 //
 // Support LRC, LRU and TTL-based eviction.
-// Package is thread-safe and doesn't spawn any goroutines.
+// Package is thread-safe and doesn't spawn any goroutines, except for loader calls and background refreshes,
+// write-behind flushes set by WithWriteBehind, and OnEvicted workers set by WithAsyncOnEvicted, which run
+// until Close is called.
 // On every Set() call, cache deletes single oldest entry in case it's expired.
 // In case MaxSize is set, cache deletes the oldest entry disregarding its expiration date to maintain the size,
 // either using LRC or LRU eviction.
@@ -14,7 +16,13 @@ package cache
 
 import (
 	"container/list"
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 )
@@ -22,49 +30,196 @@ import (
 // Cache defines cache interface
 type Cache[K comparable, V any] interface {
 	fmt.Stringer
+	slog.LogValuer
 	options[K, V]
 	Add(key K, value V) bool
 	Set(key K, value V, ttl time.Duration)
+	SetWithDeps(key K, value V, ttl time.Duration, deps ...K)
+	SetWithSoftHardTTL(key K, value V, soft, hard time.Duration)
+	SetWithMeta(key K, value V, ttl time.Duration, meta map[string]string)
+	Txn(fn func(tx Tx[K, V]) error) error
+	Swap(key K, value V, ttl time.Duration) (V, bool)
 	Get(key K) (V, bool)
+	GetE(key K) (V, error)
+	GetCtx(ctx context.Context, key K) (V, error)
+	Wait(ctx context.Context, key K) (V, bool, error)
+	GetAsync(key K) <-chan Result[V]
+	Flush(ctx context.Context) error
 	GetExpiration(key K) (time.Time, bool)
+	GetCreation(key K) (time.Time, bool)
+	SuggestedTTL(key K) (time.Duration, bool)
 	GetOldest() (K, V, bool)
+	EvictionOrder() []K
 	Contains(key K) (ok bool)
+	ContainsMany(keys ...K) []bool
 	Peek(key K) (V, bool)
+	GetQuiet(key K) (V, bool)
+	GetEntry(key K) (Entry[K, V], bool)
+	GetStale(key K) (value V, stale, ok bool)
 	Values() []V
 	Keys() []K
+	KeysPage(offset, limit int) []K
+	SortedKeys() []K
+	KeysBetween(lo, hi K) []K
 	Len() int
 	Remove(key K) bool
+	Expire(key K, notify bool) bool
 	Invalidate(key K)
 	InvalidateFn(fn func(key K) bool)
+	InvalidateMany(keys ...K) int
+	InvalidateOlderThan(t time.Time) int
+	InvalidateByIndex(name, indexValue string)
+	KeysByIndex(name, indexValue string) []K
+	FindKeys(value V) []K
 	RemoveOldest() (K, V, bool)
 	DeleteExpired()
+	Maintain() MaintenanceReport
+	LastCleanup() time.Time
+	NextSuggestedCleanup() time.Time
 	Purge()
+	Clear()
+	Epoch() uint64
+	BumpEpoch() uint64
+	Close() error
 	Resize(int) int
+	MaxKeys() int
+	TrimToSize(size int) int
+	UpdateCost(key K, cost int64) bool
+	RecalculateCosts() int
 	Stat() Stats
+	StatsRate() Rates
+	LockProfile() map[Op]LockTimes
+	DebugString() string
+	Validate() []Warning
+	Name() string
+	Labels() map[string]string
+	StatsByNamespace() map[string]Stats
+	StatsDetailed() DetailedStats
+	ExpirationBuckets(width time.Duration) map[time.Time]int
+	WouldHaveHit() []CapacityEstimate
+	EstimateFrequency(key K) int
+	WriteSnapshot(w io.Writer) error
+	SnapshotView() View[K, V]
+	Range(fn func(e Entry[K, V]) bool)
+	ReadSnapshot(r io.Reader) error
 }
 
-// Stats provides statistics for cache
+// Stats provides statistics for cache. Counters wrap around to 0 on uint64 overflow, which takes centuries
+// at realistic rates. Stats are kept by Purge, with purged entries counted in Evicted, unless WithStatsResetOnPurge
+// is set, which increments Resets, so deltas between two Stat calls should be computed with Sub, which handles the reset.
 type Stats struct {
-	Hits, Misses   int // cache effectiveness
-	Added, Evicted int // number of added and evicted records
+	Hits, Misses         uint64 // cache effectiveness
+	Added, Evicted       uint64 // number of added and evicted records
+	PeekHits, PeekMisses uint64 // Peek effectiveness, Peek calls are counted in Hits and Misses as well by default
+	EvictedEarly         uint64 // evicted to maintain the size with a large part of TTL remaining, MaxKeys may be too small
+	EvictedOnOverwrite   uint64 // evicted to fit the cost limit after an overwrite increased the entry cost
+	RejectedTooLarge     uint64 // writes rejected for the entry cost above the limit set by WithMaxEntryCost
+
+	MissCost     time.Duration // total cost of misses, set by WithMissCost or measured on loader calls
+	CostedMisses uint64        // number of miss costs summed in MissCost
+
+	Resets uint64 // number of stats resets by Purge with WithStatsResetOnPurge, kept over the reset
 }
 
 // cacheImpl provides Cache interface implementation.
 type cacheImpl[K comparable, V any] struct {
-	ttl       time.Duration
-	maxKeys   int
-	isLRU     bool
-	onEvicted func(key K, value V)
+	name          string
+	labels        map[string]string
+	noStringStats bool // exclude stats from String output
+
+	resetStatsOnPurge bool
+
+	ttl         time.Duration
+	zeroTTL     time.Duration // ttl used for Set with ttl of 0, set by WithZeroTTL, 0 for cache-wide TTL
+	jitter      float64       // part of TTL randomly added to or subtracted from it, set by WithTTLJitter
+	rand        *rand.Rand    // random source of the jitter, made on the first use if not set by WithRand
+	maxKeys     int
+	isLRU       bool
+	isClock     bool
+	promoteSize int
+	promoteBuf  []*list.Element // entries accessed by Get, not moved to the front yet, each one once
+	promoteSeq  uint64          // sequence of accesses recorded in promoteBuf
+	peekApart   bool            // don't count Peek in Hits and Misses
+	earlyRate   float64         // part of TTL remaining for eviction to be counted in EvictedEarly
+	onEvicted   func(key K, value V)
+	evictPool   evictPool[K, V]
+	onDemote    func(key K, value V, expiresAt time.Time)
+	onReplaced  func(key K, old, value V)
+	onPanic     func(key K, value V, recovered any)
+	copyOnGet   func(value V) V
+	normalize   func(value V) V // applied to values before they are stored, set by WithNormalizeValue
+	logger      *slog.Logger
+
+	snapshotKey   []byte   // AES key for snapshot encryption
+	checksum      Checksum // checksum of snapshot entries
+	keyEncode     func(key K) ([]byte, error)
+	keyDecode     func(data []byte) (K, error)
+	namespaceFn   func(key K) string
+	pins          map[K]int // keys pinned by Range callbacks, with number of pins
+	tombstones    tombstones[K]
+	doorkeeper    *doorkeeper
+	missFilter    *missFilter
+	sketch        *countMinSketch
+	admission     func(key K, value V, cost int64) bool
+	loader        func(ctx context.Context, key K) (LoadResult[V], error)
+	loaderLimiter Limiter
+	missCost      func(key K) time.Duration
+	parent        Cache[K, V] // cache read through on miss, set by NewChild
+
+	refreshAhead     time.Duration
+	refreshWorkers   int
+	refreshQueueSize int
+	refreshQueue     chan refreshJob[K, V]
+	burst            burstExtension
+	validator        func(key K, value V) bool
+
+	maxCost           int64
+	costFn            func(key K, value V) int64
+	maxEvictionsPerOp int // limit of entries evicted by a single write to fit the cost limit, 0 for no limit
+	overwriteEviction OverwriteEviction
+	maxEntryCost      int64 // entries of larger cost are not added, 0 for no limit
+
+	coalesceWindow time.Duration
+	observer       func(op Op, d time.Duration)
+	lockProf       lockProfile
+	sink           statsSink
+	store          Backend[K, V]
+	writeBehind    writeBehind[K, V]
 
 	sync.Mutex
+	lockFree  lockFree[K, V]
+	flushMu   sync.Mutex // serializes write-behind flushes
+	closed    bool
+	totalCost int64
+	epoch     uint64    // incremented by BumpEpoch
+	epochAt   time.Time // time of the last BumpEpoch
+	peakSize  int       // the largest number of entries since the last map compaction
+	cleanedAt time.Time // time of the last deletion of expired entries
 	stat      Stats
+	rates     statsWindow
+	nsStat    map[string]*Stats
+	inflight  map[K]*inflightLoad[V]
 	items     map[K]*list.Element
 	evictList *list.List
+	lruK      lruK[K]
+	ghost     ghostCache[K]
+	adaptive  adaptiveTTL
+	indexes   map[string]*valueIndex[K, V]
+	reverse   *valueIndex[K, V] // value to keys index, set by WithReverseLookup
+	deps      dependencies[K]
+	ordered   *orderedIndex[K]
 }
 
 // noEvictionTTL - very long ttl to prevent eviction
 const noEvictionTTL = time.Hour * 24 * 365 * 10
 
+// TTL sentinels for Set and other writes, making the intent explicit regardless of what 0 means, see WithZeroTTL
+const (
+	DefaultTTL time.Duration = math.MinInt64 // use cache-wide TTL
+	NoTTL      time.Duration = noEvictionTTL // never expire
+	ExpireNow  time.Duration = -1            // expire immediately, so Get misses
+)
+
 // NewCache returns a new Cache.
 // Default MaxKeys is unlimited (0).
 // Default TTL is 10 years, sane value for expirable cache is 5 minutes.
@@ -72,48 +227,158 @@ const noEvictionTTL = time.Hour * 24 * 365 * 10
 func NewCache[K comparable, V any]() Cache[K, V] {
 	return &cacheImpl[K, V]{
 		items:     map[K]*list.Element{},
+		inflight:  map[K]*inflightLoad[V]{},
 		evictList: list.New(),
 		ttl:       noEvictionTTL,
 		maxKeys:   0,
+		earlyRate: 0.5,
+	}
+}
+
+// NewExpirable returns a new Cache with mandatory cache-wide TTL, which prevents unbounded growth
+// caused by forgotten WithTTL, as the default TTL is 10 years. Panics in case ttl is not positive.
+func NewExpirable[K comparable, V any](ttl time.Duration) Cache[K, V] {
+	if ttl <= 0 {
+		panic(fmt.Sprintf("cache: NewExpirable requires positive ttl, got %v", ttl))
+	}
+	return NewCache[K, V]().WithTTL(ttl)
+}
+
+// NewLRU returns a new Cache in LRU mode, with signature of hashicorp/golang-lru/v2/expirable.NewLRU,
+// so it can be used as a drop-in replacement for it.
+// Size of 0 makes cache of unlimited size, ttl of 0 (or negative) turns expiration off.
+// Unlike hashicorp implementation, it doesn't spawn a goroutine to delete expired entries.
+func NewLRU[K comparable, V any](size int, onEvict func(key K, value V), ttl time.Duration) Cache[K, V] {
+	if size < 0 {
+		size = 0
+	}
+	if ttl <= 0 {
+		ttl = noEvictionTTL
 	}
+	return NewCache[K, V]().WithLRU().WithMaxKeys(size).WithTTL(ttl).WithOnEvicted(onEvict)
 }
 
 // Add adds a value to the cache. Returns true if an eviction occurred.
 // Returns false if there was no eviction: the item was already in the cache,
-// or the size was not exceeded.
+// or the size was not exceeded. With WithMaxCost, update of existing item can cause eviction as well.
 func (c *cacheImpl[K, V]) Add(key K, value V) (evicted bool) {
-	return c.addWithTTL(key, value, c.ttl)
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	return c.addWithTTL(key, value, c.ttl, true)
 }
 
-// Set key, ttl of 0 would use cache-wide TTL
+// Set key, ttl of 0 would use cache-wide TTL unless changed by WithZeroTTL. DefaultTTL, NoTTL and ExpireNow
+// make the intent explicit.
 func (c *cacheImpl[K, V]) Set(key K, value V, ttl time.Duration) {
-	c.addWithTTL(key, value, ttl)
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	c.addWithTTL(key, value, ttl, true)
 }
 
 // Returns true if an eviction occurred.
 // Returns false if there was no eviction: the item was already in the cache,
 // or the size was not exceeded.
-func (c *cacheImpl[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted bool) {
-	c.Lock()
-	defer c.Unlock()
+// Entry is written to the backing store if persist is true, otherwise it's only added to the cache.
+func (c *cacheImpl[K, V]) addWithTTL(key K, value V, ttl time.Duration, persist bool) (evicted bool) {
+	defer c.writeThrough()
+	c.lock(OpSet)
+	defer c.unlock(OpSet)
+	return c.add(key, value, ttl, persist)
+}
+
+// add adds or updates the key, the same way addWithTTL does. Has to be called with lock!
+func (c *cacheImpl[K, V]) add(key K, value V, ttl time.Duration, persist bool) (evicted bool) {
+	return c.addEntry(key, value, ttl, 0, persist)
+}
+
+// addEntry adds or updates the key with the given cost, or the cost set by WithMaxCost if cost is not positive.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) addEntry(key K, value V, ttl time.Duration, cost int64, persist bool) (evicted bool) {
+	if c.closed {
+		return false
+	}
 	now := time.Now()
-	if ttl == 0 {
-		ttl = c.ttl
+	if c.tombstoned(key, now) {
+		return false
+	}
+	defer c.updateReadView(key)
+	c.applyPromotions()
+	c.recordAccess(key)
+	if c.normalize != nil {
+		value = c.normalize(value)
+	}
+	if ttl == 0 && c.zeroTTL != 0 {
+		ttl = c.zeroTTL
+	}
+	if ttl == 0 || ttl == DefaultTTL {
+		ttl = c.defaultTTL(key)
+	}
+	ttl = c.jitteredTTL(ttl)
+	if cost <= 0 {
+		cost = c.entryCost(key, value)
+	}
+	if c.tooLarge(key, cost) {
+		return false
 	}
 
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
+		if c.lruK.k > 0 {
+			c.lruK.access(key)
+		}
+		old, live := ent.Value.(*cacheItem[K, V]).value, !now.After(c.expiration(ent.Value.(*cacheItem[K, V])))
+		grown := cost > ent.Value.(*cacheItem[K, V]).cost
 		ent.Value.(*cacheItem[K, V]).value = value
+		c.indexRemove(key)
+		c.indexAdd(key, value)
 		ent.Value.(*cacheItem[K, V]).expiresAt = now.Add(ttl)
+		ent.Value.(*cacheItem[K, V]).createdAt = now
+		ent.Value.(*cacheItem[K, V]).epoch = c.epoch
+		ent.Value.(*cacheItem[K, V]).ttl = ttl
+		ent.Value.(*cacheItem[K, V]).silent = false
+		ent.Value.(*cacheItem[K, V]).staleMisses = 0
+		ent.Value.(*cacheItem[K, V]).extended = 0
+		ent.Value.(*cacheItem[K, V]).grace = 0
+		ent.Value.(*cacheItem[K, V]).meta = nil
+		c.setCost(ent.Value.(*cacheItem[K, V]), cost)
+		if live {
+			c.callOnReplaced(key, old, value)
+		}
+		if c.written(ent.Value.(*cacheItem[K, V]), now, persist) {
+			c.persist(ent.Value.(*cacheItem[K, V]))
+		}
+		return c.evictOnOverwrite(key, ent, grown)
+	}
+
+	// Under capacity pressure check if the new entry should be admitted
+	full := c.maxKeys > 0 && len(c.items) >= c.maxKeys || c.maxCost > 0 && c.totalCost+cost > c.maxCost
+	if full && !c.admit(key, value, cost) {
 		return false
 	}
 
 	// Add new item
-	ent := &cacheItem[K, V]{key: key, value: value, expiresAt: now.Add(ttl)}
+	ent := &cacheItem[K, V]{key: key, value: value, expiresAt: now.Add(ttl), createdAt: now, ttl: ttl, epoch: c.epoch}
+	c.setCost(ent, cost)
 	entry := c.evictList.PushFront(ent)
+	if c.missFilter != nil {
+		c.missFilter.add(hashKey(key))
+	}
 	c.items[key] = entry
-	c.stat.Added++
+	c.peakSize = max(c.peakSize, len(c.items))
+	c.indexAdd(key, value)
+	if c.ordered != nil {
+		c.ordered.add(key)
+	}
+	if c.lruK.k > 0 {
+		c.lruK.access(key)
+	}
+	c.updateStat(key, func(s *Stats) { s.Added++ })
+	if c.written(ent, now, persist) {
+		c.persist(ent)
+	}
 
 	// Remove the oldest entry if it is expired, only in case of non-default TTL.
 	if c.ttl != noEvictionTTL || ttl != noEvictionTTL {
@@ -125,229 +390,601 @@ func (c *cacheImpl[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted
 	if evict {
 		c.removeOldest()
 	}
-	return evict
+	return c.evictOverCost(c.maxEvictionsPerOp) > 0 || evict
+}
+
+// Swap sets the key the same way Set does and returns its previous value, atomically.
+// Existed is true if the key was in the cache, even if expired, so the previous value can be released.
+func (c *cacheImpl[K, V]) Swap(key K, value V, ttl time.Duration) (previous V, existed bool) {
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	defer c.writeThrough()
+	c.lock(OpSet)
+	defer c.unlock(OpSet)
+	if ent, ok := c.items[key]; ok {
+		previous, existed = ent.Value.(*cacheItem[K, V]).value, true
+	}
+	c.add(key, value, ttl, true)
+	return previous, existed
 }
 
 // Get returns the key value if it's not expired
 func (c *cacheImpl[K, V]) Get(key K) (V, bool) {
+	if c.observer != nil {
+		defer c.observe(OpGet, time.Now())
+	}
+	if c.rejectMiss(key) {
+		return *new(V), false
+	}
+	c.lock(OpGet)
+	defer c.unlock(OpGet)
+	if value, ok := c.parentLookup(key, false, OpGet); ok {
+		return value, true
+	}
+	return c.get(key)
+}
+
+// get returns the key value if it's not expired, updating stats and recent-ness. Parent cache of a child one
+// is not consulted, parentLookup has to be called first for that. Has to be called with lock!
+func (c *cacheImpl[K, V]) get(key K) (V, bool) {
 	def := *new(V)
-	c.Lock()
-	defer c.Unlock()
-	if ent, ok := c.items[key]; ok {
+	c.recordAccess(key)
+	if ent, ok := c.items[key]; ok && c.valid(ent) {
+		now := time.Now()
+		c.observeReuse(ent.Value.(*cacheItem[K, V]), now)
 		// Expired item check
-		if time.Now().After(ent.Value.(*cacheItem[K, V]).expiresAt) {
-			c.stat.Misses++
-			return ent.Value.(*cacheItem[K, V]).value, false
+		if now.After(c.expiration(ent.Value.(*cacheItem[K, V]))) {
+			if c.extendOnBurst(ent.Value.(*cacheItem[K, V]), now) {
+				c.updateStat(key, func(s *Stats) { s.Hits++ })
+				return c.copyValue(ent.Value.(*cacheItem[K, V]).value), true
+			}
+			c.updateStat(key, func(s *Stats) { s.Misses++ })
+			c.countMissCost(key, 0)
+			return c.copyValue(ent.Value.(*cacheItem[K, V]).value), false
+		}
+		switch {
+		case c.lruK.k > 0:
+			c.lruK.access(key)
+		case c.isClock:
+			ent.Value.(*cacheItem[K, V]).referenced = true
+		case c.isLRU:
+			c.promote(ent)
 		}
-		if c.isLRU {
-			c.evictList.MoveToFront(ent)
+		if c.refreshAhead > 0 && time.Until(c.expiration(ent.Value.(*cacheItem[K, V]))) < c.refreshAhead {
+			c.refresh(key)
 		}
-		c.stat.Hits++
-		return ent.Value.(*cacheItem[K, V]).value, true
+		c.updateStat(key, func(s *Stats) { s.Hits++ })
+		return c.copyValue(ent.Value.(*cacheItem[K, V]).value), true
 	}
-	c.stat.Misses++
+	c.ghostMiss(key)
+	c.updateStat(key, func(s *Stats) { s.Misses++ })
+	c.countMissCost(key, 0)
 	return def, false
 }
 
 // Contains checks if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
 func (c *cacheImpl[K, V]) Contains(key K) (ok bool) {
-	c.Lock()
-	defer c.Unlock()
+	if c.lockFree.enabled {
+		_, ok = c.readView().get(key)
+		return ok
+	}
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	_, ok = c.items[key]
 	return ok
 }
 
+// ContainsMany checks if the keys are in the cache in a single lock pass, the same way Contains does.
+// Result has the same order as keys.
+func (c *cacheImpl[K, V]) ContainsMany(keys ...K) []bool {
+	res := make([]bool, len(keys))
+	if c.lockFree.enabled {
+		view := c.readView()
+		for i, key := range keys {
+			_, res[i] = view.get(key)
+		}
+		return res
+	}
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	for i, key := range keys {
+		_, res[i] = c.items[key]
+	}
+	return res
+}
+
 // Peek returns the key value (or undefined if not found) without updating the "recently used"-ness of the key.
 // Works exactly the same as Get in case of LRC mode (default one).
 func (c *cacheImpl[K, V]) Peek(key K) (V, bool) {
+	if c.observer != nil {
+		defer c.observe(OpPeek, time.Now())
+	}
+	if c.lockFree.enabled && c.parent == nil {
+		return c.peekLockFree(key)
+	}
+	c.lock(OpPeek)
+	defer c.unlock(OpPeek)
+	if value, ok := c.parentLookup(key, true, OpPeek); ok {
+		return value, true
+	}
+	return c.peek(key)
+}
+
+// peek returns the key value if it's not expired, updating stats only. Parent cache of a child one
+// is not consulted, parentLookup has to be called first for that. Has to be called with lock!
+func (c *cacheImpl[K, V]) peek(key K) (V, bool) {
 	def := *new(V)
-	c.Lock()
-	defer c.Unlock()
+	c.recordAccess(key)
 	if ent, ok := c.items[key]; ok {
 		// Expired item check
-		if time.Now().After(ent.Value.(*cacheItem[K, V]).expiresAt) {
-			c.stat.Misses++
-			return ent.Value.(*cacheItem[K, V]).value, false
+		if time.Now().After(c.expiration(ent.Value.(*cacheItem[K, V]))) {
+			c.updatePeekStat(key, false)
+			return c.copyValue(ent.Value.(*cacheItem[K, V]).value), false
 		}
-		c.stat.Hits++
-		return ent.Value.(*cacheItem[K, V]).value, true
+		c.updatePeekStat(key, true)
+		return c.copyValue(ent.Value.(*cacheItem[K, V]).value), true
 	}
-	c.stat.Misses++
+	c.updatePeekStat(key, false)
 	return def, false
 }
 
+// GetQuiet returns the key value if it's not expired, without updating the "recently used"-ness of the key,
+// stats and frequency sketch. Intended for monitoring probes which shouldn't affect the cache.
+func (c *cacheImpl[K, V]) GetQuiet(key K) (V, bool) {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if ent, ok := c.items[key]; ok {
+		item := ent.Value.(*cacheItem[K, V])
+		return c.copyValue(item.value), !time.Now().After(c.expiration(item))
+	}
+	return *new(V), false
+}
+
 // GetExpiration returns the expiration time of the key. Non-existing key returns zero time.
 func (c *cacheImpl[K, V]) GetExpiration(key K) (time.Time, bool) {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if ent, ok := c.items[key]; ok {
+		return c.expiration(ent.Value.(*cacheItem[K, V])), true
+	}
+	return time.Time{}, false
+}
+
+// GetCreation returns the creation time of the key, which is the time of its last write, the same
+// way entries are ordered in LRC mode. Non-existing key returns zero time.
+func (c *cacheImpl[K, V]) GetCreation(key K) (time.Time, bool) {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	if ent, ok := c.items[key]; ok {
-		return ent.Value.(*cacheItem[K, V]).expiresAt, true
+		return ent.Value.(*cacheItem[K, V]).createdAt, true
 	}
 	return time.Time{}, false
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *cacheImpl[K, V]) Keys() []K {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	return c.keys()
 }
 
+// KeysPage returns up to limit keys in the cache, from oldest to newest, skipping the first offset keys.
+// Unlike Keys, it doesn't copy all the keys, which makes it suitable for listing huge caches page by page.
+// Pages are not consistent with each other in case the cache is modified between calls.
+func (c *cacheImpl[K, V]) KeysPage(offset, limit int) []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || offset >= c.evictList.Len() {
+		return []K{}
+	}
+	if rest := c.evictList.Len() - offset; limit > rest {
+		limit = rest
+	}
+	keys := make([]K, 0, limit)
+	ent := c.oldest()
+	for i := 0; i < offset; i++ {
+		ent = ent.Prev()
+	}
+	for ; ent != nil && len(keys) < limit; ent = ent.Prev() {
+		keys = append(keys, ent.Value.(*cacheItem[K, V]).key)
+	}
+	return keys
+}
+
 // Values returns a slice of the values in the cache, from oldest to newest.
 // Expired entries are filtered out.
 func (c *cacheImpl[K, V]) Values() []V {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	values := make([]V, 0, len(c.items))
 	now := time.Now()
-	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
-		if now.After(ent.Value.(*cacheItem[K, V]).expiresAt) {
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		if now.After(c.expiration(ent.Value.(*cacheItem[K, V]))) {
 			continue
 		}
-		values = append(values, ent.Value.(*cacheItem[K, V]).value)
+		values = append(values, c.copyValue(ent.Value.(*cacheItem[K, V]).value))
 	}
 	return values
 }
 
 // Len return count of items in cache, including expired
 func (c *cacheImpl[K, V]) Len() int {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	return c.evictList.Len()
 }
 
 // Resize changes the cache size. Size of 0 means unlimited.
 func (c *cacheImpl[K, V]) Resize(size int) int {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
 	if size <= 0 {
+		c.logDebug("cache resized", slog.Int("size", 0), slog.Int("evicted", 0))
 		c.maxKeys = 0
 		return 0
 	}
-	diff := c.evictList.Len() - size
-	if diff < 0 {
-		diff = 0
-	}
-	for i := 0; i < diff; i++ {
-		c.removeOldest()
+	evicted := 0
+	for c.evictList.Len() > size && c.removeOldest() {
+		evicted++
 	}
+	c.logDebug("cache resized", slog.Int("size", size), slog.Int("evicted", evicted))
 	c.maxKeys = size
-	return diff
+	return evicted
+}
+
+// MaxKeys returns the cache size limit set by WithMaxKeys or Resize, 0 means unlimited
+func (c *cacheImpl[K, V]) MaxKeys() int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	return c.maxKeys
 }
 
 // Invalidate key (item) from the cache
 func (c *cacheImpl[K, V]) Invalidate(key K) {
-	c.Lock()
-	defer c.Unlock()
-	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
-	}
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.invalidate(key)
 }
 
 // InvalidateFn deletes multiple keys if predicate is true
 func (c *cacheImpl[K, V]) InvalidateFn(fn func(key K) bool) {
-	c.Lock()
-	defer c.Unlock()
-	for key, ent := range c.items {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	for key := range c.items {
 		if fn(key) {
-			c.removeElement(ent)
+			c.invalidate(key)
+		}
+	}
+}
+
+// InvalidateOlderThan deletes keys created before t, e.g. everything cached before a deploy changing
+// the values schema, the same way Invalidate does. Returns the number of deleted keys, not counting dependent ones.
+func (c *cacheImpl[K, V]) InvalidateOlderThan(t time.Time) int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	removed := 0
+	for key, ent := range c.items {
+		if ent.Value.(*cacheItem[K, V]).createdAt.Before(t) && c.invalidate(key) {
+			removed++
 		}
 	}
+	return removed
+}
+
+// InvalidateMany deletes multiple keys in a single lock pass, the same way Invalidate does,
+// returning the number of keys which were in the cache.
+func (c *cacheImpl[K, V]) InvalidateMany(keys ...K) int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	removed := 0
+	for _, key := range keys {
+		if c.invalidate(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// Expire marks the key expired right away, so Get misses, but leaves it in the cache until it's removed
+// the usual way, e.g. by DeleteExpired, so stale reads with GetQuiet still see the old value.
+// OnEvicted is called on removal only if notify is true. Returns false if the key is not in the cache.
+func (c *cacheImpl[K, V]) Expire(key K, notify bool) bool {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	if now := time.Now(); item.expiresAt.After(now) {
+		item.expiresAt = now.Add(-time.Nanosecond) // Get misses even if the clock didn't move
+	}
+	item.silent = !notify
+	c.updateReadView(key)
+	c.logDebug("entry expired", slog.Any("key", key), slog.Bool("notify", notify))
+	return true
 }
 
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
 func (c *cacheImpl[K, V]) Remove(key K) bool {
-	c.Lock()
-	defer c.Unlock()
-	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
-		return true
-	}
-	return false
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	return c.invalidate(key)
 }
 
 // RemoveOldest remove the oldest element in the cache
 func (c *cacheImpl[K, V]) RemoveOldest() (key K, value V, ok bool) {
-	c.Lock()
-	defer c.Unlock()
-	if ent := c.evictList.Back(); ent != nil {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if ent := c.oldest(); ent != nil {
+		key, value = ent.Value.(*cacheItem[K, V]).key, ent.Value.(*cacheItem[K, V]).value
 		c.removeElement(ent)
-		return ent.Value.(*cacheItem[K, V]).key, ent.Value.(*cacheItem[K, V]).value, true
+		return key, value, true
 	}
 	return
 }
 
 // GetOldest returns the oldest entry
 func (c *cacheImpl[K, V]) GetOldest() (key K, value V, ok bool) {
-	c.Lock()
-	defer c.Unlock()
-	if ent := c.evictList.Back(); ent != nil {
-		return ent.Value.(*cacheItem[K, V]).key, ent.Value.(*cacheItem[K, V]).value, true
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if ent := c.oldest(); ent != nil {
+		return ent.Value.(*cacheItem[K, V]).key, c.copyValue(ent.Value.(*cacheItem[K, V]).value), true
 	}
 	return
 }
 
 // DeleteExpired clears cache of expired items
 func (c *cacheImpl[K, V]) DeleteExpired() {
-	c.Lock()
-	defer c.Unlock()
+	if c.observer != nil {
+		defer c.observe(OpDeleteExpired, time.Now())
+	}
+	c.lock(OpDeleteExpired)
+	defer c.unlock(OpDeleteExpired)
+	c.deleteExpired()
+}
+
+// deleteExpired deletes expired entries, returning the number of deleted ones. Has to be called with lock!
+func (c *cacheImpl[K, V]) deleteExpired() int {
+	c.cleanedAt = time.Now()
+	deleted := 0
 	for _, key := range c.keys() {
-		if time.Now().After(c.items[key].Value.(*cacheItem[K, V]).expiresAt) {
+		if time.Now().After(c.hardExpiration(c.items[key].Value.(*cacheItem[K, V]))) && !c.pinned(c.items[key]) {
 			c.removeElement(c.items[key])
+			deleted++
 		}
 	}
+	c.logDebug("expired entries deleted", slog.Int("deleted", deleted), slog.Int("size", c.evictList.Len()))
+	if tombstones := c.deleteExpiredTombstones(time.Now()); tombstones > 0 {
+		c.logDebug("expired tombstones deleted", slog.Int("deleted", tombstones))
+	}
+	return deleted
 }
 
-// Purge clears the cache completely.
+// Purge clears the cache completely. Stats are kept, with purged entries counted in Evicted,
+// unless WithStatsResetOnPurge is set.
 func (c *cacheImpl[K, V]) Purge() {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.purge()
+}
+
+// Close makes the cache closed: write-behind entries are flushed to the backing store, in-flight loads
+// are canceled and all entries are purged, calling eviction callback for each of them.
+// After Close, writes are ignored, reads miss, and methods returning errors return ErrClosed.
+// Returns ErrClosed if the cache is already closed, or write-behind flush error. With WithAsyncOnEvicted,
+// Close waits for the workers to make queued OnEvicted calls, so it must not be called from OnEvicted,
+// as it would wait for the worker making the call forever.
+func (c *cacheImpl[K, V]) Close() error {
+	c.lock(OpOther)
+	if c.closed {
+		c.unlock(OpOther)
+		return ErrClosed
+	}
+	c.closed = true // set before the flush, so concurrent Close returns right away and writes are ignored
+	c.unlock(OpOther)
+
+	err := c.Flush(context.Background())
+
+	c.lock(OpOther)
+	if c.writeBehind.timer != nil {
+		c.writeBehind.timer.Stop()
+	}
+	for _, load := range c.inflight {
+		load.cancel()
+	}
+	if c.refreshQueue != nil {
+		close(c.refreshQueue)
+	}
+	c.purge()
+	c.stopEvictWorkers()
+	c.logDebug("cache closed")
+	c.unlock(OpOther)
+	c.evictPool.wg.Wait() // queued OnEvicted calls are delivered before Close returns
+	return err
+}
+
+// purge clears the cache completely. Has to be called with lock!
+func (c *cacheImpl[K, V]) purge() {
+	c.logDebug("cache purged", slog.Int("size", len(c.items)))
+	c.dropReadView()
 	for k, v := range c.items {
 		delete(c.items, k)
-		c.stat.Evicted++
-		if c.onEvicted != nil {
-			c.onEvicted(k, v.Value.(*cacheItem[K, V]).value)
+		c.updateStat(k, func(s *Stats) { s.Evicted++ })
+		if !v.Value.(*cacheItem[K, V]).silent && !c.staleEpoch(v.Value.(*cacheItem[K, V])) {
+			c.callOnEvicted(k, v.Value.(*cacheItem[K, V]).value)
 		}
 	}
+	c.resetEntries()
+}
+
+// Clear clears the cache completely in O(1), the same way Purge does, but without OnEvicted calls and
+// namespace stats updates, as it drops internal structures instead of deleting entries one by one.
+// Cleared entries are counted in Evicted cache-wide stats. It's meant for large caches, where Purge with
+// OnEvicted set holds the lock for too long, e.g. on failover, and the callback calls are not needed.
+func (c *cacheImpl[K, V]) Clear() {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	size := len(c.items)
+	c.logDebug("cache cleared", slog.Int("size", size))
+	c.dropReadView()
+	c.items = map[K]*list.Element{}
+	c.peakSize = 0
+	c.stat.Evicted += uint64(size)
+	c.pushStats()
+	c.resetEntries()
+}
+
+// resetEntries resets structures holding entries after they are deleted from the map, and stats
+// if WithStatsResetOnPurge is set. Has to be called with lock!
+func (c *cacheImpl[K, V]) resetEntries() {
+	if c.resetStatsOnPurge {
+		c.foldLockFreeStats() // drop counters not folded yet
+		c.stat = Stats{Resets: c.stat.Resets + 1}
+		clear(c.nsStat)
+		c.rates = statsWindow{window: c.rates.window}
+	}
 	c.evictList.Init()
+	clear(c.promoteBuf) // elements of the reset list can't be moved
+	c.promoteBuf = c.promoteBuf[:0]
+	if c.ghost.enabled {
+		c.ghost.reset()
+	}
+	c.indexReset()
+	if c.ordered != nil {
+		c.ordered.keys = nil
+	}
+	c.deps.reset()
+	if c.lruK.k > 0 {
+		c.lruK.reset()
+	}
+	c.tombstones.until = nil
+	c.totalCost = 0
 }
 
 // Stat gets the current stats for cache
 func (c *cacheImpl[K, V]) Stat() Stats {
-	c.Lock()
-	defer c.Unlock()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.foldLockFreeStats()
 	return c.stat
 }
 
+// LogValue implements slog.LogValuer, logging the cache name, size and stats as a group
+func (c *cacheImpl[K, V]) LogValue() slog.Value {
+	stats := c.Stat()
+	attrs := make([]slog.Attr, 0, 7)
+	if c.name != "" {
+		attrs = append(attrs, slog.String("name", c.name))
+	}
+	if len(c.labels) > 0 {
+		attrs = append(attrs, c.labelsGroup())
+	}
+	attrs = append(attrs, slog.Int("len", c.Len()), slog.Float64("hit_ratio", stats.HitRatio()),
+		slog.Uint64("hits", stats.Hits), slog.Uint64("misses", stats.Misses), slog.Uint64("evicted", stats.Evicted))
+	return slog.GroupValue(attrs...)
+}
+
+// String returns cache size and stats, prefixed with the cache name if set by WithName.
+// Stats are omitted in case they are excluded by WithStringStats.
 func (c *cacheImpl[K, V]) String() string {
 	stats := c.Stat()
 	size := c.Len()
-	return fmt.Sprintf("Size: %d, Stats: %+v (%0.1f%%)", size, stats, 100*float64(stats.Hits)/float64(stats.Hits+stats.Misses))
+	res := ""
+	if c.name != "" {
+		res = fmt.Sprintf("Name: %s, ", c.name)
+	}
+	res += fmt.Sprintf("Size: %d", size)
+	if c.noStringStats {
+		return res
+	}
+	return res + fmt.Sprintf(", Stats: {Hits:%d Misses:%d Added:%d Evicted:%d} (%0.1f%%)",
+		stats.Hits, stats.Misses, stats.Added, stats.Evicted, 100*float64(stats.Hits)/float64(stats.Hits+stats.Misses))
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest. Has to be called with lock!
 func (c *cacheImpl[K, V]) keys() []K {
 	keys := make([]K, 0, len(c.items))
-	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
 		keys = append(keys, ent.Value.(*cacheItem[K, V]).key)
 	}
 	return keys
 }
 
-// removeOldest removes the oldest item from the cache. Has to be called with lock!
-func (c *cacheImpl[K, V]) removeOldest() {
-	ent := c.evictList.Back()
-	if ent != nil {
-		c.removeElement(ent)
+// written fires write hooks for the added or updated item, unless the write is coalesced with the previous one,
+// made within write coalescing window. Returns true if the item has to be persisted, which is requested
+// by persist. Coalesced item to be persisted is persisted by a trailing write once the window closes,
+// so the store gets the latest value. Has to be called with lock!
+func (c *cacheImpl[K, V]) written(item *cacheItem[K, V], now time.Time, persist bool) bool {
+	if c.coalesceWindow > 0 && !item.writtenAt.IsZero() && now.Sub(item.writtenAt) < c.coalesceWindow {
+		if persist && c.store != nil && !item.trailing {
+			item.trailing = true
+			key := item.key
+			time.AfterFunc(item.writtenAt.Add(c.coalesceWindow).Sub(now), func() { c.writeTrailing(key) })
+		}
+		return false
+	}
+	item.writtenAt, item.trailing = now, false
+	c.logDebug("entry written", slog.Any("key", item.key), slog.Time("expires_at", item.expiresAt))
+	return persist
+}
+
+// writeTrailing persists the latest value of the key written within write coalescing window,
+// unless the key was written again or removed since
+func (c *cacheImpl[K, V]) writeTrailing(key K) {
+	defer c.writeThrough()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	ent, ok := c.items[key]
+	if !ok || c.closed || !ent.Value.(*cacheItem[K, V]).trailing {
+		return
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	item.writtenAt, item.trailing = time.Now(), false
+	c.logDebug("coalesced entry written", slog.Any("key", key))
+	c.persist(item)
+}
+
+// admit checks if a new entry should be added to the full cache, using doorkeeper and admission function,
+// if they are set. Has to be called with lock!
+func (c *cacheImpl[K, V]) admit(key K, value V, cost int64) bool {
+	if c.doorkeeper != nil && !c.doorkeeper.allow(hashKey(key)) {
+		c.logDebug("entry rejected by doorkeeper", slog.Any("key", key))
+		return false
+	}
+	if c.admission != nil && !c.admission(key, value, cost) {
+		c.logDebug("entry rejected by admission function", slog.Any("key", key))
+		return false
+	}
+	return true
+}
+
+// removeOldest removes the oldest item from the cache to maintain its size,
+// demoting the item in case it's not expired yet. Returns false if there is nothing to remove,
+// e.g. all entries are pinned by Range. Has to be called with lock!
+func (c *cacheImpl[K, V]) removeOldest() bool {
+	ent := c.victim()
+	if ent == nil {
+		return false
 	}
+	item := ent.Value.(*cacheItem[K, V])
+	c.removeElement(ent)
+	c.ghostAdd(item.key)
+	c.countEarlyEviction(item)
+	c.callOnDemote(item)
+	return true
 }
 
 // removeOldest removes the oldest item from the cache in case it's already expired. Has to be called with lock!
 func (c *cacheImpl[K, V]) removeOldestIfExpired() {
-	ent := c.evictList.Back()
-	if ent != nil && time.Now().After(ent.Value.(*cacheItem[K, V]).expiresAt) {
+	ent := c.oldest()
+	if ent != nil && time.Now().After(c.hardExpiration(ent.Value.(*cacheItem[K, V]))) && !c.pinned(ent) {
 		c.removeElement(ent)
 	}
 }
@@ -356,16 +993,149 @@ func (c *cacheImpl[K, V]) removeOldestIfExpired() {
 func (c *cacheImpl[K, V]) removeElement(e *list.Element) {
 	c.evictList.Remove(e)
 	kv := e.Value.(*cacheItem[K, V])
+	if c.lruK.k > 0 {
+		c.lruK.remove(kv.key)
+	}
+	c.indexRemove(kv.key)
+	if c.ordered != nil {
+		c.ordered.remove(kv.key)
+	}
+	c.deps.drop(kv.key)
 	delete(c.items, kv.key)
-	c.stat.Evicted++
-	if c.onEvicted != nil {
-		c.onEvicted(kv.key, kv.value)
+	c.updateReadView(kv.key)
+	c.totalCost -= kv.cost
+	c.updateStat(kv.key, func(s *Stats) { s.Evicted++ })
+	c.logDebug("entry evicted", slog.Any("key", kv.key), slog.Time("expires_at", kv.expiresAt))
+	if !kv.silent && !c.staleEpoch(kv) {
+		c.callOnEvicted(kv.key, kv.value)
+	}
+}
+
+// callOnEvicted calls onEvicted callback if it's set, or queues the call if WithAsyncOnEvicted is set.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) callOnEvicted(key K, value V) {
+	if c.onEvicted == nil || c.pushEviction(key, value) {
+		return
+	}
+	c.invokeOnEvicted(key, value)
+}
+
+// invokeOnEvicted calls onEvicted callback, recovering from panic inside it so the cache state
+// is not left inconsistent
+func (c *cacheImpl[K, V]) invokeOnEvicted(key K, value V) {
+	defer c.recoverCallback(key, value)
+	c.onEvicted(key, value)
+}
+
+// callOnDemote calls onDemote callback for not expired item if it's set. Has to be called with lock!
+func (c *cacheImpl[K, V]) callOnDemote(item *cacheItem[K, V]) {
+	if c.onDemote == nil || time.Now().After(c.expiration(item)) {
+		return
+	}
+	defer c.recoverCallback(item.key, item.value)
+	c.onDemote(item.key, item.value, c.expiration(item))
+}
+
+// callOnReplaced calls onReplaced callback if it's set. Has to be called with lock!
+func (c *cacheImpl[K, V]) callOnReplaced(key K, old, value V) {
+	if c.onReplaced == nil {
+		return
+	}
+	defer c.recoverCallback(key, old)
+	c.onReplaced(key, old, value)
+}
+
+// recoverCallback recovers from panic in user callback, has to be deferred before the callback call.
+func (c *cacheImpl[K, V]) recoverCallback(key K, value V) {
+	if r := recover(); r != nil {
+		c.logDebug("panic in eviction callback recovered", slog.Any("key", key), slog.Any("panic", r))
+		if c.onPanic != nil {
+			c.onPanic(key, value, r)
+		}
+	}
+}
+
+// copyValue returns a copy of the value made with copyOnGet function, or the value itself if it's not set.
+func (c *cacheImpl[K, V]) copyValue(value V) V {
+	if c.copyOnGet == nil {
+		return value
+	}
+	return c.copyOnGet(value)
+}
+
+// logDebug logs message at debug level in case logger is set.
+func (c *cacheImpl[K, V]) logDebug(msg string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, c.labelAttrs(attrs)...)
+}
+
+// logError logs message at error level in case logger is set.
+func (c *cacheImpl[K, V]) logError(msg string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelError, msg, c.labelAttrs(attrs)...)
+}
+
+// labelAttrs appends the cache name and labels to log attributes, so caches are distinguishable in logs
+func (c *cacheImpl[K, V]) labelAttrs(attrs []slog.Attr) []slog.Attr {
+	if c.name != "" {
+		attrs = append(attrs, slog.String("cache", c.name))
+	}
+	if len(c.labels) > 0 {
+		attrs = append(attrs, c.labelsGroup())
+	}
+	return attrs
+}
+
+// labelsGroup returns labels as log attributes group, sorted by label name
+func (c *cacheImpl[K, V]) labelsGroup() slog.Attr {
+	names := make([]string, 0, len(c.labels))
+	for name := range c.labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, slog.String(name, c.labels[name]))
+	}
+	return slog.Group("labels", attrs...)
+}
+
+// Name returns the cache name set by WithName
+func (c *cacheImpl[K, V]) Name() string {
+	return c.name
+}
+
+// Labels returns a copy of the cache labels set by WithLabels, to be attached to metrics exported for the cache
+func (c *cacheImpl[K, V]) Labels() map[string]string {
+	res := make(map[string]string, len(c.labels))
+	for k, v := range c.labels {
+		res[k] = v
 	}
+	return res
 }
 
 // cacheItem is used to hold a value in the evictList
 type cacheItem[K comparable, V any] struct {
-	expiresAt time.Time
-	key       K
-	value     V
+	expiresAt   time.Time
+	writtenAt   time.Time     // time of the last not coalesced write
+	trailing    bool          // coalesced write is waiting to be persisted once the window closes
+	promoted    uint64        // sequence of the last access waiting in promotion buffer, 0 if none
+	createdAt   time.Time     // time of the last write
+	ttl         time.Duration // ttl set by the last write
+	cost        int64
+	referenced  bool              // accessed since the last eviction pass, CLOCK mode only
+	lastAccess  time.Time         // the last Get, adaptive TTL only
+	reuse       time.Duration     // moving average of intervals between Get calls, adaptive TTL only
+	silent      bool              // expired by Expire without OnEvicted call on removal
+	epoch       uint64            // cache epoch at the last write
+	staleMisses int               // misses since expiration, burst extension only
+	extended    time.Duration     // total extension since the last write, burst extension only
+	grace       time.Duration     // time after expiration the stale entry is kept for GetStale, SetWithSoftHardTTL only
+	meta        map[string]string // metadata set by SetWithMeta
+	key         K
+	value       V
 }
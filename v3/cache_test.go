@@ -1,135 +1,82 @@
 package cache
 
 import (
-	"crypto/rand"
+	"bytes"
+	"context"
 	"fmt"
-	"math"
-	"math/big"
+	"log/slog"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/hashicorp/golang-lru/v2/simplelru"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func getRand(tb testing.TB) int64 {
-	out, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
-	if err != nil {
-		tb.Fatal(err)
-	}
-	return out.Int64()
-}
-
-func BenchmarkLRU_Rand_NoExpire(b *testing.B) {
-	l := NewCache[int64, int64]().WithLRU().WithMaxKeys(8192)
-
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		trace[i] = getRand(b) % 32768
-	}
-
-	b.ResetTimer()
-
-	var hit, miss int
-	for i := 0; i < 2*b.N; i++ {
-		if i%2 == 0 {
-			l.Add(trace[i], trace[i])
-		} else {
-			if _, ok := l.Get(trace[i]); ok {
-				hit++
-			} else {
-				miss++
-			}
-		}
-	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+func TestSimpleLRUInterface(_ *testing.T) {
+	var _ simplelru.LRUCache[int, int] = NewCache[int, int]()
 }
 
-func BenchmarkLRU_Freq_NoExpire(b *testing.B) {
-	l := NewCache[int64, int64]().WithLRU().WithMaxKeys(8192)
-
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		if i%2 == 0 {
-			trace[i] = getRand(b) % 16384
-		} else {
-			trace[i] = getRand(b) % 32768
-		}
-	}
-
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		l.Add(trace[i], trace[i])
-	}
-	var hit, miss int
-	for i := 0; i < b.N; i++ {
-		if _, ok := l.Get(trace[i]); ok {
-			hit++
-		} else {
-			miss++
-		}
+func TestExpirableLRUInterface(_ *testing.T) {
+	// expirableLRU is the method set of hashicorp/golang-lru/v2/expirable.LRU
+	type expirableLRU[K comparable, V any] interface {
+		Add(key K, value V) bool
+		Get(key K) (V, bool)
+		Contains(key K) bool
+		Peek(key K) (V, bool)
+		Remove(key K) bool
+		RemoveOldest() (K, V, bool)
+		GetOldest() (K, V, bool)
+		Keys() []K
+		Values() []V
+		Len() int
+		Resize(size int) int
+		Purge()
 	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+	var _ expirableLRU[int, int] = expirable.NewLRU[int, int](10, nil, time.Second)
+	var _ expirableLRU[int, int] = NewLRU[int, int](10, nil, time.Second)
 }
 
-func BenchmarkLRU_Rand_WithExpire(b *testing.B) {
-	l := NewCache[int64, int64]().WithLRU().WithMaxKeys(8192).WithTTL(time.Millisecond * 10)
-
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		trace[i] = getRand(b) % 32768
-	}
+func TestNewExpirable(t *testing.T) {
+	lc := NewExpirable[string, string](time.Millisecond * 5)
+	lc.Set("key1", "val1", 0)
+	exp, ok := lc.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.True(t, exp.Before(time.Now().Add(time.Millisecond*6)))
 
-	b.ResetTimer()
-
-	var hit, miss int
-	for i := 0; i < 2*b.N; i++ {
-		if i%2 == 0 {
-			l.Add(trace[i], trace[i])
-		} else {
-			if _, ok := l.Get(trace[i]); ok {
-				hit++
-			} else {
-				miss++
-			}
-		}
-	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+	assert.PanicsWithValue(t, "cache: NewExpirable requires positive ttl, got 0s", func() {
+		NewExpirable[string, string](0)
+	})
 }
 
-func BenchmarkLRU_Freq_WithExpire(b *testing.B) {
-	l := NewCache[int64, int64]().WithLRU().WithMaxKeys(8192).WithTTL(time.Millisecond * 10)
+func TestNewLRU(t *testing.T) {
+	var evicted []string
+	lc := NewLRU[string, string](2, func(key string, _ string) { evicted = append(evicted, key) }, 0)
 
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		if i%2 == 0 {
-			trace[i] = getRand(b) % 16384
-		} else {
-			trace[i] = getRand(b) % 32768
-		}
-	}
+	assert.False(t, lc.Add("key1", "val1"))
+	assert.False(t, lc.Add("key2", "val2"))
+	_, ok := lc.Get("key1") // LRU mode, key1 becomes the newest
+	assert.True(t, ok)
+	assert.True(t, lc.Add("key3", "val3"))
+	assert.Equal(t, []string{"key2"}, evicted)
+	assert.Equal(t, []string{"key1", "key3"}, lc.Keys())
 
-	b.ResetTimer()
+	exp, ok := lc.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.True(t, exp.After(time.Now().Add(time.Hour*24*365)), "zero ttl turns expiration off")
 
-	for i := 0; i < b.N; i++ {
-		l.Add(trace[i], trace[i])
-	}
-	var hit, miss int
-	for i := 0; i < b.N; i++ {
-		if _, ok := l.Get(trace[i]); ok {
-			hit++
-		} else {
-			miss++
-		}
+	lc = NewLRU[string, string](-1, nil, time.Millisecond*5)
+	for i := 0; i < 100; i++ {
+		lc.Add(fmt.Sprintf("key%d", i), "val")
 	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
-}
-
-func TestSimpleLRUInterface(_ *testing.T) {
-	var _ simplelru.LRUCache[int, int] = NewCache[int, int]()
+	assert.Equal(t, 100, lc.Len(), "negative size means unlimited")
+	time.Sleep(time.Millisecond * 10)
+	_, ok = lc.Get("key1")
+	assert.False(t, ok)
 }
 
 func TestCacheNoPurge(t *testing.T) {
@@ -231,9 +178,25 @@ func TestCache_Values(t *testing.T) {
 	assert.Equal(t, 0, lc.Resize(0))
 	assert.Equal(t, 1, lc.Resize(2))
 	assert.Equal(t, 0, lc.Resize(5))
+	assert.Equal(t, 5, lc.MaxKeys())
 	assert.Equal(t, 1, lc.Resize(1))
 }
 
+func TestCache_KeysPage(t *testing.T) {
+	lc := NewCache[string, string]()
+	assert.Empty(t, lc.KeysPage(0, 10))
+	for i := 0; i < 5; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+
+	assert.Equal(t, []string{"key0", "key1"}, lc.KeysPage(0, 2))
+	assert.Equal(t, []string{"key2", "key3"}, lc.KeysPage(2, 2))
+	assert.Equal(t, []string{"key4"}, lc.KeysPage(4, 2))
+	assert.Equal(t, lc.Keys(), lc.KeysPage(-1, 100))
+	assert.Empty(t, lc.KeysPage(5, 2))
+	assert.Empty(t, lc.KeysPage(0, 0))
+}
+
 func TestCacheWithPurgeEnforcedBySize(t *testing.T) {
 	lc := NewCache[string, string]().WithTTL(time.Hour).WithMaxKeys(10)
 
@@ -263,6 +226,38 @@ func TestCacheConcurrency(t *testing.T) {
 	assert.Equal(t, 100, lc.Len())
 }
 
+func TestCacheContainsMany(t *testing.T) {
+	for _, lc := range []Cache[string, string]{NewCache[string, string](), NewCache[string, string]().WithLockFreeReads()} {
+		lc.Set("key1", "val1", 0)
+		lc.Set("key3", "val3", 0)
+		assert.Equal(t, []bool{true, false, true, false}, lc.ContainsMany("key1", "key2", "key3", "key4"))
+		assert.Empty(t, lc.ContainsMany())
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	var evicted int
+	lc := NewCache[string, string]().WithLRU().WithMaxKeys(10).WithOnEvicted(func(string, string) { evicted++ })
+	for i := 0; i < 5; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+	lc.Clear()
+	assert.Zero(t, evicted, "no OnEvicted calls")
+	assert.Zero(t, lc.Len())
+	assert.Empty(t, lc.Keys())
+	_, ok := lc.Get("key1")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(5), lc.Stat().Evicted)
+
+	for i := 0; i < 12; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+	assert.Equal(t, 10, lc.Len(), "size limit works after clear")
+	assert.Equal(t, 2, evicted)
+	_, _, ok = lc.GetOldest()
+	assert.True(t, ok)
+}
+
 func TestCacheInvalidateAndEvict(t *testing.T) {
 	var evicted int
 	lc := NewCache[string, string]().WithLRU().WithOnEvicted(func(_ string, _ string) { evicted++ })
@@ -303,6 +298,45 @@ func TestCacheInvalidateAndEvict(t *testing.T) {
 	assert.Zero(t, lc.Len())
 }
 
+func TestCacheInvalidateMany(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithOnEvicted(func(key string, _ string) { evicted = append(evicted, key) })
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Set("key3", "val3", 0)
+	lc.SetWithDeps("key4", "val4", 0, "key3")
+
+	assert.Equal(t, 2, lc.InvalidateMany("key1", "key3", "key5"), "only existing keys counted")
+	assert.Equal(t, []string{"key1", "key3", "key4"}, evicted, "dependent key invalidated as well")
+	assert.Equal(t, []string{"key2"}, lc.Keys())
+	assert.Zero(t, lc.InvalidateMany())
+}
+
+func TestCacheInvalidateOlderThan(t *testing.T) {
+	lc := NewCache[string, string]()
+	_, ok := lc.GetCreation("key1")
+	assert.False(t, ok)
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	created, ok := lc.GetCreation("key1")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now(), created, time.Second)
+
+	time.Sleep(time.Millisecond)
+	deploy := time.Now()
+	time.Sleep(time.Millisecond)
+	lc.Set("key2", "new", 0)
+	lc.SetWithDeps("key3", "val3", 0, "key1")
+	lc.Set("key4", "val4", 0)
+	created, _ = lc.GetCreation("key2")
+	assert.True(t, created.After(deploy), "overwrite updates creation time")
+
+	assert.Equal(t, 1, lc.InvalidateOlderThan(deploy), "new key3 is removed as dependent of key1")
+	assert.Equal(t, []string{"key2", "key4"}, lc.Keys())
+	assert.Zero(t, lc.InvalidateOlderThan(deploy))
+}
+
 func TestCacheExpired(t *testing.T) {
 	lc := NewCache[string, string]().WithTTL(time.Millisecond * 5)
 
@@ -355,6 +389,86 @@ func TestCache_GetExpiration(t *testing.T) {
 	assert.Zero(t, exp)
 }
 
+func TestCacheWithOnReplaced(t *testing.T) {
+	var replaced []string
+	lc := NewCache[string, string]().WithOnReplaced(func(key string, old, value string) {
+		replaced = append(replaced, key+":"+old+"->"+value)
+	})
+
+	lc.Set("key1", "val1", 0)
+	assert.Empty(t, replaced)
+	lc.Set("key1", "val2", time.Millisecond)
+	lc.Swap("key1", "val3", time.Millisecond)
+	assert.Equal(t, []string{"key1:val1->val2", "key1:val2->val3"}, replaced)
+
+	time.Sleep(time.Millisecond * 2)
+	lc.Add("key1", "val4")
+	assert.Len(t, replaced, 2, "not called for expired entry")
+}
+
+func TestCache_GetE(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Millisecond)
+	time.Sleep(time.Millisecond * 2)
+
+	v, err := lc.GetE("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "val1", v)
+
+	v, err = lc.GetE("key2")
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.Equal(t, "val2", v)
+
+	v, err = lc.GetE("key3")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Empty(t, v)
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Added: 2}, lc.Stat())
+}
+
+func TestCache_Swap(t *testing.T) {
+	lc := NewCache[string, string]()
+
+	prev, existed := lc.Swap("key1", "val1", 0)
+	assert.False(t, existed)
+	assert.Empty(t, prev)
+
+	prev, existed = lc.Swap("key1", "val2", time.Millisecond)
+	assert.True(t, existed)
+	assert.Equal(t, "val1", prev)
+
+	time.Sleep(time.Millisecond * 2)
+	prev, existed = lc.Swap("key1", "val3", 0)
+	assert.True(t, existed, "expired entry reported")
+	assert.Equal(t, "val2", prev)
+
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val3", v)
+	assert.Equal(t, uint64(1), lc.Stat().Added)
+}
+
+func TestCache_GetQuiet(t *testing.T) {
+	lc := NewCache[string, string]().WithLRU().WithFrequencySketch(10)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Millisecond)
+
+	v, ok := lc.GetQuiet("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v)
+	assert.Equal(t, []string{"key1", "key2"}, lc.Keys(), "recent-ness not updated")
+
+	time.Sleep(time.Millisecond * 2)
+	v, ok = lc.GetQuiet("key2")
+	assert.False(t, ok)
+	assert.Equal(t, "val2", v, "expired value returned")
+
+	_, ok = lc.GetQuiet("key3")
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Added: 2}, lc.Stat())
+	assert.Equal(t, 1, lc.EstimateFrequency("key1"), "only Set counted")
+}
+
 func TestCacheRemoveOldest(t *testing.T) {
 	lc := NewCache[string, string]().WithLRU().WithMaxKeys(2)
 
@@ -394,6 +508,256 @@ func TestCacheRemoveOldest(t *testing.T) {
 
 }
 
+func TestCacheWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lc := NewCache[string, string]().WithLogger(logger).WithTTL(-1).WithMaxKeys(-1).WithTTL(time.Millisecond * 5)
+	assert.Contains(t, buf.String(), `level=DEBUG msg="non-positive ttl set, all entries will expire immediately" ttl=-1ns`)
+	assert.Contains(t, buf.String(), `level=DEBUG msg="negative max keys set, cache size is unlimited" max_keys=-1`)
+
+	buf.Reset()
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Hour)
+	time.Sleep(time.Millisecond * 10)
+	lc.DeleteExpired()
+	assert.Contains(t, buf.String(), `msg="entry evicted" key=key1`)
+	assert.Contains(t, buf.String(), `msg="expired entries deleted" deleted=1 size=1`)
+
+	buf.Reset()
+	lc.Set("key3", "val3", time.Hour)
+	assert.Equal(t, 1, lc.Resize(1))
+	assert.Contains(t, buf.String(), `msg="entry evicted" key=key2`)
+	assert.Contains(t, buf.String(), `msg="cache resized" size=1 evicted=1`)
+
+	buf.Reset()
+	lc.Purge()
+	assert.Contains(t, buf.String(), `msg="cache purged" size=1`)
+
+	// no logger, no output and no panic
+	buf.Reset()
+	NewCache[string, string]().WithTTL(-1).Purge()
+	assert.Empty(t, buf.String())
+}
+
+func TestCacheEvictionCallbackPanic(t *testing.T) {
+	var recovered []any
+	lc := NewCache[string, string]().WithMaxKeys(2).
+		WithOnEvicted(func(key string, _ string) { panic("boom " + key) }).
+		WithPanicHandler(func(key string, value string, r any) { recovered = append(recovered, key, value, r) })
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	assert.NotPanics(t, func() { lc.Set("key3", "val3", 0) })
+	assert.Equal(t, []any{"key1", "val1", "boom key1"}, recovered)
+	assert.Equal(t, []string{"key2", "key3"}, lc.Keys())
+
+	assert.NotPanics(t, lc.Purge)
+	assert.Equal(t, 0, lc.Len())
+	assert.Len(t, recovered, 9)
+	assert.Equal(t, uint64(3), lc.Stat().Evicted)
+
+	// cache is still usable after recovered panics
+	lc.Set("key4", "val4", 0)
+	v, ok := lc.Get("key4")
+	assert.True(t, ok)
+	assert.Equal(t, "val4", v)
+
+	// no panic handler, panic is recovered anyway
+	lc = NewCache[string, string]().WithOnEvicted(func(string, string) { panic("boom") })
+	lc.Set("key1", "val1", 0)
+	assert.NotPanics(t, func() { lc.Invalidate("key1") })
+	assert.Equal(t, 0, lc.Len())
+}
+
+func TestCacheWithCopyOnGet(t *testing.T) {
+	type data struct{ val string }
+	lc := NewCache[string, *data]().WithCopyOnGet(func(v *data) *data {
+		res := *v
+		return &res
+	})
+	lc.Set("key1", &data{val: "val1"}, 0)
+
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	v.val = "changed by Get caller"
+
+	v, ok = lc.Peek("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v.val)
+	v.val = "changed by Peek caller"
+
+	_, v, ok = lc.GetOldest()
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v.val)
+	v.val = "changed by GetOldest caller"
+
+	values := lc.Values()
+	assert.Equal(t, "val1", values[0].val)
+	values[0].val = "changed by Values caller"
+
+	v, ok = lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v.val)
+
+	// without copy function the cached value is shared
+	lc = NewCache[string, *data]()
+	lc.Set("key1", &data{val: "val1"}, 0)
+	v, _ = lc.Get("key1")
+	v.val = "changed"
+	v, _ = lc.Get("key1")
+	assert.Equal(t, "changed", v.val)
+}
+
+func TestCacheWithNormalizeValue(t *testing.T) {
+	lc := NewCache[string, string]().WithNormalizeValue(strings.TrimSpace).
+		WithMaxCost(10, func(_, value string) int64 { return int64(len(value)) })
+	lc.Set("key1", "  val1 ", 0)
+	lc.Add("key2", "val2\n")
+	old, ok := lc.Swap("key1", " new1", 0)
+	assert.True(t, ok)
+	assert.Equal(t, "val1", old)
+
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "new1", v)
+	v, ok = lc.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "val2", v)
+	assert.Equal(t, int64(8), lc.(*cacheImpl[string, string]).totalCost, "cost of normalized values")
+
+	lc = NewCache[string, string]().WithNormalizeValue(strings.TrimSpace).
+		WithLoader(func(context.Context, string) (string, error) { return " loaded ", nil })
+	_, err := lc.GetCtx(context.Background(), "key1")
+	require.NoError(t, err)
+	v, ok = lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "loaded", v)
+}
+
+func TestCacheWithAdmission(t *testing.T) {
+	var calls []string
+	lc := NewCache[string, int]().WithMaxKeys(2).WithAdmission(func(key string, value int, cost int64) bool {
+		calls = append(calls, fmt.Sprintf("%s:%d:%d", key, value, cost))
+		return value >= 10
+	})
+
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	assert.Empty(t, calls, "not consulted when cache is not full")
+
+	assert.False(t, lc.Add("key3", 3))
+	assert.Equal(t, []string{"key1", "key2"}, lc.Keys(), "low value entry rejected, nothing evicted")
+	assert.Equal(t, uint64(0), lc.Stat().Evicted)
+
+	assert.True(t, lc.Add("key4", 40))
+	assert.Equal(t, []string{"key2", "key4"}, lc.Keys())
+	assert.Equal(t, []string{"key3:3:1", "key4:40:1"}, calls)
+
+	lc.Set("key2", 5, 0)
+	assert.Len(t, calls, 2, "not consulted for existing key")
+}
+
+func TestCacheWithOnDemote(t *testing.T) {
+	var demoted, evicted []string
+	lc := NewCache[string, string]().WithMaxKeys(2).
+		WithOnEvicted(func(key string, _ string) { evicted = append(evicted, key) }).
+		WithOnDemote(func(key string, value string, expiresAt time.Time) {
+			assert.True(t, expiresAt.After(time.Now()))
+			demoted = append(demoted, key+":"+value)
+		})
+
+	lc.Set("key1", "val1", time.Millisecond*5)
+	lc.Set("key2", "val2", 0)
+	lc.Set("key3", "val3", 0)
+	assert.Equal(t, []string{"key1:val1"}, demoted, "evicted to maintain size")
+
+	lc.Set("key4", "val4", time.Millisecond*5)
+	time.Sleep(time.Millisecond * 10)
+	lc.Resize(1)
+	assert.Equal(t, []string{"key1:val1", "key2:val2", "key3:val3"}, demoted)
+
+	lc.Resize(5)
+	lc.Set("key5", "val5", 0)
+	lc.Invalidate("key5")
+	lc.RemoveOldest()
+	lc.Purge()
+	assert.Equal(t, []string{"key1:val1", "key2:val2", "key3:val3"}, demoted, "not called for other evictions")
+	assert.Equal(t, []string{"key1", "key2", "key3", "key5", "key4"}, evicted)
+}
+
+func TestCacheWithWriteCoalescing(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lc := NewCache[string, string]().WithLogger(logger).WithWriteCoalescing(time.Millisecond * 20)
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key1", "val2", 0)
+	lc.Set("key1", "val3", 0)
+	assert.Equal(t, 1, strings.Count(buf.String(), `msg="entry written" key=key1`), "writes coalesced")
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val3", v, "value updated in place")
+
+	time.Sleep(time.Millisecond * 25)
+	lc.Set("key1", "val4", 0)
+	assert.Equal(t, 2, strings.Count(buf.String(), `msg="entry written" key=key1`), "write after window")
+
+	buf.Reset()
+	lc = NewCache[string, string]().WithLogger(logger)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key1", "val2", 0)
+	assert.Equal(t, 2, strings.Count(buf.String(), `msg="entry written" key=key1`), "no coalescing by default")
+}
+
+func TestCache_Close(t *testing.T) {
+	var evicted []string
+	store := &memBackend{}
+	lc := NewCache[string, string]().WithWriteBehind(store, time.Hour, 100).
+		WithOnEvicted(func(key string, _ string) { evicted = append(evicted, key) }).
+		WithLoader(func(ctx context.Context, _ string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	res := lc.GetAsync("key3")
+
+	require.NoError(t, lc.Close())
+	assert.Equal(t, [][]string{{"key1:val1", "key2:val2"}}, store.keys(), "write-behind flushed")
+	assert.ElementsMatch(t, []string{"key1", "key2"}, evicted, "eviction callbacks called")
+	assert.ErrorIs(t, (<-res).Err, context.Canceled, "in-flight load canceled")
+
+	lc.Set("key1", "val1", 0)
+	assert.Equal(t, 0, lc.Len(), "writes ignored")
+	_, ok := lc.Get("key1")
+	assert.False(t, ok)
+	_, err := lc.GetE("key1")
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = lc.GetCtx(context.Background(), "key1")
+	assert.ErrorIs(t, err, ErrClosed)
+	_, _, err = lc.Wait(context.Background(), "key1")
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, (<-lc.GetAsync("key1")).Err, ErrClosed)
+	assert.ErrorIs(t, lc.ReadSnapshot(bytes.NewBufferString("")), ErrClosed)
+	assert.ErrorIs(t, lc.Close(), ErrClosed)
+}
+
+func TestCache_CloseConcurrent(t *testing.T) {
+	lc := NewCache[string, string]().WithRefreshConcurrency(1).
+		WithLoader(func(context.Context, string) (string, error) { return "val", nil })
+	lc.Set("key1", "val1", 0)
+	lc.(*cacheImpl[string, string]).Lock()
+	lc.(*cacheImpl[string, string]).refresh("key1") // starts refresh workers
+	lc.(*cacheImpl[string, string]).Unlock()
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- lc.Close() }()
+	}
+	first, second := <-errs, <-errs
+	assert.ElementsMatch(t, []error{nil, ErrClosed}, []error{first, second})
+}
+
 func ExampleCache() {
 	// make cache with short TTL and 3 max keys
 	cache := NewCache[string, string]().WithMaxKeys(3).WithTTL(time.Millisecond * 10)
@@ -428,3 +792,66 @@ func ExampleCache() {
 	// value after expiration is found: false, value: "val1"
 	// Size: 1, Stats: {Hits:1 Misses:1 Added:2 Evicted:1} (50.0%)
 }
+
+func TestCacheZeroTTL(t *testing.T) {
+	lc := NewCache[string, string]().WithTTL(time.Minute)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", NoTTL)
+	lc.Set("key3", "val3", ExpireNow)
+	lc.Set("key4", "val4", DefaultTTL)
+	exp, _ := lc.GetExpiration("key1")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "0 is cache-wide ttl by default")
+	exp, _ = lc.GetExpiration("key2")
+	assert.WithinDuration(t, time.Now().Add(NoTTL), exp, time.Second)
+	_, ok := lc.Get("key3")
+	assert.False(t, ok, "expired immediately")
+	exp, _ = lc.GetExpiration("key4")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second)
+
+	lc = NewCache[string, string]().WithTTL(time.Minute).WithZeroTTL(NoTTL)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", DefaultTTL)
+	exp, _ = lc.GetExpiration("key1")
+	assert.WithinDuration(t, time.Now().Add(NoTTL), exp, time.Second, "0 means no expiration")
+	exp, _ = lc.GetExpiration("key2")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "DefaultTTL is still cache-wide ttl")
+
+	lc = NewCache[string, string]().WithTTL(time.Minute).WithZeroTTL(ExpireNow)
+	lc.Set("key1", "val1", 0)
+	_, ok = lc.Get("key1")
+	assert.False(t, ok, "0 means immediate expiration")
+
+	assert.Panics(t, func() { NewCache[string, string]().WithZeroTTL(time.Second) })
+	assert.Panics(t, func() { NewCache[string, string]().WithZeroTTL(0) })
+}
+
+func TestCacheExpire(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithOnEvicted(func(key, _ string) { evicted = append(evicted, key) })
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Set("key3", "val3", 0)
+	assert.False(t, lc.Expire("missing", true))
+
+	assert.True(t, lc.Expire("key1", false))
+	assert.True(t, lc.Expire("key2", true))
+	_, ok := lc.Get("key1")
+	assert.False(t, ok, "expired key misses")
+	v, ok := lc.GetQuiet("key1")
+	assert.False(t, ok)
+	assert.Equal(t, "val1", v, "stale value is still readable")
+	assert.Equal(t, 3, lc.Len(), "expired entries are not removed")
+	assert.Empty(t, evicted)
+
+	lc.DeleteExpired()
+	assert.Equal(t, 1, lc.Len())
+	assert.Equal(t, []string{"key2"}, evicted, "OnEvicted is called only for notified key")
+
+	assert.True(t, lc.Expire("key3", false))
+	lc.Set("key3", "val3-new", 0)
+	v, ok = lc.Get("key3")
+	assert.True(t, ok, "overwritten key is not expired")
+	assert.Equal(t, "val3-new", v)
+	lc.Purge()
+	assert.Equal(t, []string{"key2", "key3"}, evicted, "overwrite clears silent expiration")
+}
@@ -0,0 +1,133 @@
+// Package cachetest provides helpers for testing code which uses the cache, not intended for production use.
+package cachetest
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+// ChaosConfig defines delays injected by Chaos
+type ChaosConfig struct {
+	Latency       time.Duration // delay before the operation, without holding the cache lock
+	LockHold      time.Duration // time the cache lock is held before the operation, blocking all other callers
+	CallbackDelay time.Duration // delay of OnEvicted callback set with Chaos.WithOnEvicted, made with the lock held
+	Probability   float64       // part of the operations delayed, all of them in case of 0
+	Seed          int64         // random seed, makes delayed operations reproducible
+}
+
+// Chaos wraps the cache, injecting delays into Get, Peek, GetCtx, Set, Add, Remove, Invalidate and DeleteExpired,
+// so code using the cache can be tested with a slow cache, e.g. under lock contention during an eviction storm,
+// without changes of the real implementation. Other methods are passed to the cache as is.
+// Options except WithOnEvicted should be set on the cache before wrapping, as they return the wrapped cache.
+type Chaos[K comparable, V any] struct {
+	cache.Cache[K, V]
+	cfg ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewChaos returns the cache wrapped with delays defined by cfg
+func NewChaos[K comparable, V any](c cache.Cache[K, V], cfg ChaosConfig) *Chaos[K, V] {
+	return &Chaos[K, V]{Cache: c, cfg: cfg, rand: rand.New(rand.NewSource(cfg.Seed))} //nolint:gosec // not for security
+}
+
+// WithOnEvicted sets OnEvicted callback of the cache, delayed by CallbackDelay each time it's called
+func (c *Chaos[K, V]) WithOnEvicted(fn func(key K, value V)) cache.Cache[K, V] {
+	c.Cache.WithOnEvicted(func(key K, value V) {
+		if c.hit() {
+			time.Sleep(c.cfg.CallbackDelay)
+		}
+		fn(key, value)
+	})
+	return c
+}
+
+// Get returns the key value after injected delays
+func (c *Chaos[K, V]) Get(key K) (V, bool) {
+	c.delay()
+	return c.Cache.Get(key)
+}
+
+// Peek returns the key value after injected delays
+func (c *Chaos[K, V]) Peek(key K) (V, bool) {
+	c.delay()
+	return c.Cache.Peek(key)
+}
+
+// GetCtx returns the key value after injected delays, or ctx error in case ctx is done before the delay is over
+func (c *Chaos[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	if c.hit() {
+		select {
+		case <-ctx.Done():
+			return *new(V), ctx.Err()
+		case <-time.After(c.cfg.Latency):
+		}
+		c.holdLock()
+	}
+	return c.Cache.GetCtx(ctx, key)
+}
+
+// Set sets the key after injected delays
+func (c *Chaos[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.delay()
+	c.Cache.Set(key, value, ttl)
+}
+
+// Add adds the key after injected delays
+func (c *Chaos[K, V]) Add(key K, value V) bool {
+	c.delay()
+	return c.Cache.Add(key, value)
+}
+
+// Remove removes the key after injected delays
+func (c *Chaos[K, V]) Remove(key K) bool {
+	c.delay()
+	return c.Cache.Remove(key)
+}
+
+// Invalidate removes the key after injected delays
+func (c *Chaos[K, V]) Invalidate(key K) {
+	c.delay()
+	c.Cache.Invalidate(key)
+}
+
+// DeleteExpired deletes expired entries after injected delays
+func (c *Chaos[K, V]) DeleteExpired() {
+	c.delay()
+	c.Cache.DeleteExpired()
+}
+
+// delay sleeps for Latency and then holds the cache lock for LockHold, in case the operation is picked
+func (c *Chaos[K, V]) delay() {
+	if !c.hit() {
+		return
+	}
+	time.Sleep(c.cfg.Latency)
+	c.holdLock()
+}
+
+// holdLock holds the cache lock for LockHold, using transaction which keeps the lock while its function runs
+func (c *Chaos[K, V]) holdLock() {
+	if c.cfg.LockHold <= 0 {
+		return
+	}
+	_ = c.Cache.Txn(func(cache.Tx[K, V]) error {
+		time.Sleep(c.cfg.LockHold)
+		return nil
+	})
+}
+
+// hit picks the operation to be delayed with the configured probability
+func (c *Chaos[K, V]) hit() bool {
+	if c.cfg.Probability <= 0 || c.cfg.Probability >= 1 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < c.cfg.Probability
+}
@@ -0,0 +1,66 @@
+package cachetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+func TestChaos(t *testing.T) {
+	c := NewChaos(cache.NewCache[string, int]().WithMaxKeys(1), ChaosConfig{Latency: 20 * time.Millisecond})
+	start := time.Now()
+	c.Set("key1", 1, 0)
+	v, ok := c.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "both operations delayed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := c.GetCtx(ctx, "key1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestChaos_LockHold(t *testing.T) {
+	inner := cache.NewCache[string, int]()
+	c := NewChaos(inner, ChaosConfig{LockHold: 50 * time.Millisecond})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Set("key1", 1, 0)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	inner.Peek("key1") // not delayed itself, but waits for the lock held by Set
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	wg.Wait()
+}
+
+func TestChaos_CallbackDelay(t *testing.T) {
+	var evicted []string
+	c := NewChaos(cache.NewCache[string, int]().WithMaxKeys(1), ChaosConfig{CallbackDelay: 20 * time.Millisecond}).
+		WithOnEvicted(func(key string, _ int) { evicted = append(evicted, key) })
+	c.Set("key1", 1, 0)
+	start := time.Now()
+	c.Set("key2", 2, 0)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, []string{"key1"}, evicted)
+}
+
+func TestChaos_Probability(t *testing.T) {
+	c := NewChaos(cache.NewCache[string, int](), ChaosConfig{Latency: time.Millisecond, Probability: 0.3, Seed: 1})
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		if c.hit() {
+			hits++
+		}
+	}
+	assert.InDelta(t, 300, hits, 50)
+}
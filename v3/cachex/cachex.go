@@ -0,0 +1,126 @@
+// Package cachex provides string-keyed cache with sane defaults for the most common use case:
+// sharded by the number of CPUs, entries expire in 5 minutes with a jitter to avoid mass expiration,
+// and expired entries are deleted in background.
+package cachex
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	cache "github.com/go-pkgz/expirable-cache/v3"
+)
+
+// Defaults of StringCache
+const (
+	DefaultTTL    = 5 * time.Minute
+	DefaultJitter = 0.1 // part of TTL
+)
+
+// StringCache is a string-keyed expirable cache, sharded to reduce lock contention.
+// Expired entries are deleted by the background janitor every TTL/2, Close stops it.
+type StringCache[V any] struct {
+	router *cache.Router[string, V]
+	shards []cache.Cache[string, V]
+	ttl    time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewStringCache returns StringCache with DefaultTTL and DefaultJitter, limited to maxKeys entries
+// split evenly between shards, or unlimited in case maxKeys is 0.
+func NewStringCache[V any](maxKeys int) *StringCache[V] {
+	return NewStringCacheWithTTL[V](maxKeys, DefaultTTL, DefaultJitter)
+}
+
+// NewStringCacheWithTTL returns StringCache with given TTL and jitter, which is a part of TTL (0..1)
+// randomly added to or subtracted from TTL of each entry.
+func NewStringCacheWithTTL[V any](maxKeys int, ttl time.Duration, jitter float64) *StringCache[V] {
+	numShards := runtime.GOMAXPROCS(0)
+	res := &StringCache[V]{ttl: ttl, done: make(chan struct{})}
+	nodes := map[string]cache.Cache[string, V]{}
+	for i := 0; i < numShards; i++ {
+		shardKeys := 0
+		if maxKeys > 0 {
+			shardKeys = (maxKeys + numShards - 1) / numShards
+		}
+		c := cache.NewCache[string, V]().WithTTL(ttl).WithTTLJitter(jitter).WithMaxKeys(shardKeys)
+		res.shards = append(res.shards, c)
+		nodes[fmt.Sprintf("shard-%d", i)] = c
+	}
+	res.router = cache.NewRouter(nodes)
+	go res.janitor(ttl / 2)
+	return res
+}
+
+// WithRand sets random source used for the jitter, e.g. seeded one so TTLs are deterministic in tests,
+// or CryptoSource in case expiration time of the entries shouldn't be predictable.
+// The source is shared by all shards, so it's used under a lock.
+func (s *StringCache[V]) WithRand(src rand.Source) *StringCache[V] {
+	src = &lockedSource{src: src}
+	for _, c := range s.shards {
+		c.WithRand(src)
+	}
+	return s
+}
+
+// Set sets the key with cache TTL, adjusted by the jitter
+func (s *StringCache[V]) Set(key string, value V) {
+	s.router.Set(key, value, s.ttl)
+}
+
+// SetWithTTL sets the key with given TTL, adjusted by the jitter
+func (s *StringCache[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	s.router.Set(key, value, ttl)
+}
+
+// Get returns the key value if it's not expired
+func (s *StringCache[V]) Get(key string) (V, bool) {
+	return s.router.Get(key)
+}
+
+// Remove removes the key, returning if the key was contained
+func (s *StringCache[V]) Remove(key string) bool {
+	return s.router.Remove(key)
+}
+
+// Len returns number of entries in all shards, including expired
+func (s *StringCache[V]) Len() (res int) {
+	for _, c := range s.shards {
+		res += c.Len()
+	}
+	return res
+}
+
+// Stat returns stats summed for all shards
+func (s *StringCache[V]) Stat() (res cache.Stats) {
+	for _, c := range s.shards {
+		res = res.Add(c.Stat())
+	}
+	return res
+}
+
+// Close stops the background janitor, the cache is still usable after it
+func (s *StringCache[V]) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// janitor deletes expired entries every interval until the cache is closed
+func (s *StringCache[V]) janitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.router.DeleteExpired(0)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cachex
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringCache(t *testing.T) {
+	c := NewStringCache[int](0)
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+	assert.Equal(t, 100, c.Len())
+	v, ok := c.Get("key42")
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	_, ok = c.Get("key100")
+	assert.False(t, ok)
+	assert.True(t, c.Remove("key42"))
+	assert.Equal(t, 99, c.Len())
+
+	st := c.Stat()
+	assert.Equal(t, uint64(1), st.Hits)
+	assert.Equal(t, uint64(1), st.Misses)
+	assert.Equal(t, uint64(100), st.Added)
+	assert.Equal(t, uint64(1), st.Evicted)
+	c.Close() // second close is fine
+}
+
+func TestStringCacheJanitorAndJitter(t *testing.T) {
+	c := NewStringCacheWithTTL[string](10, time.Millisecond*20, 0.5)
+	defer c.Close()
+	c.Set("key1", "val1")
+	c.SetWithTTL("key2", "val2", time.Hour)
+	assert.Eventually(t, func() bool { return c.Len() == 1 }, time.Second, time.Millisecond*5,
+		"expired entry deleted by janitor")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.SetWithTTL(key, "val", time.Second)
+		ttl := entryTTL(c, key)
+		assert.GreaterOrEqual(t, ttl, time.Millisecond*500)
+		assert.LessOrEqual(t, ttl, time.Millisecond*1500)
+	}
+	c = NewStringCacheWithTTL[string](10, time.Second, 0)
+	defer c.Close()
+	c.Set("key1", "val1")
+	assert.Equal(t, time.Second, entryTTL(c, "key1"))
+}
+
+// entryTTL returns TTL the key is set with, found in the shard holding it
+func entryTTL(c *StringCache[string], key string) time.Duration {
+	for _, sh := range c.shards {
+		if exp, ok := sh.GetExpiration(key); ok {
+			created, _ := sh.GetCreation(key)
+			return exp.Sub(created)
+		}
+	}
+	return 0
+}
+
+func TestStringCacheMaxKeys(t *testing.T) {
+	c := NewStringCache[int](1000)
+	defer c.Close()
+	for i := 0; i < 10000; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+	assert.LessOrEqual(t, c.Len(), 1000+len(c.shards))
+	assert.Greater(t, c.Len(), 500)
+}
+
+func TestStringCacheWithRand(t *testing.T) {
+	ttls := func(c *StringCache[string]) (res []time.Duration) {
+		defer c.Close()
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("key%d", i)
+			c.SetWithTTL(key, "val", time.Second)
+			res = append(res, entryTTL(c, key))
+		}
+		return res
+	}
+	first := ttls(NewStringCache[string](0).WithRand(rand.NewSource(42)))
+	assert.Equal(t, first, ttls(NewStringCache[string](0).WithRand(rand.NewSource(42))), "same seed, same jitter")
+	assert.NotEqual(t, first, ttls(NewStringCache[string](0).WithRand(rand.NewSource(43))))
+
+	for _, ttl := range ttls(NewStringCache[string](0).WithRand(CryptoSource{})) {
+		assert.GreaterOrEqual(t, ttl, time.Millisecond*900)
+		assert.LessOrEqual(t, ttl, time.Millisecond*1100)
+	}
+
+	c := NewStringCache[string](0)
+	defer c.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			c.Set(fmt.Sprintf("key%d", i), "val")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		c.WithRand(rand.NewSource(int64(i))) // swapped concurrently with Set
+	}
+	wg.Wait()
+}
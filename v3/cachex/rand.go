@@ -0,0 +1,49 @@
+package cachex
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+)
+
+// CryptoSource is a random source reading crypto/rand, for use with WithRand.
+// It's slower than the default source and can't be seeded.
+type CryptoSource struct{}
+
+// Int63 returns a non-negative random 63-bit integer
+func (CryptoSource) Int63() int64 {
+	return int64(CryptoSource{}.Uint64() >> 1)
+}
+
+// Uint64 returns a random 64-bit integer, panics in the unlikely case crypto/rand fails
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("failed to read crypto random: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Seed does nothing, crypto source can't be seeded
+func (CryptoSource) Seed(int64) {}
+
+// lockedSource makes the source safe for concurrent use by shards
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+// Int63 returns a non-negative random 63-bit integer of the source
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+// Seed seeds the source
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
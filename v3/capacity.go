@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// CapacityTarget defines bounds and goals for AdjustCapacity
+type CapacityTarget struct {
+	MinKeys, MaxKeys int     // bounds of the cache size limit
+	HitRatio         float64 // target hit ratio (0..1), 0 to ignore
+	MemoryBudget     uint64  // process memory usage in bytes to stay under, 0 to ignore
+}
+
+// capacityShrinkRatio is a part of the cache entries removed when memory usage exceeds the budget
+const capacityShrinkRatio = 0.1
+
+// AdjustCapacity re-evaluates the cache size limit every interval until ctx is done, resizing the cache
+// within target bounds. Once hit ratio since the previous check is below the target, the limit is increased
+// to the smallest one which would reach the target according to the ghost cache set with WithGhostCache,
+// or to the largest estimated one, capped by MaxKeys. Once memory usage exceeds the budget, the limit is
+// decreased by 10% of the entries, down to MinKeys, and it's not increased until the usage is back under
+// the budget. After a decrease the next one waits for a GC cycle to complete, as the usage doesn't drop
+// until GC releases memory of the evicted entries. Requires MaxKeys and the ghost cache to be set for the cache, and does nothing otherwise.
+// It's blocking, so should be started in a separate goroutine.
+func AdjustCapacity[K comparable, V any](ctx context.Context, c Cache[K, V], interval time.Duration, target CapacityTarget) {
+	ctrl := capacityController[K, V]{cache: c, target: target, prev: c.Stat(), prevGhost: c.WouldHaveHit()}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ctrl.adjust()
+		}
+	}
+}
+
+// capacityController keeps stats of the previous check, so each check uses stats of the last interval only
+type capacityController[K comparable, V any] struct {
+	cache     Cache[K, V]
+	target    CapacityTarget
+	prev      Stats
+	prevGhost []CapacityEstimate
+	gate      gcGate // delays the next shrink on memory pressure until GC runs
+}
+
+// adjust resizes the cache according to the target, returning the new size limit or 0 if it's not changed
+func (cc *capacityController[K, V]) adjust() int {
+	stat, ghost := cc.cache.Stat(), cc.cache.WouldHaveHit()
+	prev, prevGhost := cc.prev, cc.prevGhost
+	cc.prev, cc.prevGhost = stat, ghost
+	current := cc.cache.MaxKeys()
+	if len(ghost) == 0 || current == 0 {
+		return 0
+	}
+
+	size := current
+	switch {
+	case cc.target.MemoryBudget > 0 && cc.overBudget():
+		if !cc.gate.ready() {
+			return 0
+		}
+		size = current - int(float64(current)*capacityShrinkRatio)
+	case cc.target.HitRatio > 0:
+		delta := stat.Sub(prev)
+		hits, misses := int(delta.Hits), int(delta.Misses)
+		if hits+misses == 0 || float64(hits)/float64(hits+misses) >= cc.target.HitRatio {
+			return 0
+		}
+		for i, est := range ghost {
+			size = est.MaxKeys
+			wouldHit := est.WouldHaveHit
+			if i < len(prevGhost) {
+				wouldHit -= prevGhost[i].WouldHaveHit
+			}
+			if float64(hits+wouldHit)/float64(hits+misses) >= cc.target.HitRatio {
+				break
+			}
+		}
+	}
+
+	size = max(size, cc.target.MinKeys, 1)
+	if cc.target.MaxKeys > 0 {
+		size = min(size, cc.target.MaxKeys)
+	}
+	if size == current {
+		return 0
+	}
+	if size < current {
+		cc.gate.taken()
+	}
+	cc.cache.Resize(size)
+	cc.prevGhost = cc.cache.WouldHaveHit() // estimates are for the new size
+	return size
+}
+
+// overBudget reports if process memory usage exceeds the budget
+func (cc *capacityController[K, V]) overBudget() bool {
+	used, _ := memoryUsage()
+	return used > cc.target.MemoryBudget
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustCapacity_HitRatio(t *testing.T) {
+	lc := NewCache[int, int]().WithLRU().WithMaxKeys(10).WithGhostCache()
+	ctrl := capacityController[int, int]{cache: lc, target: CapacityTarget{MinKeys: 5, MaxKeys: 100, HitRatio: 0.5}}
+	ctrl.prev, ctrl.prevGhost = lc.Stat(), lc.WouldHaveHit()
+
+	// loop over 14 keys, plain LRU with 10 keys misses every time
+	for round := 0; round < 3; round++ {
+		for key := 0; key < 14; key++ {
+			if _, ok := lc.Get(key); !ok {
+				lc.Set(key, key, 0)
+			}
+		}
+	}
+	assert.Equal(t, uint64(0), lc.Stat().Hits)
+	assert.Equal(t, 15, ctrl.adjust(), "+50% capacity would hit all keys")
+
+	for key := 0; key < 14; key++ {
+		lc.Get(key)
+	}
+	assert.Equal(t, 0, ctrl.adjust(), "target reached")
+
+	ctrl.target.MaxKeys = 12
+	for key := 100; key < 200; key++ {
+		if _, ok := lc.Get(key % 130); !ok {
+			lc.Set(key%130, key, 0)
+		}
+	}
+	assert.Equal(t, 12, ctrl.adjust(), "capped by MaxKeys")
+	assert.Equal(t, 12, lc.Len())
+
+	assert.Equal(t, 0, (&capacityController[int, int]{cache: NewCache[int, int]().WithMaxKeys(10)}).adjust(), "no ghost cache")
+}
+
+func TestAdjustCapacity_MemoryBudget(t *testing.T) {
+	origUsage, origGC := memoryUsage, gcCycles
+	defer func() { memoryUsage, gcCycles = origUsage, origGC }()
+	used := uint64(200)
+	memoryUsage = func() (uint64, uint64) { return used, 1000 }
+	var gc atomic.Uint64
+	gcCycles = gc.Load
+
+	lc := NewCache[int, int]().WithMaxKeys(100).WithGhostCache()
+	for i := 0; i < 100; i++ {
+		lc.Set(i, i, 0)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		AdjustCapacity(ctx, lc, time.Millisecond, CapacityTarget{MinKeys: 80, MemoryBudget: 100})
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return lc.Len() == 90 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(t, 90, lc.MaxKeys(), "shrunk once until GC")
+	for i := 1; i <= 3; i++ {
+		gc.Add(1)
+		time.Sleep(time.Millisecond * 20)
+	}
+	assert.Eventually(t, func() bool { return lc.MaxKeys() == 80 }, time.Second, time.Millisecond, "shrunk down to MinKeys")
+	assert.Equal(t, 80, lc.Len())
+	cancel()
+	<-done
+}
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// Chain is a fallback chain of caches (layers), e.g. small in-process cache in front of larger shared one.
+// Get queries layers in order and promotes a hit to all the earlier layers, Set writes to the write layers only.
+// Chain implements Cache: writes, lookups and removals listed below apply to the layers as a chain, while other
+// methods, e.g. stats, eviction order, Resize or GetCtx, are passed to the first layer as is, and the rest of
+// the layers are reachable with Layers. Options should be set on the layers before chaining, as they are passed
+// to the first layer and return it.
+type Chain[K comparable, V any] struct {
+	Cache[K, V] // the first layer, receives methods not implemented by the chain
+
+	layers []Cache[K, V]
+	write  []bool    // layers written by Set, all by default
+	scale  []float64 // TTL multiplier of each layer, 1 by default
+}
+
+// NewChain returns a new Chain of given caches, queried in the given order. At least one cache is required.
+func NewChain[K comparable, V any](caches ...Cache[K, V]) *Chain[K, V] {
+	res := &Chain[K, V]{Cache: caches[0], layers: caches, write: make([]bool, len(caches)),
+		scale: make([]float64, len(caches))}
+	for i := range caches {
+		res.write[i], res.scale[i] = true, 1
+	}
+	return res
+}
+
+// WithWriteLayers sets indexes of layers written by Set, other layers get values only by promotion on Get.
+// Out of range indexes are ignored.
+func (ch *Chain[K, V]) WithWriteLayers(layers ...int) *Chain[K, V] {
+	for i := range ch.write {
+		ch.write[i] = false
+	}
+	for _, i := range layers {
+		if i >= 0 && i < len(ch.write) {
+			ch.write[i] = true
+		}
+	}
+	return ch
+}
+
+// WithTTLScale sets TTL multiplier of each layer, in layers order, e.g. 0.1, 1 keeps entries in the first layer
+// for a tenth of TTL. Layers without positive multiplier keep 1. TTL of 0 and the sentinels DefaultTTL, NoTTL
+// and ExpireNow passed to Set are not scaled, so each layer applies them the usual way.
+func (ch *Chain[K, V]) WithTTLScale(scale ...float64) *Chain[K, V] {
+	for i := 0; i < len(scale) && i < len(ch.scale); i++ {
+		if scale[i] > 0 {
+			ch.scale[i] = scale[i]
+		}
+	}
+	return ch
+}
+
+// Layers returns caches of the chain, in query order.
+func (ch *Chain[K, V]) Layers() []Cache[K, V] {
+	return append([]Cache[K, V](nil), ch.layers...)
+}
+
+// Set writes the key to all the write layers, with ttl scaled for each layer.
+func (ch *Chain[K, V]) Set(key K, value V, ttl time.Duration) {
+	for i, c := range ch.layers {
+		if ch.write[i] {
+			c.Set(key, value, ch.scaleTTL(i, ttl))
+		}
+	}
+}
+
+// Add writes the key to all the write layers with their default TTL. Returns true if an eviction
+// occurred in any of them.
+func (ch *Chain[K, V]) Add(key K, value V) (evicted bool) {
+	for i, c := range ch.layers {
+		if ch.write[i] {
+			evicted = c.Add(key, value) || evicted
+		}
+	}
+	return evicted
+}
+
+// SetWithDeps writes the key with its dependencies to all the write layers, with ttl scaled for each layer.
+func (ch *Chain[K, V]) SetWithDeps(key K, value V, ttl time.Duration, deps ...K) {
+	for i, c := range ch.layers {
+		if ch.write[i] {
+			c.SetWithDeps(key, value, ch.scaleTTL(i, ttl), deps...)
+		}
+	}
+}
+
+// SetWithSoftHardTTL writes the key to all the write layers, with both TTLs scaled for each layer.
+func (ch *Chain[K, V]) SetWithSoftHardTTL(key K, value V, soft, hard time.Duration) {
+	for i, c := range ch.layers {
+		if ch.write[i] {
+			c.SetWithSoftHardTTL(key, value, ch.scaleTTL(i, soft), ch.scaleTTL(i, hard))
+		}
+	}
+}
+
+// SetWithMeta writes the key with metadata to all the write layers, with ttl scaled for each layer.
+func (ch *Chain[K, V]) SetWithMeta(key K, value V, ttl time.Duration, meta map[string]string) {
+	for i, c := range ch.layers {
+		if ch.write[i] {
+			c.SetWithMeta(key, value, ch.scaleTTL(i, ttl), meta)
+		}
+	}
+}
+
+// Swap writes the key to all the write layers, returning the previous value of the first write layer.
+func (ch *Chain[K, V]) Swap(key K, value V, ttl time.Duration) (previous V, existed bool) {
+	swapped := false
+	for i, c := range ch.layers {
+		if !ch.write[i] {
+			continue
+		}
+		if swapped {
+			c.Set(key, value, ch.scaleTTL(i, ttl))
+			continue
+		}
+		previous, existed = c.Swap(key, value, ch.scaleTTL(i, ttl))
+		swapped = true
+	}
+	return previous, existed
+}
+
+// Get returns the key value from the first layer which has it, promoting it to all the earlier layers
+// with the remaining TTL rescaled for each of them.
+func (ch *Chain[K, V]) Get(key K) (V, bool) {
+	for i, c := range ch.layers {
+		if value, ok := c.Get(key); ok {
+			ch.promote(i, key, value)
+			return value, true
+		}
+	}
+	return *new(V), false
+}
+
+// GetE returns the key value the same way Get does. In case no layer has it, the error of the first layer
+// reporting anything but ErrNotFound is returned, e.g. ErrExpired with the expired value, ErrNotFound otherwise.
+func (ch *Chain[K, V]) GetE(key K) (V, error) {
+	var res V
+	resErr := ErrNotFound
+	for i, c := range ch.layers {
+		value, err := c.GetE(key)
+		if err == nil {
+			ch.promote(i, key, value)
+			return value, nil
+		}
+		if errors.Is(resErr, ErrNotFound) && !errors.Is(err, ErrNotFound) {
+			res, resErr = value, err
+		}
+	}
+	return res, resErr
+}
+
+// Peek returns the key value from the first layer which has it, without promotion.
+func (ch *Chain[K, V]) Peek(key K) (V, bool) {
+	for _, c := range ch.layers {
+		if value, ok := c.Peek(key); ok {
+			return value, true
+		}
+	}
+	return *new(V), false
+}
+
+// GetQuiet returns the key value from the first layer which has it, without promotion and stats updates.
+func (ch *Chain[K, V]) GetQuiet(key K) (V, bool) {
+	for _, c := range ch.layers {
+		if value, ok := c.GetQuiet(key); ok {
+			return value, true
+		}
+	}
+	return *new(V), false
+}
+
+// GetEntry returns the key entry from the first layer which has it, without promotion.
+func (ch *Chain[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	for _, c := range ch.layers {
+		if e, ok := c.GetEntry(key); ok {
+			return e, true
+		}
+	}
+	return Entry[K, V]{}, false
+}
+
+// GetExpiration returns the key expiration time from the first layer which has it.
+func (ch *Chain[K, V]) GetExpiration(key K) (time.Time, bool) {
+	for _, c := range ch.layers {
+		if exp, ok := c.GetExpiration(key); ok {
+			return exp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Contains checks if any of the layers contains the key.
+func (ch *Chain[K, V]) Contains(key K) bool {
+	for _, c := range ch.layers {
+		if c.Contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsMany checks if any of the layers contains each of the keys. Result has the same order as keys.
+func (ch *Chain[K, V]) ContainsMany(keys ...K) []bool {
+	res := make([]bool, len(keys))
+	for _, c := range ch.layers {
+		for i, ok := range c.ContainsMany(keys...) {
+			res[i] = res[i] || ok
+		}
+	}
+	return res
+}
+
+// Remove removes the key from all the layers, returning if any of them contained it.
+func (ch *Chain[K, V]) Remove(key K) bool {
+	res := false
+	for _, c := range ch.layers {
+		if c.Remove(key) {
+			res = true
+		}
+	}
+	return res
+}
+
+// Expire marks the key expired in all the layers, returning if any of them contained it.
+func (ch *Chain[K, V]) Expire(key K, notify bool) bool {
+	res := false
+	for _, c := range ch.layers {
+		if c.Expire(key, notify) {
+			res = true
+		}
+	}
+	return res
+}
+
+// Invalidate invalidates the key in all the layers.
+func (ch *Chain[K, V]) Invalidate(key K) {
+	for _, c := range ch.layers {
+		c.Invalidate(key)
+	}
+}
+
+// InvalidateMany invalidates the keys in all the layers, returning the number of keys which were
+// in any of them.
+func (ch *Chain[K, V]) InvalidateMany(keys ...K) int {
+	found := make([]bool, len(keys))
+	for _, c := range ch.layers {
+		for i, ok := range c.ContainsMany(keys...) {
+			found[i] = found[i] || ok
+		}
+		c.InvalidateMany(keys...)
+	}
+	res := 0
+	for _, ok := range found {
+		if ok {
+			res++
+		}
+	}
+	return res
+}
+
+// InvalidateFn invalidates keys matching the predicate in all the layers.
+func (ch *Chain[K, V]) InvalidateFn(fn func(key K) bool) {
+	for _, c := range ch.layers {
+		c.InvalidateFn(fn)
+	}
+}
+
+// InvalidateOlderThan invalidates keys created before t in all the layers, returning the number of keys
+// deleted from all of them in total.
+func (ch *Chain[K, V]) InvalidateOlderThan(t time.Time) (res int) {
+	for _, c := range ch.layers {
+		res += c.InvalidateOlderThan(t)
+	}
+	return res
+}
+
+// DeleteExpired deletes expired entries of all the layers.
+func (ch *Chain[K, V]) DeleteExpired() {
+	for _, c := range ch.layers {
+		c.DeleteExpired()
+	}
+}
+
+// Purge deletes all the entries of all the layers.
+func (ch *Chain[K, V]) Purge() {
+	for _, c := range ch.layers {
+		c.Purge()
+	}
+}
+
+// Clear clears all the layers.
+func (ch *Chain[K, V]) Clear() {
+	for _, c := range ch.layers {
+		c.Clear()
+	}
+}
+
+// Close closes all the layers, returning their errors joined.
+func (ch *Chain[K, V]) Close() error {
+	errs := make([]error, 0, len(ch.layers))
+	for _, c := range ch.layers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// promote sets the key value found in the layer i to all the earlier layers, with the remaining TTL
+// rescaled for each of them
+func (ch *Chain[K, V]) promote(i int, key K, value V) {
+	var remaining time.Duration
+	if exp, found := ch.layers[i].GetExpiration(key); found {
+		remaining = time.Until(exp)
+	}
+	for j := 0; j < i && remaining > 0; j++ {
+		ch.layers[j].Set(key, value, time.Duration(float64(remaining)*ch.scale[j]/ch.scale[i]))
+	}
+}
+
+// scaleTTL returns ttl scaled for the layer i, 0 and the sentinels are kept as is
+func (ch *Chain[K, V]) scaleTTL(i int, ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl == NoTTL {
+		return ttl
+	}
+	return time.Duration(float64(ttl) * ch.scale[i])
+}
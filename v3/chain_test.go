@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	l1, l2, l3 := NewCache[string, string](), NewCache[string, string](), NewCache[string, string]()
+	ch := NewChain(l1, l2, l3).WithWriteLayers(0, 2).WithTTLScale(0.1, 1, 1)
+	assert.Len(t, ch.Layers(), 3)
+
+	ch.Set("key1", "val1", time.Hour)
+	assert.True(t, l1.Contains("key1"))
+	assert.False(t, l2.Contains("key1"), "not a write layer")
+	assert.True(t, l3.Contains("key1"))
+	exp, _ := l1.GetExpiration("key1")
+	assert.WithinDuration(t, time.Now().Add(6*time.Minute), exp, time.Second, "ttl scaled")
+
+	l3.Set("key2", "val2", time.Hour)
+	v, ok := ch.Peek("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "val2", v)
+	assert.False(t, l1.Contains("key2"), "peek doesn't promote")
+
+	v, ok = ch.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "val2", v)
+	assert.True(t, l2.Contains("key2"), "promoted to earlier layers")
+	exp, _ = l1.GetExpiration("key2")
+	assert.WithinDuration(t, time.Now().Add(6*time.Minute), exp, time.Second, "promoted with scaled remaining ttl")
+
+	_, ok = ch.Get("missing")
+	assert.False(t, ok)
+
+	assert.True(t, ch.Remove("key2"))
+	assert.False(t, l1.Contains("key2") || l2.Contains("key2") || l3.Contains("key2"))
+	assert.False(t, ch.Remove("key2"))
+}
+
+func TestChain_TTLSentinels(t *testing.T) {
+	l1, l2 := NewCache[string, string]().WithTTL(time.Hour), NewCache[string, string]()
+	ch := NewChain(l1, l2).WithTTLScale(0.1, 1)
+
+	ch.Set("key1", "val1", DefaultTTL)
+	exp, _ := l1.GetExpiration("key1")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second, "cache-wide ttl of the layer")
+
+	ch.Set("key2", "val2", NoTTL)
+	exp, _ = l1.GetExpiration("key2")
+	assert.WithinDuration(t, time.Now().Add(NoTTL), exp, time.Second, "never expires")
+
+	ch.Set("key3", "val3", ExpireNow)
+	_, ok := l1.Get("key3")
+	assert.False(t, ok, "expired right away")
+}
+
+func TestChain_Cache(t *testing.T) {
+	l1, l2 := NewCache[string, string](), NewCache[string, string]().WithTTL(time.Hour)
+	var ch Cache[string, string] = NewChain(l1, l2).WithWriteLayers(1)
+
+	l2.Set("key1", "val1", 0)
+	v, err := ch.GetE("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "val1", v)
+	assert.True(t, l1.Contains("key1"), "promoted by GetE")
+	_, err = ch.GetE("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	ch.Add("key2", "val2")
+	assert.False(t, l1.Contains("key2"), "not a write layer")
+	assert.Equal(t, []bool{true, true, false}, ch.ContainsMany("key1", "key2", "missing"))
+	prev, existed := ch.Swap("key2", "val3", 0)
+	assert.True(t, existed)
+	assert.Equal(t, "val2", prev)
+	e, ok := ch.GetEntry("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "val3", e.Value)
+
+	assert.Equal(t, 2, ch.InvalidateMany("key1", "key2", "missing"))
+	assert.False(t, ch.Contains("key1") || ch.Contains("key2"))
+
+	ch.Set("key3", "val3", 0)
+	assert.Equal(t, 0, ch.Len(), "passed to the first layer, not written")
+	assert.Equal(t, 1, l2.Len())
+	require.NoError(t, ch.Close())
+	assert.ErrorIs(t, l2.Close(), ErrClosed, "all layers closed")
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/crc32"
+	"io"
+)
+
+// Checksum calculates checksum of data, used to detect corrupted entries. xxhash.Sum64
+// from github.com/cespare/xxhash can be used as a faster alternative to CRC32.
+type Checksum func(data []byte) uint64
+
+var crc32Table = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC32 is a Checksum calculating CRC-32 with Castagnoli polynomial, which is hardware accelerated on most CPUs
+func CRC32(data []byte) uint64 {
+	return uint64(crc32.Checksum(data, crc32Table))
+}
+
+// checksummedEntry is a gob-encoded snapshot entry with its checksum
+type checksummedEntry struct {
+	Data []byte
+	Sum  uint64
+}
+
+// encodeList gob-encodes items, each of them encoded separately along with its checksum in case sum is set
+func encodeList[T any](w io.Writer, items []T, sum Checksum) error {
+	if sum == nil {
+		return gob.NewEncoder(w).Encode(items)
+	}
+	res := make([]checksummedEntry, 0, len(items))
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+			return err
+		}
+		res = append(res, checksummedEntry{Data: buf.Bytes(), Sum: sum(buf.Bytes())})
+	}
+	return gob.NewEncoder(w).Encode(res)
+}
+
+// decodeList decodes items written by encodeList, skipping items with checksum mismatch
+// and undecodable ones, returning number of the skipped items as corrupted.
+func decodeList[T any](r io.Reader, sum Checksum) (items []T, corrupted int, err error) {
+	if sum == nil {
+		err = gob.NewDecoder(r).Decode(&items)
+		return items, 0, err
+	}
+	var entries []checksummedEntry
+	if err = gob.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, 0, err
+	}
+	items = make([]T, 0, len(entries))
+	for _, e := range entries {
+		var item T
+		if sum(e.Data) != e.Sum || gob.NewDecoder(bytes.NewReader(e.Data)).Decode(&item) != nil {
+			corrupted++
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, corrupted, nil
+}
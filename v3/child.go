@@ -0,0 +1,47 @@
+package cache
+
+import "time"
+
+// NewChild returns a new Cache which reads through to the parent on miss, but keeps writes local,
+// e.g. for per-request memoization layered over a shared process cache, discarded at the end of the request.
+// Get and Peek return local value if it's present and not expired, otherwise the parent one, which is read
+// without the child lock held. Get of a transaction made by Txn reads local entries only, as the lock is held.
+// Invalidation and eviction affect local entries only, so invalidated key becomes visible from the parent again.
+func NewChild[K comparable, V any](parent Cache[K, V]) Cache[K, V] {
+	c := NewCache[K, V]().(*cacheImpl[K, V])
+	c.parent = parent
+	return c
+}
+
+// parentLookup returns the key value from the parent cache in case the key is missing or expired locally,
+// counting it as a hit in case it's found. Peek of the parent is used for peek, Get otherwise. The lock taken
+// for op is released while the parent is called, and the local entry is checked again once it's taken back,
+// so a local value set meanwhile takes precedence. Has to be called with lock!
+func (c *cacheImpl[K, V]) parentLookup(key K, peek bool, op Op) (value V, ok bool) {
+	if c.parent == nil || c.liveLocal(key) {
+		return value, false
+	}
+	c.unlock(op)
+	if peek {
+		value, ok = c.parent.Peek(key)
+	} else {
+		value, ok = c.parent.Get(key)
+	}
+	c.lock(op)
+	if !ok || c.liveLocal(key) {
+		return *new(V), false
+	}
+	c.recordAccess(key)
+	if peek {
+		c.updatePeekStat(key, true)
+		return value, true
+	}
+	c.updateStat(key, func(s *Stats) { s.Hits++ })
+	return value, true
+}
+
+// liveLocal checks if the key entry is in the cache, valid and not expired. Has to be called with lock!
+func (c *cacheImpl[K, V]) liveLocal(key K) bool {
+	ent, ok := c.items[key]
+	return ok && !time.Now().After(c.expiration(ent.Value.(*cacheItem[K, V]))) && c.valid(ent)
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChild(t *testing.T) {
+	parent := NewCache[string, string]()
+	parent.Set("shared", "parent-val", 0)
+	parent.Set("overridden", "parent-val", 0)
+
+	child := NewChild(parent)
+	child.Set("overridden", "child-val", 0)
+	child.Set("local", "child-val", 0)
+
+	v, ok := child.Get("shared")
+	assert.True(t, ok)
+	assert.Equal(t, "parent-val", v, "read through to parent")
+	v, ok = child.Peek("overridden")
+	assert.True(t, ok)
+	assert.Equal(t, "child-val", v, "local value shadows parent")
+	_, ok = child.Get("missing")
+	assert.False(t, ok)
+
+	assert.False(t, parent.Contains("local"), "writes are kept local")
+	v, _ = parent.Get("overridden")
+	assert.Equal(t, "parent-val", v)
+	assert.Equal(t, 2, child.Len())
+	assert.Equal(t, Stats{Hits: 2, Misses: 1, Added: 2, PeekHits: 1}, child.Stat())
+
+	child.Invalidate("overridden")
+	v, ok = child.Get("overridden")
+	assert.True(t, ok)
+	assert.Equal(t, "parent-val", v, "invalidated key visible from parent again")
+
+	child.Set("expiring", "child-val", time.Millisecond)
+	parent.Set("expiring", "parent-val", 0)
+	time.Sleep(5 * time.Millisecond)
+	v, ok = child.Get("expiring")
+	assert.True(t, ok)
+	assert.Equal(t, "parent-val", v, "expired local value falls back to parent")
+}
+
+func TestNewChildLockFreeReads(t *testing.T) {
+	parent := NewCache[string, string]()
+	parent.Set("shared", "parent-val", 0)
+	child := NewChild(parent).WithLockFreeReads()
+	child.Set("local", "child-val", 0)
+
+	v, ok := child.Peek("shared")
+	assert.True(t, ok)
+	assert.Equal(t, "parent-val", v, "read through to parent")
+	v, ok = child.Peek("local")
+	assert.True(t, ok)
+	assert.Equal(t, "child-val", v)
+	_, ok = child.Peek("missing")
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Hits: 2, Misses: 1, Added: 1, PeekHits: 2, PeekMisses: 1}, child.Stat())
+}
+
+// reentrantParent calls the child from its Get, which would deadlock with the child lock held
+type reentrantParent struct {
+	Cache[string, string]
+	child Cache[string, string]
+}
+
+func (p *reentrantParent) Get(key string) (string, bool) {
+	p.child.Set("set-by-parent", "val", 0)
+	return p.Cache.Get(key)
+}
+
+func TestNewChildParentWithoutLock(t *testing.T) {
+	parent := &reentrantParent{Cache: NewCache[string, string]()}
+	parent.Cache.Set("key1", "parent-val", 0)
+	child := NewChild[string, string](parent)
+	parent.child = child
+
+	v, ok := child.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "parent-val", v)
+	assert.True(t, child.Contains("set-by-parent"), "child called by parent")
+
+	parent.Cache.Set("key2", "parent-val", 0)
+	v, err := child.GetE("key2")
+	assert.NoError(t, err)
+	assert.Equal(t, "parent-val", v)
+
+	child.Invalidate("set-by-parent")
+	parent.Cache.Set("set-by-parent", "parent-val", 0)
+	v, ok = child.Get("set-by-parent")
+	assert.True(t, ok)
+	assert.Equal(t, "val", v, "local value set while the parent was called takes precedence")
+}
@@ -0,0 +1,50 @@
+package cache
+
+import "container/list"
+
+// victim returns the entry to evict to maintain the cache size. In CLOCK mode referenced entries
+// get a second chance, being moved next to the newest entry with the mark cleared, so the entry
+// just added is not evicted in place of the older ones. Entries pinned by Range or Txn are skipped
+// in favor of the next older one, or the next LRU-K candidate in LRU-K mode. Has to be called with lock!
+func (c *cacheImpl[K, V]) victim() *list.Element {
+	if c.lruK.k > 0 {
+		return c.lruK.victim(c.items, c.pinned)
+	}
+	ent := c.oldest()
+	for c.isClock && ent != nil && ent.Value.(*cacheItem[K, V]).referenced {
+		ent.Value.(*cacheItem[K, V]).referenced = false
+		c.evictList.MoveAfter(ent, c.evictList.Front())
+		ent = c.evictList.Back()
+	}
+	for ent != nil && c.pinned(ent) {
+		ent = ent.Prev()
+	}
+	return ent
+}
+
+// EvictionOrder returns keys in the order they would be evicted to maintain the cache size under
+// the current policy, starting with the next victim, assuming no other access happens in between.
+// In CLOCK mode referenced entries follow not referenced ones, as they get a second chance first.
+// Entries pinned by Range at the moment are not taken into account.
+func (c *cacheImpl[K, V]) EvictionOrder() []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.lruK.k > 0 {
+		return c.lruK.order()
+	}
+	res := make([]K, 0, c.evictList.Len())
+	var second []K // referenced entries, evicted after second chance
+	front := c.evictList.Front()
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		item := ent.Value.(*cacheItem[K, V])
+		if c.isClock && item.referenced && ent != front {
+			second = append(second, item.key)
+			continue
+		}
+		if ent == front {
+			res = append(res, second...) // referenced entries are moved next to the front one
+		}
+		res = append(res, item.key)
+	}
+	return res
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Clock(t *testing.T) {
+	lc := NewCache[string, int]().WithClock().WithMaxKeys(3)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	lc.Set("key3", 3, 0)
+	lc.Get("key1")
+	assert.Equal(t, []string{"key1", "key2", "key3"}, lc.Keys(), "Get doesn't reorder entries")
+
+	lc.Set("key4", 4, 0)
+	assert.Equal(t, []string{"key3", "key1", "key4"}, lc.Keys(), "referenced key1 got a second chance")
+
+	lc.Set("key5", 5, 0)
+	assert.Equal(t, []string{"key1", "key4", "key5"}, lc.Keys())
+	lc.Set("key6", 6, 0)
+	lc.Set("key7", 7, 0)
+	assert.Equal(t, []string{"key5", "key6", "key7"}, lc.Keys(), "second chance is used once")
+
+	lc.Get("key5")
+	lc.Get("key6")
+	lc.Get("key7")
+	lc.Set("key8", 8, 0)
+	assert.Equal(t, []string{"key6", "key7", "key8"}, lc.Keys(), "all referenced, the oldest evicted after a full pass, not the new one")
+}
+
+func TestCache_EvictionOrder(t *testing.T) {
+	lc := NewCache[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		lc.Set(key, i, 0)
+	}
+	lc.Get("a")
+	assert.Equal(t, []string{"a", "b", "c", "d"}, lc.EvictionOrder(), "LRC ignores access")
+
+	lc = NewCache[string, int]().WithLRU()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		lc.Set(key, i, 0)
+	}
+	lc.Get("a")
+	assert.Equal(t, []string{"b", "c", "d", "a"}, lc.EvictionOrder())
+
+	var evicted []string
+	lc = NewCache[string, int]().WithClock().WithMaxKeys(4).WithOnEvicted(func(key string, _ int) {
+		evicted = append(evicted, key)
+	})
+	for i, key := range []string{"a", "b", "c", "d"} {
+		lc.Set(key, i, 0)
+	}
+	lc.Get("a")
+	lc.Get("c")
+	order := lc.EvictionOrder()
+	assert.Equal(t, []string{"b", "a", "c", "d"}, order)
+	lc.TrimToSize(0)
+	assert.Equal(t, order, evicted, "evicted in predicted order")
+
+	lc = NewCache[string, int]().WithLRUK(2).WithMaxKeys(4)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		lc.Set(key, i, 0)
+	}
+	lc.Get("a")
+	lc.Get("b")
+	lc.Get("c")
+	order = lc.EvictionOrder()
+	assert.Equal(t, []string{"d", "a", "b", "c"}, order, "accessed once first, then by 2nd most recent access")
+	lc.Set("e", 0, 0)
+	assert.False(t, lc.Contains("d"))
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"log/slog"
+)
+
+// OverwriteEviction defines which entries are evicted when an overwrite increases the entry cost
+// over the limit set by WithMaxCost
+type OverwriteEviction int
+
+// Eviction modes for overwrites exceeding the cost limit
+const (
+	OverwriteEvictOldest OverwriteEviction = iota // evict the oldest entries, the default
+	OverwriteEvictSelf                            // evict the overwritten entry, keeping the rest
+)
+
+// UpdateCost sets cost of the entry, for values which size changes after they are added,
+// evicting the oldest entries in case total cost exceeds the limit. Returns false if the key is not found.
+func (c *cacheImpl[K, V]) UpdateCost(key K, cost int64) bool {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.setCost(ent.Value.(*cacheItem[K, V]), cost)
+	c.evictOverCost(c.maxEvictionsPerOp)
+	return true
+}
+
+// RecalculateCosts calculates costs of all entries with the cost function set by WithMaxCost,
+// evicting the oldest entries in case total cost exceeds the limit, and returns the number of evicted entries.
+// Can be called periodically in case values size changes after they are added, so cost accounting doesn't drift.
+func (c *cacheImpl[K, V]) RecalculateCosts() int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		item := ent.Value.(*cacheItem[K, V])
+		c.setCost(item, c.entryCost(item.key, item.value))
+	}
+	size := c.evictList.Len()
+	c.evictOverCost(0)
+	return size - c.evictList.Len()
+}
+
+// tooLarge checks if the entry cost is above the limit set by WithMaxEntryCost, counting the rejected write
+// and removing the existing entry of the key, as its value is outdated. Has to be called with lock!
+func (c *cacheImpl[K, V]) tooLarge(key K, cost int64) bool {
+	if c.maxEntryCost <= 0 || cost <= c.maxEntryCost {
+		return false
+	}
+	c.updateStat(key, func(s *Stats) { s.RejectedTooLarge++ })
+	c.logDebug("entry rejected, cost is above the limit", slog.Any("key", key), slog.Int64("cost", cost))
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+	}
+	return true
+}
+
+// entryCost returns cost of the entry, 1 in case cost function is not set
+func (c *cacheImpl[K, V]) entryCost(key K, value V) int64 {
+	if c.costFn == nil {
+		return 1
+	}
+	return c.costFn(key, value)
+}
+
+// setCost sets cost of the item, updating total cost. Has to be called with lock!
+func (c *cacheImpl[K, V]) setCost(item *cacheItem[K, V], cost int64) {
+	c.totalCost += cost - item.cost
+	item.cost = cost
+}
+
+// evictOnOverwrite restores the cost limit after the overwrite of the entry, returning true if any entry was removed.
+// In case the overwrite increased the entry cost, entries are evicted according to the mode set by
+// WithOverwriteEviction and counted in EvictedOnOverwrite stats of the overwritten key, otherwise only evictions
+// deferred by WithMaxEvictionsPerOp are caught up. Has to be called with lock!
+func (c *cacheImpl[K, V]) evictOnOverwrite(key K, ent *list.Element, grown bool) bool {
+	if !grown {
+		return c.evictOverCost(c.maxEvictionsPerOp) > 0
+	}
+	if c.maxCost <= 0 || c.totalCost <= c.maxCost {
+		return false
+	}
+	if c.overwriteEviction == OverwriteEvictSelf && !c.pinned(ent) {
+		c.removeElement(ent)
+		c.updateStat(key, func(s *Stats) { s.EvictedOnOverwrite++ })
+		return true
+	}
+	evicted := c.evictOverCost(c.maxEvictionsPerOp)
+	if evicted > 0 {
+		c.updateStat(key, func(s *Stats) { s.EvictedOnOverwrite += uint64(evicted) })
+	}
+	return evicted > 0
+}
+
+// evictOverCost removes the oldest entries while total cost exceeds the limit, but no more than limit entries
+// unless limit is 0, returning the number of removed entries. Has to be called with lock!
+func (c *cacheImpl[K, V]) evictOverCost(limit int) int {
+	evicted := 0
+	for c.maxCost > 0 && c.totalCost > c.maxCost && (limit <= 0 || evicted < limit) && c.removeOldest() {
+		evicted++
+	}
+	if limit > 0 && c.maxCost > 0 && evicted == limit && c.totalCost > c.maxCost {
+		c.logDebug("eviction deferred, entries per operation limit reached", slog.Int("limit", limit))
+	}
+	return evicted
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_MaxCost(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxCost(10, func(_ string, value string) int64 { return int64(len(value)) })
+	assert.False(t, lc.Add("key1", "aaaa"))
+	assert.False(t, lc.Add("key2", "bbbb"))
+	assert.True(t, lc.Add("key3", "cccc"), "total cost 12 exceeds 10")
+	assert.Equal(t, []string{"key2", "key3"}, lc.Keys())
+
+	assert.True(t, lc.Add("key3", "cccccccc"), "update increases cost")
+	assert.Equal(t, []string{"key3"}, lc.Keys())
+
+	lc.Purge()
+	assert.False(t, lc.Add("key1", "aaaaaaaaaa"))
+	assert.Equal(t, 1, lc.Len(), "cost counter reset on purge")
+}
+
+func TestCache_UpdateCost(t *testing.T) {
+	sizes := map[string]int64{}
+	lc := NewCache[string, []int]().WithMaxCost(10, func(key string, _ []int) int64 { return sizes[key] })
+	for _, key := range []string{"key1", "key2", "key3"} {
+		sizes[key] = 3
+		lc.Set(key, nil, 0)
+	}
+	assert.False(t, lc.UpdateCost("no-such-key", 5))
+
+	assert.True(t, lc.UpdateCost("key3", 4))
+	assert.Equal(t, 3, lc.Len(), "total cost 10 fits")
+	assert.True(t, lc.UpdateCost("key3", 5))
+	assert.Equal(t, []string{"key2", "key3"}, lc.Keys())
+
+	sizes["key2"], sizes["key3"] = 6, 6
+	assert.Equal(t, 1, lc.RecalculateCosts())
+	assert.Equal(t, []string{"key3"}, lc.Keys())
+
+	sizes["key3"] = 1
+	assert.Equal(t, 0, lc.RecalculateCosts())
+	lc.Set("key4", nil, 0)
+	assert.Equal(t, []string{"key3", "key4"}, lc.Keys())
+}
+
+func TestCache_MaxEvictionsPerOp(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithMaxCost(10, func(_, value string) int64 { return int64(len(value)) }).
+		WithMaxEvictionsPerOp(2).WithOnEvicted(func(key, _ string) { evicted = append(evicted, key) })
+	for i := 0; i < 10; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "v", 0)
+	}
+	lc.Set("big", "12345678", 0)
+	assert.Equal(t, []string{"key0", "key1"}, evicted, "only 2 entries evicted by single write")
+	assert.Equal(t, 9, lc.Len())
+
+	lc.Set("key0", "v", 0)
+	assert.Equal(t, []string{"key0", "key1", "key2", "key3"}, evicted, "next write evicts more")
+
+	res := lc.Maintain()
+	assert.Equal(t, 5, res.Trimmed, "maintenance evicts the rest")
+	assert.Equal(t, []string{"key9", "big", "key0"}, lc.Keys())
+}
+
+func TestCache_OverwriteEviction(t *testing.T) {
+	costFn := func(_, value string) int64 { return int64(len(value)) }
+	lc := NewCache[string, string]().WithMaxCost(10, costFn)
+	lc.Add("key1", "aaa")
+	lc.Add("key2", "bbb")
+	lc.Add("key3", "ccc")
+	assert.False(t, lc.Add("key3", "c"))
+	assert.True(t, lc.Add("key2", "bbbbbbb"), "total cost 11 exceeds 10")
+	assert.Equal(t, []string{"key3", "key2"}, lc.Keys())
+	assert.Equal(t, uint64(1), lc.Stat().EvictedOnOverwrite)
+	assert.Equal(t, uint64(1), lc.Stat().Evicted)
+	assert.Equal(t, int64(8), lc.(*cacheImpl[string, string]).totalCost)
+
+	var evicted []string
+	lc = NewCache[string, string]().WithMaxCost(10, costFn).WithOverwriteEviction(OverwriteEvictSelf).
+		WithOnEvicted(func(key, value string) { evicted = append(evicted, key+":"+value) })
+	lc.Add("key1", "aaa")
+	lc.Add("key2", "bbb")
+	lc.Add("key3", "ccc")
+	assert.False(t, lc.Add("key2", "bbbb"), "total cost 10 fits")
+	assert.True(t, lc.Add("key2", "bbbbbbb"))
+	assert.Equal(t, []string{"key1", "key3"}, lc.Keys())
+	assert.Equal(t, []string{"key2:bbbbbbb"}, evicted)
+	assert.Equal(t, uint64(1), lc.Stat().EvictedOnOverwrite)
+	assert.Equal(t, int64(6), lc.(*cacheImpl[string, string]).totalCost, "cost of the evicted entry released")
+
+	lc.Range(func(e Entry[string, string]) bool {
+		if e.Key == "key3" {
+			assert.True(t, lc.Add("key3", "ccccccccc"), "pinned entry evicts the oldest instead")
+		}
+		return true
+	})
+	assert.Equal(t, []string{"key3"}, lc.Keys())
+	assert.Equal(t, uint64(2), lc.Stat().EvictedOnOverwrite)
+	assert.Equal(t, int64(9), lc.(*cacheImpl[string, string]).totalCost)
+}
+
+func TestCache_MaxEntryCost(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithMaxCost(10, func(_, value string) int64 { return int64(len(value)) }).
+		WithMaxEntryCost(5).WithOnEvicted(func(key, _ string) { evicted = append(evicted, key) })
+	lc.Set("key1", "aaa", 0)
+	lc.Set("key2", "bbb", 0)
+	lc.Set("key3", "cccccc", 0)
+	assert.Equal(t, []string{"key1", "key2"}, lc.Keys(), "too large entry is not added and evicts nothing")
+	assert.Empty(t, evicted)
+
+	lc.Set("key2", "bbbbbbbbbb", 0)
+	assert.Equal(t, []string{"key1"}, lc.Keys(), "outdated value removed")
+	assert.Equal(t, []string{"key2"}, evicted)
+	assert.Equal(t, int64(3), lc.(*cacheImpl[string, string]).totalCost)
+
+	lc.Set("key3", "ccccc", 0)
+	assert.Equal(t, []string{"key1", "key3"}, lc.Keys(), "entry of max cost fits")
+	st := lc.Stat()
+	assert.Equal(t, uint64(2), st.RejectedTooLarge)
+	assert.Equal(t, uint64(3), st.Added)
+}
+
+func TestCache_EvictionDeferredLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lc := NewCache[string, string]().WithLogger(logger)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	assert.NotContains(t, buf.String(), "eviction deferred", "no cost limit and no evictions limit")
+
+	lc = NewCache[string, string]().WithLogger(logger).WithMaxEvictionsPerOp(1).
+		WithMaxCost(10, func(_ string, value string) int64 { return int64(len(value)) })
+	lc.Set("key1", "aaaa", 0)
+	lc.Set("key2", "bbbb", 0)
+	lc.Set("key3", "cccccccc", 0)
+	assert.Contains(t, buf.String(), "eviction deferred")
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// debugMaxEntries is a maximum number of entries listed by DebugString
+const debugMaxEntries = 100
+
+// DebugString returns cache settings and entries in eviction order, from the oldest to the newest,
+// with their expiration time, to diagnose unexpected misses. Only the first 100 entries are listed,
+// so it's meant for small caches.
+func (c *cacheImpl[K, V]) DebugString() string {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	var sb strings.Builder
+	if c.name != "" {
+		fmt.Fprintf(&sb, "Name: %s, ", c.name)
+	}
+	fmt.Fprintf(&sb, "Size: %d, MaxKeys: %d, TTL: %v, LRU: %v\n", len(c.items), c.maxKeys, c.ttl, c.isLRU)
+	now := time.Now()
+	i := 0
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		if i == debugMaxEntries {
+			fmt.Fprintf(&sb, "... %d more\n", len(c.items)-debugMaxEntries)
+			break
+		}
+		i++
+		item := ent.Value.(*cacheItem[K, V])
+		if now.After(c.expiration(item)) {
+			fmt.Fprintf(&sb, "%d. %v expired %v ago\n", i, item.key, now.Sub(c.expiration(item)).Round(time.Millisecond))
+			continue
+		}
+		fmt.Fprintf(&sb, "%d. %v expires in %v\n", i, item.key, c.expiration(item).Sub(now).Round(time.Millisecond))
+	}
+	return sb.String()
+}
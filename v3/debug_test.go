@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_String(t *testing.T) {
+	lc := NewCache[string, string]().WithName("users")
+	lc.Set("key1", "val1", 0)
+	lc.Get("key1")
+	lc.Get("key2")
+	assert.Equal(t, "Name: users, Size: 1, Stats: {Hits:1 Misses:1 Added:1 Evicted:0} (50.0%)", lc.String())
+
+	lc = lc.WithStringStats(false)
+	assert.Equal(t, "Name: users, Size: 1", lc.String())
+	assert.Equal(t, "Size: 0, Stats: {Hits:0 Misses:0 Added:0 Evicted:0} (NaN%)", NewCache[string, string]().String())
+}
+
+func TestCache_DebugString(t *testing.T) {
+	lc := NewCache[string, string]().WithName("users").WithLRU().WithMaxKeys(200).WithTTL(time.Hour)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	lines := strings.Split(lc.DebugString(), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "Name: users, Size: 2, MaxKeys: 200, TTL: 1h0m0s, LRU: true", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "1. key1 expires in 59m59."), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "2. key2 expired "), lines[2])
+	assert.Empty(t, lines[3])
+
+	for i := 0; i < 150; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+	lines = strings.Split(lc.DebugString(), "\n")
+	assert.Len(t, lines, 103)
+	assert.Equal(t, "... 50 more", lines[101])
+}
+
+func TestCache_NameLabels(t *testing.T) {
+	var buf bytes.Buffer
+	labels := map[string]string{"tier": "l1", "service": "api"}
+	lc := NewCache[string, string]().WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))).
+		WithName("users").WithLabels(labels).WithMaxKeys(1)
+	labels["tier"] = "l2"
+	assert.Equal(t, "users", lc.Name())
+	assert.Equal(t, map[string]string{"tier": "l1", "service": "api"}, lc.Labels(), "labels copied")
+	lc.Labels()["tier"] = "l3"
+	assert.Equal(t, "l1", lc.Labels()["tier"])
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	assert.Contains(t, buf.String(), `msg="entry evicted" key=key1 expires_at=`)
+	assert.Contains(t, buf.String(), ` cache=users labels.service=api labels.tier=l1`)
+
+	buf.Reset()
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("state", "cache", lc)
+	assert.Contains(t, buf.String(), "cache.name=users cache.labels.service=api cache.labels.tier=l1 cache.len=1")
+	assert.Empty(t, NewCache[string, string]().Labels())
+}
@@ -0,0 +1,73 @@
+package cache
+
+import "time"
+
+// dependencies tracks keys depending on other keys, set by SetWithDeps
+type dependencies[K comparable] struct {
+	dependents map[K]map[K]struct{} // parent key to keys depending on it
+	parents    map[K][]K            // dependent key to keys it depends on
+}
+
+// SetWithDeps sets the key value with ttl, the same way Set does, and declares the key dependent on deps keys,
+// replacing previously declared dependencies. Invalidation of any of deps keys by Invalidate, InvalidateMany, Remove,
+// InvalidateFn, InvalidateOlderThan or InvalidateByIndex invalidates the key as well, cascading to keys depending on it, while eviction and expiration
+// of deps keys don't affect it. Dependencies are kept until the key is removed, even if deps keys are not in the cache.
+func (c *cacheImpl[K, V]) SetWithDeps(key K, value V, ttl time.Duration, deps ...K) {
+	defer c.writeThrough()
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	c.lock(OpSet)
+	defer c.unlock(OpSet)
+	c.add(key, value, ttl, true)
+	if _, ok := c.items[key]; !ok {
+		return // not admitted
+	}
+	c.deps.drop(key)
+	if len(deps) == 0 {
+		return
+	}
+	if c.deps.dependents == nil {
+		c.deps = dependencies[K]{dependents: map[K]map[K]struct{}{}, parents: map[K][]K{}}
+	}
+	for _, parent := range deps {
+		if c.deps.dependents[parent] == nil {
+			c.deps.dependents[parent] = map[K]struct{}{}
+		}
+		c.deps.dependents[parent][key] = struct{}{}
+	}
+	c.deps.parents[key] = append([]K(nil), deps...)
+}
+
+// invalidate removes the key and all keys depending on it. Has to be called with lock!
+func (c *cacheImpl[K, V]) invalidate(key K) bool {
+	ent, ok := c.items[key]
+	if ok {
+		c.removeElement(ent)
+	}
+	c.addTombstone(key)
+	dependents := c.deps.dependents[key]
+	delete(c.deps.dependents, key)
+	for dep := range dependents {
+		c.invalidate(dep)
+	}
+	return ok
+}
+
+// drop removes dependencies of the removed key
+func (d *dependencies[K]) drop(key K) {
+	for _, parent := range d.parents[key] {
+		delete(d.dependents[parent], key)
+		if len(d.dependents[parent]) == 0 {
+			delete(d.dependents, parent)
+		}
+	}
+	delete(d.parents, key)
+}
+
+// reset removes all dependencies
+func (d *dependencies[K]) reset() {
+	if d.dependents != nil {
+		*d = dependencies[K]{dependents: map[K]map[K]struct{}{}, parents: map[K][]K{}}
+	}
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_SetWithDeps(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxKeys(10)
+	lc.Set("user", "u", 0)
+	lc.Set("org", "o", 0)
+	lc.SetWithDeps("profile", "p", 0, "user", "org")
+	lc.SetWithDeps("page", "pg", 0, "profile")
+	lc.Set("other", "x", 0)
+
+	lc.Invalidate("org")
+	assert.Equal(t, []string{"user", "other"}, lc.Keys(), "cascaded to profile and page")
+
+	lc.SetWithDeps("profile", "p", 0, "user")
+	lc.SetWithDeps("profile", "p", 0, "org")
+	assert.True(t, lc.Remove("user"))
+	assert.True(t, lc.Contains("profile"), "dependencies replaced")
+	assert.False(t, lc.Remove("org"), "parent not in cache")
+	assert.False(t, lc.Contains("profile"), "cascaded without parent in cache")
+
+	lc.SetWithDeps("a", "a", 0, "b")
+	lc.SetWithDeps("b", "b", 0, "a")
+	lc.InvalidateFn(func(key string) bool { return key == "a" })
+	assert.Equal(t, []string{"other"}, lc.Keys(), "cyclic dependencies")
+
+	lc.SetWithDeps("child", "c", 0, "parent")
+	lc.Remove("child")
+	assert.Empty(t, lc.(*cacheImpl[string, string]).deps.dependents, "dropped with the dependent key")
+
+	lc.SetWithDeps("child", "c", 0, "parent")
+	lc.Purge()
+	lc.Set("child", "c", 0)
+	lc.Invalidate("parent")
+	assert.True(t, lc.Contains("child"), "dependencies reset on purge")
+}
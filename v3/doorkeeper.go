@@ -0,0 +1,71 @@
+package cache
+
+import "math"
+
+// doorkeeper is a bloom filter remembering keys seen once, used to admit only keys seen at least twice.
+// It's cleared after expected number of inserts to keep false positive rate bounded.
+type doorkeeper struct {
+	bits     []uint64
+	m        uint64 // number of bits
+	k        uint64 // number of hash functions
+	inserts  int
+	capacity int
+}
+
+// newDoorkeeper makes bloom filter sized for expectedInserts keys with fpRate false positive rate
+func newDoorkeeper(expectedInserts int, fpRate float64) *doorkeeper {
+	if expectedInserts <= 0 {
+		expectedInserts = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(expectedInserts) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(expectedInserts) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &doorkeeper{bits: make([]uint64, (m+63)/64), m: m, k: k, capacity: expectedInserts}
+}
+
+// allow returns true if the hash was seen before, otherwise remembers it and returns false
+func (d *doorkeeper) allow(h uint64) bool {
+	if d.contains(h) {
+		return true
+	}
+	d.add(h)
+	return false
+}
+
+func (d *doorkeeper) contains(h uint64) bool {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := uint64(0); i < d.k; i++ {
+		pos := (h1 + i*h2) % d.m
+		if d.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *doorkeeper) add(h uint64) {
+	if d.inserts >= d.capacity {
+		d.reset()
+	}
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := uint64(0); i < d.k; i++ {
+		pos := (h1 + i*h2) % d.m
+		d.bits[pos/64] |= 1 << (pos % 64)
+	}
+	d.inserts++
+}
+
+func (d *doorkeeper) reset() {
+	for i := range d.bits {
+		d.bits[i] = 0
+	}
+	d.inserts = 0
+}
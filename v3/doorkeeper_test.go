@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWithDoorkeeper(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxKeys(2).WithDoorkeeper(100, 0.01)
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	assert.Equal(t, []string{"key1", "key2"}, lc.Keys(), "admitted without capacity pressure")
+
+	lc.Set("key3", "val3", 0)
+	assert.Equal(t, []string{"key1", "key2"}, lc.Keys(), "first-time key rejected on full cache")
+	assert.Equal(t, uint64(2), lc.Stat().Added)
+
+	assert.True(t, lc.Add("key3", "val3"), "second time key admitted, evicting the oldest")
+	assert.Equal(t, []string{"key2", "key3"}, lc.Keys())
+
+	lc.Set("key2", "new val2", 0)
+	v, ok := lc.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "new val2", v, "existing key updated regardless of doorkeeper")
+}
+
+func TestDoorkeeper(t *testing.T) {
+	d := newDoorkeeper(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		d.add(hashKey(fmt.Sprintf("key%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, d.contains(hashKey(fmt.Sprintf("key%d", i))))
+	}
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if d.contains(hashKey(fmt.Sprintf("key%d", i))) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 300, "false positive rate about 1%")
+
+	d.add(hashKey("key-overflow")) // reset after capacity reached
+	assert.False(t, d.contains(hashKey("key1")))
+	assert.True(t, d.contains(hashKey("key-overflow")))
+
+	d = newDoorkeeper(0, 2) // invalid params replaced by sane defaults
+	assert.False(t, d.allow(hashKey(1)))
+	assert.True(t, d.allow(hashKey(1)))
+}
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, hashKey("123"), hashKey(123))
+	assert.Equal(t, hashKey(int64(123)), hashKey(uint64(123)))
+	assert.NotEqual(t, hashKey("key1"), hashKey("key2"))
+	type point struct{ x, y int }
+	assert.Equal(t, hashKey(point{1, 2}), hashKey(point{1, 2}))
+	assert.NotEqual(t, hashKey(point{1, 2}), hashKey(point{2, 1}))
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Epoch returns the current cache epoch, incremented by BumpEpoch
+func (c *cacheImpl[K, V]) Epoch() uint64 {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	return c.epoch
+}
+
+// BumpEpoch expires all the entries in O(1), by incrementing the epoch which entries remember on write.
+// Entries written before the bump are treated as expired since the bump time, so Get misses them,
+// and they are deleted the usual way, e.g. by DeleteExpired, without OnEvicted calls, the same way as entries
+// expired by Expire without notification. Returns the new epoch.
+func (c *cacheImpl[K, V]) BumpEpoch() uint64 {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.epoch++
+	c.epochAt = time.Now()
+	c.dropReadView()
+	c.logDebug("epoch bumped", slog.Uint64("epoch", c.epoch))
+	return c.epoch
+}
+
+// expiration returns the item expiration time, which is the epoch bump time for items written
+// before the bump, if it's earlier. Has to be called with lock!
+func (c *cacheImpl[K, V]) expiration(item *cacheItem[K, V]) time.Time {
+	if item.epoch != c.epoch && c.epochAt.Before(item.expiresAt) {
+		return c.epochAt
+	}
+	return item.expiresAt
+}
+
+// staleEpoch checks if the item was written before the last epoch bump. Has to be called with lock!
+func (c *cacheImpl[K, V]) staleEpoch(item *cacheItem[K, V]) bool {
+	return item.epoch != c.epoch
+}
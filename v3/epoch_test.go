@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBumpEpoch(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithOnEvicted(func(key string, _ string) { evicted = append(evicted, key) })
+	assert.Equal(t, uint64(0), lc.Epoch())
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Hour)
+
+	assert.Equal(t, uint64(1), lc.BumpEpoch())
+	assert.Equal(t, uint64(1), lc.Epoch())
+	_, ok := lc.Get("key1")
+	assert.False(t, ok, "entry written before the bump expired")
+	_, ok = lc.Peek("key2")
+	assert.False(t, ok)
+	exp, ok := lc.GetExpiration("key2")
+	assert.True(t, ok, "still in the cache until deleted")
+	assert.WithinDuration(t, time.Now(), exp, time.Second, "expired at the bump time")
+
+	lc.Set("key2", "new", 0)
+	v, ok := lc.Get("key2")
+	assert.True(t, ok, "written after the bump")
+	assert.Equal(t, "new", v)
+
+	lc.DeleteExpired()
+	assert.Equal(t, []string{"key2"}, lc.Keys())
+	assert.Empty(t, evicted, "entries of the old epoch deleted without OnEvicted")
+	lc.Remove("key2")
+	assert.Equal(t, []string{"key2"}, evicted)
+}
+
+func TestCacheBumpEpochLockFree(t *testing.T) {
+	lc := NewCache[string, string]().WithLockFreeReads()
+	lc.Set("key1", "val1", 0)
+	_, ok := lc.Peek("key1")
+	assert.True(t, ok)
+	lc.BumpEpoch()
+	_, ok = lc.Peek("key1")
+	assert.False(t, ok, "read view dropped on bump")
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// Sentinel errors returned by the cache, to be checked with errors.Is
+var (
+	ErrNotFound     = errors.New("key not found")
+	ErrExpired      = errors.New("key expired")
+	ErrNoLoader     = errors.New("loader is not set")
+	ErrLoaderFailed = errors.New("loader failed")
+	ErrClosed       = errors.New("cache is closed")
+	ErrTxnTooLarge  = errors.New("transaction sets more keys than the cache holds")
+)
+
+// GetE returns the key value the same way Get does, but reports a missing key with ErrNotFound
+// and an expired one with ErrExpired, returning its value along with the error.
+func (c *cacheImpl[K, V]) GetE(key K) (V, error) {
+	if c.observer != nil {
+		defer c.observe(OpGet, time.Now())
+	}
+	c.lock(OpGet)
+	defer c.unlock(OpGet)
+	if c.closed {
+		return *new(V), ErrClosed
+	}
+	if value, ok := c.parentLookup(key, false, OpGet); ok {
+		return value, nil
+	}
+	value, ok := c.get(key)
+	if ok {
+		return value, nil
+	}
+	if ent, found := c.items[key]; found && time.Now().After(c.expiration(ent.Value.(*cacheItem[K, V]))) {
+		return value, ErrExpired
+	}
+	return value, ErrNotFound
+}
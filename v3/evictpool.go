@@ -0,0 +1,96 @@
+package cache
+
+import "sync"
+
+// evictEvent is OnEvicted call queued for the eviction worker
+type evictEvent[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// evictWorker delivers queued OnEvicted calls of its keys in order
+type evictWorker[K comparable, V any] struct {
+	mu    sync.Mutex
+	queue []evictEvent[K, V]
+	wake  chan struct{}
+}
+
+// evictPool calls OnEvicted in background workers, set by WithAsyncOnEvicted. Keys are spread between workers
+// by hash, so calls for the same key are made by the same worker in order. Fields are guarded by the cache lock.
+type evictPool[K comparable, V any] struct {
+	size    int
+	workers []*evictWorker[K, V]
+	stopped bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// pushEviction queues OnEvicted call to the worker of the key, starting workers on the first call.
+// Returns false if workers are stopped or not enabled, so the call has to be made synchronously.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) pushEviction(key K, value V) bool {
+	p := &c.evictPool
+	if p.size <= 0 || p.stopped {
+		return false
+	}
+	if p.workers == nil {
+		p.done = make(chan struct{})
+		for i := 0; i < p.size; i++ {
+			w := &evictWorker[K, V]{wake: make(chan struct{}, 1)}
+			p.workers = append(p.workers, w)
+			p.wg.Add(1)
+			go c.runEvictWorker(w, p.done)
+		}
+	}
+	w := p.workers[hashKey(key)%uint64(len(p.workers))]
+	w.mu.Lock()
+	w.queue = append(w.queue, evictEvent[K, V]{key: key, value: value})
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default: // already woken, the event is picked up with the rest of the queue
+	}
+	return true
+}
+
+// runEvictWorker delivers queued OnEvicted calls until done is closed, delivering the rest of the queue after it
+func (c *cacheImpl[K, V]) runEvictWorker(w *evictWorker[K, V], done <-chan struct{}) {
+	defer c.evictPool.wg.Done()
+	for {
+		select {
+		case <-w.wake:
+			c.deliverEvictions(w)
+		case <-done:
+			c.deliverEvictions(w)
+			return
+		}
+	}
+}
+
+// deliverEvictions calls OnEvicted for all queued events of the worker, without the cache lock held
+func (c *cacheImpl[K, V]) deliverEvictions(w *evictWorker[K, V]) {
+	for {
+		w.mu.Lock()
+		batch := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+		for _, e := range batch {
+			c.invokeOnEvicted(e.key, e.value)
+		}
+	}
+}
+
+// stopEvictWorkers makes workers exit once they deliver queued calls, further calls are made synchronously.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) stopEvictWorkers() {
+	if c.evictPool.stopped {
+		return
+	}
+	c.evictPool.stopped = true
+	if c.evictPool.done != nil {
+		close(c.evictPool.done)
+	}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheWithAsyncOnEvicted(t *testing.T) {
+	var mu sync.Mutex
+	evicted := map[string][]int{}
+	var lc Cache[string, int]
+	lc = NewCache[string, int]().WithMaxKeys(1).WithAsyncOnEvicted(4).WithOnEvicted(func(key string, value int) {
+		lc.Peek(key) // callback may call the cache
+		mu.Lock()
+		evicted[key] = append(evicted[key], value)
+		mu.Unlock()
+	})
+	for i := 0; i < 100; i++ {
+		for k := 0; k < 5; k++ {
+			lc.Set(fmt.Sprintf("key%d", k), i, 0)
+		}
+	}
+	require.NoError(t, lc.Close())
+
+	expected := make([]int, 100)
+	for i := range expected {
+		expected[i] = i
+	}
+	assert.Len(t, evicted, 5)
+	for k := 0; k < 5; k++ {
+		assert.Equal(t, expected, evicted[fmt.Sprintf("key%d", k)], "delivered in order")
+	}
+
+	lc.Set("key0", 100, 0) // rejected after close
+	assert.Len(t, evicted["key0"], 100)
+}
+
+func TestCacheAsyncOnEvictedParallel(t *testing.T) {
+	// find keys delivered by different workers
+	slow, fast := "key0", ""
+	for i := 1; fast == ""; i++ {
+		if key := fmt.Sprintf("key%d", i); hashKey(key)%2 != hashKey(slow)%2 {
+			fast = key
+		}
+	}
+
+	release := make(chan struct{})
+	delivered := make(chan string, 10)
+	lc := NewCache[string, int]().WithAsyncOnEvicted(2).WithOnEvicted(func(key string, _ int) {
+		if key == slow {
+			<-release
+		}
+		delivered <- key
+	})
+	lc.Set(slow, 1, 0)
+	lc.Set(fast, 1, 0)
+	start := time.Now()
+	lc.Remove(slow)
+	lc.Remove(fast)
+	assert.Less(t, time.Since(start), time.Millisecond*100, "eviction doesn't wait for callback")
+
+	select {
+	case key := <-delivered:
+		assert.Equal(t, fast, key, "other key is not blocked by slow callback")
+	case <-time.After(time.Second):
+		t.Fatal("callback of the fast key not called")
+	}
+	close(release)
+	assert.Equal(t, slow, <-delivered)
+	require.NoError(t, lc.Close())
+}
@@ -0,0 +1,95 @@
+package cache
+
+import "container/list"
+
+// ghostRatios are capacity increases, relative to MaxKeys, estimated by the ghost cache
+var ghostRatios = []float64{0.25, 0.5, 1}
+
+// CapacityEstimate is a number of misses which would be hits with a larger cache capacity
+type CapacityEstimate struct {
+	MaxKeys      int // hypothetical cache capacity
+	WouldHaveHit int // misses of evicted keys, which would be kept with MaxKeys capacity
+}
+
+// ghostCache keeps keys evicted to maintain the cache size, with no values, to estimate hit ratio of
+// a larger cache. A key missed by Get, which was evicted less than n evictions ago, would be a hit
+// with n more entries allowed.
+type ghostCache[K comparable] struct {
+	enabled bool
+	seq     uint64 // number of evictions
+	keys    map[K]*list.Element
+	order   *list.List // evicted keys from the oldest eviction, values are ghostEntry
+	hits    []int      // would-have-hit counts for each of ghostRatios
+}
+
+type ghostEntry[K comparable] struct {
+	key K
+	seq uint64
+}
+
+func newGhostCache[K comparable]() ghostCache[K] {
+	return ghostCache[K]{enabled: true, keys: map[K]*list.Element{}, order: list.New(), hits: make([]int, len(ghostRatios))}
+}
+
+// WouldHaveHit returns numbers of misses which would be hits with the cache capacity increased by 25%,
+// 50% and twice, as estimated by the ghost cache set with WithGhostCache. Returns nil if the ghost cache
+// is not set or the cache size is not limited.
+func (c *cacheImpl[K, V]) WouldHaveHit() []CapacityEstimate {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if !c.ghost.enabled || c.maxKeys <= 0 {
+		return nil
+	}
+	res := make([]CapacityEstimate, len(ghostRatios))
+	for i, ratio := range ghostRatios {
+		res[i] = CapacityEstimate{MaxKeys: c.maxKeys + int(float64(c.maxKeys)*ratio), WouldHaveHit: c.ghost.hits[i]}
+	}
+	return res
+}
+
+// ghostAdd remembers the key evicted to maintain the cache size,
+// keeping no more evicted keys than MaxKeys. Has to be called with lock!
+func (c *cacheImpl[K, V]) ghostAdd(key K) {
+	if !c.ghost.enabled || c.maxKeys <= 0 {
+		return
+	}
+	c.ghost.seq++
+	c.ghost.remove(key)
+	c.ghost.keys[key] = c.ghost.order.PushBack(ghostEntry[K]{key: key, seq: c.ghost.seq})
+	for c.ghost.order.Len() > c.maxKeys {
+		c.ghost.remove(c.ghost.order.Front().Value.(ghostEntry[K]).key)
+	}
+}
+
+// ghostMiss counts a miss of the key in case it was evicted recently enough to be kept by a larger cache.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) ghostMiss(key K) {
+	if !c.ghost.enabled || c.maxKeys <= 0 {
+		return
+	}
+	ent, ok := c.ghost.keys[key]
+	if !ok {
+		return
+	}
+	distance := c.ghost.seq - ent.Value.(ghostEntry[K]).seq // evictions since the key was evicted
+	for i, ratio := range ghostRatios {
+		if distance < uint64(float64(c.maxKeys)*ratio) {
+			c.ghost.hits[i]++
+		}
+	}
+	c.ghost.remove(key)
+}
+
+// remove forgets the evicted key
+func (g *ghostCache[K]) remove(key K) {
+	if ent, ok := g.keys[key]; ok {
+		g.order.Remove(ent)
+		delete(g.keys, key)
+	}
+}
+
+// reset forgets all evicted keys, keeping the counts
+func (g *ghostCache[K]) reset() {
+	g.keys = map[K]*list.Element{}
+	g.order.Init()
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_GhostCache(t *testing.T) {
+	lc := NewCache[int, int]().WithLRU().WithMaxKeys(8).WithGhostCache()
+	for i := 0; i < 16; i++ {
+		lc.Set(i, i, 0)
+	}
+	// keys 0..7 evicted, key 7 the last
+	for _, key := range []int{7, 5, 3, 0, 100} {
+		_, ok := lc.Get(key)
+		assert.False(t, ok)
+	}
+	// evictions since: key7 - 0, key5 - 2, key3 - 4, key0 - 7
+	assert.Equal(t, []CapacityEstimate{{MaxKeys: 10, WouldHaveHit: 1}, {MaxKeys: 12, WouldHaveHit: 2},
+		{MaxKeys: 16, WouldHaveHit: 4}}, lc.WouldHaveHit())
+
+	_, ok := lc.Get(7)
+	assert.False(t, ok)
+	assert.Equal(t, 1, lc.WouldHaveHit()[0].WouldHaveHit, "missed key counted once")
+
+	for i := 100; i < 120; i++ {
+		lc.Set(i, i, 0)
+	}
+	assert.Len(t, lc.(*cacheImpl[int, int]).ghost.keys, 8, "ghost cache limited to MaxKeys")
+
+	lc.Purge()
+	assert.Empty(t, lc.(*cacheImpl[int, int]).ghost.keys)
+	assert.Equal(t, 4, lc.WouldHaveHit()[2].WouldHaveHit, "counts kept on purge")
+
+	assert.Nil(t, NewCache[int, int]().WithMaxKeys(8).WouldHaveHit(), "ghost cache not set")
+	assert.Nil(t, NewCache[int, int]().WithGhostCache().WouldHaveHit(), "size not limited")
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+)
+
+// goCacheItem is an item of patrickmn/go-cache, gob-encoded by its Save method as map[string]Item
+type goCacheItem struct {
+	Object     any
+	Expiration int64 // UnixNano, 0 means no expiration
+}
+
+// SaveGoCacheFile writes all non-expired entries of the cache to the file, in the format of
+// patrickmn/go-cache SaveFile, so the file can be loaded by go-cache LoadFile and LoadGoCacheFile.
+// Entries set without expiration, with NoTTL or the default cache TTL, are written without expiration time.
+// Value types are registered with gob.Register, the same way go-cache does it.
+func SaveGoCacheFile[V any](c Cache[string, V], path string) (err error) {
+	items := goCacheItems(c)
+	for _, item := range items {
+		registerGobType(item.Object)
+	}
+
+	fh, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create go-cache file: %w", err)
+	}
+	defer func() {
+		if closeErr := fh.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close go-cache file: %w", closeErr)
+		}
+	}()
+	if err = gob.NewEncoder(fh).Encode(&items); err != nil {
+		return fmt.Errorf("failed to encode go-cache file: %w", err)
+	}
+	return nil
+}
+
+// LoadGoCacheFile adds entries from the file written by patrickmn/go-cache SaveFile to the cache,
+// keeping their expiration time. The same way go-cache LoadFile does, expired entries are skipped and
+// existing entries are not overwritten. Entries without expiration are added with the cache default TTL.
+// Value types have to be registered with gob.Register before the call, as go-cache requires.
+func LoadGoCacheFile[V any](c Cache[string, V], path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open go-cache file: %w", err)
+	}
+	defer fh.Close()
+
+	items := map[string]goCacheItem{}
+	if err = gob.NewDecoder(fh).Decode(&items); err != nil {
+		return fmt.Errorf("failed to decode go-cache file: %w", err)
+	}
+
+	now := time.Now()
+	for key, item := range items {
+		value, ok := item.Object.(V)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T for key %q", item.Object, key)
+		}
+		ttl := DefaultTTL
+		if item.Expiration > 0 {
+			if ttl = time.Unix(0, item.Expiration).Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		if c.Contains(key) {
+			continue
+		}
+		c.Set(key, value, ttl)
+	}
+	return nil
+}
+
+// goCacheItems returns non-expired entries of the cache as go-cache items, without stats updates.
+// Entries of other Cache implementations, e.g. Chain, are read from SnapshotView, which doesn't tell
+// entries without expiration apart, so they are written with their expiration time.
+func goCacheItems[V any](c Cache[string, V]) map[string]goCacheItem {
+	if impl, ok := c.(*cacheImpl[string, V]); ok {
+		return impl.goCacheItems()
+	}
+	res := map[string]goCacheItem{}
+	c.SnapshotView().Range(func(e Entry[string, V]) bool {
+		res[e.Key] = goCacheItem{Object: e.Value, Expiration: e.ExpiresAt.UnixNano()}
+		return true
+	})
+	return res
+}
+
+// goCacheItems returns non-expired entries as go-cache items, each value read together with its expiration
+// in a single pass under the lock. Entries set without expiration get Expiration of 0.
+func (c *cacheImpl[K, V]) goCacheItems() map[K]goCacheItem {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	now := time.Now()
+	res := make(map[K]goCacheItem, len(c.items))
+	for key, ent := range c.items {
+		item := ent.Value.(*cacheItem[K, V])
+		if now.After(c.expiration(item)) {
+			continue
+		}
+		exp := c.expiration(item).UnixNano()
+		if item.ttl == noEvictionTTL {
+			exp = 0
+		}
+		res[key] = goCacheItem{Object: c.copyValue(item.value), Expiration: exp}
+	}
+	return res
+}
+
+// registerGobType registers type of the value with gob, ignoring types which can't be registered
+func registerGobType(value any) {
+	defer func() { _ = recover() }()
+	gob.Register(value)
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type goCacheValue struct{ Name string }
+
+func TestGoCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	lc := NewCache[string, goCacheValue]().WithTTL(time.Hour)
+	lc.Set("key1", goCacheValue{"a"}, 0)
+	lc.Set("key2", goCacheValue{"b"}, time.Minute)
+	lc.Set("key3", goCacheValue{"c"}, time.Millisecond)
+	lc.Set("key4", goCacheValue{"d"}, NoTTL)
+	time.Sleep(time.Millisecond * 5)
+	stats := lc.Stat()
+	require.NoError(t, SaveGoCacheFile(lc, path))
+	assert.Equal(t, stats, lc.Stat(), "export doesn't count as reads")
+
+	fh, err := os.Open(path)
+	require.NoError(t, err)
+	saved := map[string]goCacheItem{}
+	require.NoError(t, gob.NewDecoder(fh).Decode(&saved))
+	require.NoError(t, fh.Close())
+	assert.Len(t, saved, 3)
+	assert.Equal(t, int64(0), saved["key4"].Expiration, "no expiration")
+	assert.NotZero(t, saved["key1"].Expiration)
+
+	restored := NewCache[string, goCacheValue]()
+	restored.Set("key2", goCacheValue{"existing"}, 0)
+	require.NoError(t, LoadGoCacheFile(restored, path))
+	assert.Equal(t, 3, restored.Len())
+	v, ok := restored.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, goCacheValue{"a"}, v)
+	v, ok = restored.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, goCacheValue{"existing"}, v, "existing entry is not overwritten")
+	exp, ok := restored.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second)
+
+	other := NewCache[string, int]()
+	assert.ErrorContains(t, LoadGoCacheFile(other, path), "unexpected value type cache.goCacheValue for key")
+	assert.Error(t, LoadGoCacheFile(other, filepath.Join(t.TempDir(), "no-such-file")))
+}
+
+func TestLoadGoCacheFile_NoExpiration(t *testing.T) {
+	// file in the format written by go-cache SaveFile
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	fh, err := os.Create(path)
+	require.NoError(t, err)
+	items := map[string]goCacheItem{
+		"key1": {Object: 1},
+		"key2": {Object: 2, Expiration: time.Now().Add(-time.Minute).UnixNano()},
+	}
+	gob.Register(0)
+	require.NoError(t, gob.NewEncoder(fh).Encode(&items))
+	require.NoError(t, fh.Close())
+
+	lc := NewCache[string, int]().WithTTL(time.Minute)
+	require.NoError(t, LoadGoCacheFile(lc, path))
+	assert.Equal(t, []string{"key1"}, lc.Keys())
+	exp, ok := lc.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "default TTL used")
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// hashKey returns 64-bit FNV-1a hash of the key. Strings and integers are hashed directly,
+// other key types are hashed by their default formatting.
+func hashKey[K comparable](key K) uint64 {
+	h := fnv.New64a()
+	switch k := any(key).(type) {
+	case string:
+		_, _ = h.Write([]byte(k))
+	case int:
+		_, _ = h.Write(strconv.AppendInt(nil, int64(k), 10))
+	case int64:
+		_, _ = h.Write(strconv.AppendInt(nil, k, 10))
+	case uint64:
+		_, _ = h.Write(strconv.AppendUint(nil, k, 10))
+	default:
+		_, _ = fmt.Fprintf(h, "%v", key)
+	}
+	return mix64(h.Sum64())
+}
+
+// mix64 is a finalizer of murmur3 hash, improving bits distribution of FNV hash for similar inputs
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
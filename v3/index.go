@@ -0,0 +1,109 @@
+package cache
+
+// valueIndex is an inverted index of entries by an attribute of their value, set by WithIndex,
+// or by the value itself, set by WithReverseLookup. Attributes have to be comparable. The attribute
+// of each key is kept, so the entry is removed by it even if fn returns something else for the value later.
+type valueIndex[K comparable, V any] struct {
+	fn    func(value V) any
+	keys  map[any]map[K]struct{}
+	attrs map[K]any
+}
+
+func (idx *valueIndex[K, V]) add(key K, value V) {
+	idx.remove(key)
+	attr := idx.fn(value)
+	idx.attrs[key] = attr
+	if idx.keys[attr] == nil {
+		idx.keys[attr] = map[K]struct{}{}
+	}
+	idx.keys[attr][key] = struct{}{}
+}
+
+// find returns keys of the entries with the attribute
+func (idx *valueIndex[K, V]) find(attr any) []K {
+	res := make([]K, 0, len(idx.keys[attr]))
+	for key := range idx.keys[attr] {
+		res = append(res, key)
+	}
+	return res
+}
+
+// remove removes the key by the attribute stored when it was added
+func (idx *valueIndex[K, V]) remove(key K) {
+	attr, ok := idx.attrs[key]
+	if !ok {
+		return
+	}
+	delete(idx.attrs, key)
+	delete(idx.keys[attr], key)
+	if len(idx.keys[attr]) == 0 {
+		delete(idx.keys, attr)
+	}
+}
+
+// KeysByIndex returns keys of all entries, including expired ones, with index value returned by
+// function of the index set with WithIndex. Keys are returned in no particular order.
+// Returns nil if the index is not set.
+func (c *cacheImpl[K, V]) KeysByIndex(name, indexValue string) []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	idx, ok := c.indexes[name]
+	if !ok {
+		return nil
+	}
+	return idx.find(indexValue)
+}
+
+// FindKeys returns keys of all entries, including expired ones, with the value, or with the same result of
+// function set by WithReverseLookup. Keys are returned in no particular order. Returns nil if reverse lookup is not set.
+func (c *cacheImpl[K, V]) FindKeys(value V) []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.reverse == nil {
+		return nil
+	}
+	return c.reverse.find(c.reverse.fn(value))
+}
+
+// InvalidateByIndex removes all entries with index value returned by function of the index set with WithIndex
+func (c *cacheImpl[K, V]) InvalidateByIndex(name, indexValue string) {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	idx, ok := c.indexes[name]
+	if !ok {
+		return
+	}
+	for key := range idx.keys[indexValue] {
+		c.invalidate(key)
+	}
+}
+
+// indexAdd adds the entry to all indexes. Has to be called with lock!
+func (c *cacheImpl[K, V]) indexAdd(key K, value V) {
+	for _, idx := range c.indexes {
+		idx.add(key, value)
+	}
+	if c.reverse != nil {
+		c.reverse.add(key, value)
+	}
+}
+
+// indexRemove removes the entry from all indexes. Has to be called with lock!
+func (c *cacheImpl[K, V]) indexRemove(key K) {
+	for _, idx := range c.indexes {
+		idx.remove(key)
+	}
+	if c.reverse != nil {
+		c.reverse.remove(key)
+	}
+}
+
+// indexReset removes all entries from all indexes. Has to be called with lock!
+func (c *cacheImpl[K, V]) indexReset() {
+	for _, idx := range c.indexes {
+		idx.keys, idx.attrs = map[any]map[K]struct{}{}, map[K]any{}
+	}
+	if c.reverse != nil {
+		c.reverse.keys, c.reverse.attrs = map[any]map[K]struct{}{}, map[K]any{}
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantValue struct {
+	tenant string
+	data   string
+}
+
+func TestCache_Index(t *testing.T) {
+	lc := NewCache[string, tenantValue]().WithMaxKeys(4).
+		WithIndex("tenant", func(v tenantValue) string { return v.tenant })
+	lc.Set("key1", tenantValue{"t1", "a"}, 0)
+	lc.Set("key2", tenantValue{"t1", "b"}, 0)
+	lc.Set("key3", tenantValue{"t2", "c"}, 0)
+	assert.ElementsMatch(t, []string{"key1", "key2"}, lc.KeysByIndex("tenant", "t1"))
+	assert.ElementsMatch(t, []string{"key3"}, lc.KeysByIndex("tenant", "t2"))
+	assert.Empty(t, lc.KeysByIndex("tenant", "t3"))
+	assert.Nil(t, lc.KeysByIndex("no-such-index", "t1"))
+
+	lc.Set("key2", tenantValue{"t2", "b"}, 0)
+	assert.ElementsMatch(t, []string{"key1"}, lc.KeysByIndex("tenant", "t1"), "moved on update")
+	assert.ElementsMatch(t, []string{"key2", "key3"}, lc.KeysByIndex("tenant", "t2"))
+
+	lc.Set("key4", tenantValue{"t3", "d"}, 0)
+	lc.Set("key5", tenantValue{"t3", "e"}, 0)
+	assert.Empty(t, lc.KeysByIndex("tenant", "t1"), "removed on eviction")
+
+	lc.InvalidateByIndex("tenant", "t2")
+	assert.Equal(t, []string{"key4", "key5"}, lc.Keys())
+	lc.InvalidateByIndex("no-such-index", "t3")
+	assert.Equal(t, 2, lc.Len())
+
+	lc.Purge()
+	assert.Empty(t, lc.KeysByIndex("tenant", "t3"))
+}
+
+func TestCache_FindKeys(t *testing.T) {
+	lc := NewCache[string, int]().WithReverseLookup(nil)
+	lc.Set("alias1", 1, 0)
+	lc.Set("alias2", 1, 0)
+	lc.Set("other", 2, 0)
+	assert.ElementsMatch(t, []string{"alias1", "alias2"}, lc.FindKeys(1))
+	for _, key := range lc.FindKeys(1) {
+		lc.Invalidate(key)
+	}
+	assert.Empty(t, lc.FindKeys(1))
+	assert.Equal(t, []string{"other"}, lc.Keys())
+	assert.Nil(t, NewCache[string, int]().FindKeys(1), "reverse lookup not set")
+
+	type object struct {
+		id   int
+		tags []string
+	}
+	oc := NewCache[string, object]().WithReverseLookup(func(v object) any { return v.id })
+	oc.Set("key1", object{id: 1, tags: []string{"a"}}, 0)
+	oc.Set("key2", object{id: 1, tags: []string{"b"}}, 0)
+	oc.Set("key2", object{id: 2}, 0)
+	assert.ElementsMatch(t, []string{"key1"}, oc.FindKeys(object{id: 1}))
+	assert.ElementsMatch(t, []string{"key2"}, oc.FindKeys(object{id: 2}))
+	oc.Purge()
+	assert.Empty(t, oc.FindKeys(object{id: 1}))
+}
+
+func TestCache_IndexMutatedValue(t *testing.T) {
+	lc := NewCache[string, *tenantValue]().WithIndex("tenant", func(v *tenantValue) string { return v.tenant })
+	val := &tenantValue{"t1", "a"}
+	lc.Set("key1", val, 0)
+	val.tenant = "t2" // the index function returns another attribute for the stored value now
+	lc.Invalidate("key1")
+	assert.Empty(t, lc.KeysByIndex("tenant", "t1"), "removed by the attribute set on add")
+	assert.Empty(t, lc.KeysByIndex("tenant", "t2"))
+}
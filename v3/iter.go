@@ -0,0 +1,27 @@
+//go:build go1.23
+
+package cache
+
+import "iter"
+
+// All returns iterator over all non-expired entries of the cache with their expiration time, from the oldest
+// to the newest, made with Range, so the cache is not locked during iteration, entries removed or replaced after
+// iteration starts are skipped, and the current entry is pinned. It's a function rather than a method of Cache,
+// so Cache interface stays the same regardless of Go version. Requires Go 1.23.
+func All[K comparable, V any](c Cache[K, V]) iter.Seq2[K, Entry[K, V]] {
+	return func(yield func(K, Entry[K, V]) bool) {
+		c.Range(func(e Entry[K, V]) bool { return yield(e.Key, e) })
+	}
+}
+
+// All returns iterator over entries of the view with their expiration time, from the oldest to the newest.
+// Requires Go 1.23.
+func (v View[K, V]) All() iter.Seq2[K, Entry[K, V]] {
+	return func(yield func(K, Entry[K, V]) bool) {
+		for _, e := range v.entries {
+			if !yield(e.Key, e) {
+				return
+			}
+		}
+	}
+}
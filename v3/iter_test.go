@@ -0,0 +1,35 @@
+//go:build go1.23
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_All(t *testing.T) {
+	lc := NewCache[string, int]()
+	lc.Set("key1", 1, time.Hour)
+	lc.Set("key2", 2, time.Minute)
+	lc.Set("key3", 3, time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+
+	var keys []string
+	for key, e := range All(lc) {
+		keys = append(keys, key)
+		exp, ok := lc.GetExpiration(key)
+		assert.True(t, ok)
+		assert.Equal(t, exp, e.ExpiresAt)
+		assert.Equal(t, key, e.Key)
+		lc.Set("key4", 4, 0) // cache is not locked during iteration
+	}
+	assert.Equal(t, []string{"key1", "key2"}, keys)
+
+	for key, e := range lc.SnapshotView().All() {
+		assert.Equal(t, "key1", key)
+		assert.Equal(t, 1, e.Value)
+		break
+	}
+}
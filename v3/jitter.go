@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// jitteredTTL returns ttl randomly changed by up to the part of it set by WithTTLJitter. TTL of entries
+// which never expire or expire right away is kept as is. Has to be called with lock!
+func (c *cacheImpl[K, V]) jitteredTTL(ttl time.Duration) time.Duration {
+	maxJitter := int64(float64(ttl) * c.jitter)
+	if maxJitter <= 0 || ttl == noEvictionTTL {
+		return ttl
+	}
+	if c.rand == nil {
+		c.rand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // jitter doesn't need crypto random
+	}
+	if res := ttl + time.Duration(c.rand.Int63n(2*maxJitter+1)-maxJitter); res > 0 {
+		return res
+	}
+	return ttl
+}
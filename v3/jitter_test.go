@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWithTTLJitter(t *testing.T) {
+	ttls := func(c Cache[string, string]) (res []time.Duration) {
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("key%d", i)
+			c.Set(key, "val", time.Second)
+			created, _ := c.GetCreation(key)
+			exp, _ := c.GetExpiration(key)
+			res = append(res, exp.Sub(created))
+		}
+		return res
+	}
+	first := ttls(NewCache[string, string]().WithTTLJitter(0.1).WithRand(rand.NewSource(42)))
+	assert.Equal(t, first, ttls(NewCache[string, string]().WithTTLJitter(0.1).WithRand(rand.NewSource(42))),
+		"same seed, same jitter")
+	assert.NotEqual(t, first, ttls(NewCache[string, string]().WithTTLJitter(0.1).WithRand(rand.NewSource(43))))
+	for _, ttl := range first {
+		assert.GreaterOrEqual(t, ttl, time.Millisecond*900)
+		assert.LessOrEqual(t, ttl, time.Millisecond*1100)
+	}
+	for _, ttl := range ttls(NewCache[string, string]().WithTTLJitter(0.5)) {
+		assert.GreaterOrEqual(t, ttl, time.Millisecond*500)
+		assert.LessOrEqual(t, ttl, time.Millisecond*1500)
+	}
+	for _, ttl := range ttls(NewCache[string, string]()) {
+		assert.Equal(t, time.Second, ttl, "no jitter by default")
+	}
+
+	lc := NewCache[string, string]().WithTTLJitter(0.5)
+	lc.Set("key1", "val1", NoTTL)
+	created, _ := lc.GetCreation("key1")
+	exp, _ := lc.GetExpiration("key1")
+	assert.Equal(t, NoTTL, exp.Sub(created), "entry without expiration not affected")
+}
@@ -0,0 +1,78 @@
+// Package keyedmutex provides mutex per key, e.g. to guard the fill path of a cache, so only one caller
+// loads the missing value of the key while callers for other keys are not blocked.
+package keyedmutex
+
+import "sync"
+
+// KeyedMutex is a set of mutexes, one per key. Mutex of a key exists only while it's locked
+// or waited for, so memory use is bounded by the number of concurrently used keys. Zero value is ready to use.
+type KeyedMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyLock
+}
+
+// keyLock is a mutex of a single key with number of its holders and waiters
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Lock locks the key, blocking until it's available
+func (m *KeyedMutex[K]) Lock(key K) {
+	m.acquire(key).Lock()
+}
+
+// TryLock tries to lock the key without blocking, returns true on success
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	l := m.acquire(key)
+	if l.TryLock() {
+		return true
+	}
+	m.release(key)
+	return false
+}
+
+// Unlock unlocks the key. It's a run-time error if the key is not locked, the same as with sync.Mutex.
+func (m *KeyedMutex[K]) Unlock(key K) {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	m.mu.Unlock()
+	if !ok {
+		panic("keyedmutex: unlock of unlocked key")
+	}
+	l.Unlock()
+	m.release(key)
+}
+
+// Len returns number of keys locked or waited for
+func (m *KeyedMutex[K]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locks)
+}
+
+// acquire returns the key mutex, making a new one if needed, and counts the caller as its user
+func (m *KeyedMutex[K]) acquire(key K) *keyLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks == nil {
+		m.locks = map[K]*keyLock{}
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+// release drops the caller from the key mutex users, deleting the mutex once it has no users
+func (m *KeyedMutex[K]) release(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.locks[key]
+	if l.refs--; l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
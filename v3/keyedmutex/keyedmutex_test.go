@@ -0,0 +1,38 @@
+package keyedmutex
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m KeyedMutex[string]
+	m.Lock("key1")
+	assert.False(t, m.TryLock("key1"), "key is locked")
+	assert.True(t, m.TryLock("key2"), "other keys are not blocked")
+	assert.Equal(t, 2, m.Len())
+	m.Unlock("key2")
+	m.Unlock("key1")
+	assert.Equal(t, 0, m.Len(), "unused mutexes are deleted")
+	assert.Panics(t, func() { m.Unlock("key1") })
+
+	counters := map[string]*int{"a": new(int), "b": new(int), "c": new(int)}
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := []string{"a", "b", "c"}[i%3]
+			m.Lock(key)
+			defer m.Unlock(key)
+			*counters[key]++ // race detector catches it unless the key is guarded
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 34, *counters["a"])
+	assert.Equal(t, 33, *counters["b"])
+	assert.Equal(t, 33, *counters["c"])
+	assert.Equal(t, 0, m.Len())
+}
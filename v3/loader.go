@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/pprof"
+	"strconv"
+	"time"
+)
+
+// loaderKeyBuckets is a number of key hash buckets in pprof labels of loader calls
+const loaderKeyBuckets = 16
+
+// inflightLoad is a loader call shared by all callers waiting for the same key
+type inflightLoad[V any] struct {
+	done    chan struct{} // closed when the load is completed
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+	results []chan Result[V] // GetAsync callers, notified when the load is completed
+}
+
+// Limiter limits the rate of loader calls, *rate.Limiter from golang.org/x/time/rate satisfies it.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// LoadResult is a value returned by loader set by WithResultLoader, along with the way it's cached
+type LoadResult[V any] struct {
+	Value   V
+	TTL     time.Duration // TTL of the entry, cache-wide TTL if 0, the same way as for Set
+	Cost    int64         // cost of the entry, the cost set by WithMaxCost if 0
+	NoStore bool          // value is returned to callers without adding it to the cache
+}
+
+// Result is a value or error returned by GetAsync
+type Result[V any] struct {
+	Value V
+	Err   error
+}
+
+// GetCtx returns the key value if it's in the cache and not expired, otherwise loads it with the loader
+// set by WithLoader. Concurrent calls for the same key share a single loader call, which runs in a separate
+// goroutine and is canceled once contexts of all waiting callers are done. Loader context keeps values
+// of ctx of the caller which started the load.
+// Returns ErrNoLoader if loader is not set, loader error wrapped with ErrLoaderFailed,
+// or ctx error in case ctx is done before the value is loaded. Done ctx is respected for cached values as well,
+// so a request past its deadline gets ctx error without touching the cache, the same way as on load.
+func (c *cacheImpl[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	if err := ctx.Err(); err != nil {
+		return *new(V), err
+	}
+	c.lock(OpOther)
+	if value, ok := c.parentLookup(key, false, OpOther); ok {
+		c.unlock(OpOther)
+		return value, nil
+	}
+	if value, ok := c.get(key); ok {
+		c.unlock(OpOther)
+		return value, nil
+	}
+	if c.closed {
+		c.unlock(OpOther)
+		return *new(V), ErrClosed
+	}
+	if c.loader == nil {
+		c.unlock(OpOther)
+		return *new(V), ErrNoLoader
+	}
+	load := c.startLoad(ctx, key)
+	load.waiters++
+	c.unlock(OpOther)
+	return c.waitLoad(ctx, key, load)
+}
+
+// Wait blocks until in-flight load of the key started by GetCtx is completed, or ctx is done.
+// Returns loaded value and loader error, ok is true if the value is loaded successfully.
+// In case there is no in-flight load, returns the cached value without waiting, the same way Peek does.
+// Waiting caller keeps the load from being canceled, the same way GetCtx callers do.
+func (c *cacheImpl[K, V]) Wait(ctx context.Context, key K) (value V, ok bool, err error) {
+	c.lock(OpOther)
+	if c.closed {
+		c.unlock(OpOther)
+		return value, false, ErrClosed
+	}
+	load, inflight := c.inflight[key]
+	if !inflight {
+		defer c.unlock(OpOther)
+		if value, ok = c.parentLookup(key, true, OpOther); ok {
+			return value, true, nil
+		}
+		value, ok = c.peek(key)
+		return value, ok, nil
+	}
+	load.waiters++
+	c.unlock(OpOther)
+	if value, err = c.waitLoad(ctx, key, load); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// waitLoad waits for load to complete or ctx to be done, load is canceled when the last waiter is gone.
+// Canceled load is forgotten right away, so the next caller starts a new load instead of joining the canceled one.
+// Has to be called without lock, with load.waiters already incremented.
+func (c *cacheImpl[K, V]) waitLoad(ctx context.Context, key K, load *inflightLoad[V]) (V, error) {
+	select {
+	case <-load.done:
+		return c.copyValue(load.value), load.err
+	case <-ctx.Done():
+		c.lock(OpOther)
+		load.waiters--
+		if load.waiters == 0 {
+			load.cancel()
+			if c.inflight[key] == load {
+				delete(c.inflight, key)
+			}
+		}
+		c.unlock(OpOther)
+		return *new(V), ctx.Err()
+	}
+}
+
+// GetAsync returns channel which receives the key value right away if it's in the cache and not expired,
+// otherwise it receives the value (or error) once it's loaded, the same way GetCtx does.
+// Waiting for many keys doesn't require a goroutine per key, and the load is never canceled.
+// The channel is buffered and receives exactly one result.
+func (c *cacheImpl[K, V]) GetAsync(key K) <-chan Result[V] {
+	res := make(chan Result[V], 1)
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if value, ok := c.parentLookup(key, false, OpOther); ok {
+		res <- Result[V]{Value: value}
+		return res
+	}
+	if value, ok := c.get(key); ok {
+		res <- Result[V]{Value: value}
+		return res
+	}
+	if c.closed {
+		res <- Result[V]{Err: ErrClosed}
+		return res
+	}
+	if c.loader == nil {
+		res <- Result[V]{Err: ErrNoLoader}
+		return res
+	}
+	load := c.startLoad(context.Background(), key)
+	load.waiters++
+	load.results = append(load.results, res)
+	return res
+}
+
+// startLoad returns in-flight load of the key, starting a new one with values of parent if there is none.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) startLoad(parent context.Context, key K) *inflightLoad[V] {
+	if load, ok := c.inflight[key]; ok {
+		return load
+	}
+	ctx, load := c.newLoad(parent, key)
+	go c.runLoad(ctx, key, load)
+	return load
+}
+
+// newLoad registers in-flight load of the key, which has to be run with runLoad. Load context keeps values
+// of parent, but not its cancellation, as the load is shared by all waiting callers. Has to be called with lock!
+func (c *cacheImpl[K, V]) newLoad(parent context.Context, key K) (context.Context, *inflightLoad[V]) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	load := &inflightLoad[V]{done: make(chan struct{}), cancel: cancel}
+	c.inflight[key] = load
+	c.logDebug("loader call started", slog.Any("key", key))
+	return ctx, load
+}
+
+// runLoad calls the loader, adds loaded value to the cache and notifies callers waiting for the load
+func (c *cacheImpl[K, V]) runLoad(ctx context.Context, key K, load *inflightLoad[V]) {
+	defer load.cancel()
+	start := time.Now()
+	res, err := c.callLoader(ctx, key)
+	took := time.Since(start)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrLoaderFailed, err)
+	}
+	c.lock(OpOther)
+	current := c.inflight[key] == load // not canceled and replaced by a new load
+	if err == nil && !res.NoStore && current {
+		soft, grace := c.softHardTTL(key) // refreshed entry keeps soft and hard TTL set by SetWithSoftHardTTL
+		ttl := res.TTL
+		if ttl == 0 {
+			ttl = DefaultTTL
+			if grace > 0 {
+				ttl = soft
+			}
+		}
+		c.addEntry(key, res.Value, ttl, res.Cost, false)
+		c.setGrace(key, grace)
+	}
+	c.countMissCost(key, took)
+	value := res.Value
+	load.value, load.err = value, err
+	if current {
+		delete(c.inflight, key)
+	}
+	results := load.results
+	c.unlock(OpOther)
+	close(load.done)
+	for _, res := range results {
+		res <- Result[V]{Value: c.copyValue(value), Err: err}
+	}
+}
+
+// callLoader calls the loader once allowed by the limiter, converting loader panic to error.
+// Loader runs with pprof labels of the cache name and key hash bucket, so CPU profiles attribute
+// the fill work to the cache and the part of the keyspace it was made for.
+func (c *cacheImpl[K, V]) callLoader(ctx context.Context, key K) (res LoadResult[V], err error) {
+	if c.loaderLimiter != nil {
+		if err = c.loaderLimiter.Wait(ctx); err != nil {
+			return res, fmt.Errorf("loader rate limit: %w", err)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("loader panic: %v", r)
+		}
+	}()
+	labels := pprof.Labels("cache", c.name, "key_bucket", strconv.FormatUint(hashKey(key)%loaderKeyBuckets, 10))
+	pprof.Do(ctx, labels, func(ctx context.Context) { res, err = c.loader(ctx, key) })
+	return res, err
+}
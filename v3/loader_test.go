@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheGetCtx(t *testing.T) {
+	var calls int32
+	lc := NewCache[string, string]().WithLoader(func(_ context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		if key == "bad" {
+			return "", errors.New("can't load")
+		}
+		time.Sleep(time.Millisecond * 10)
+		return "val-" + key, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := lc.GetCtx(context.Background(), "key1")
+			assert.NoError(t, err)
+			assert.Equal(t, "val-key1", v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "concurrent calls share single load")
+
+	v, err := lc.GetCtx(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "val-key1", v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "loaded value is cached")
+	stats := lc.Stat()
+	assert.Equal(t, uint64(1), stats.CostedMisses, "loader call duration is counted as miss cost")
+	assert.GreaterOrEqual(t, stats.MissCost, 10*time.Millisecond)
+	stats.MissCost, stats.CostedMisses = 0, 0
+	assert.Equal(t, Stats{Hits: 1, Misses: 10, Added: 1}, stats)
+
+	_, err = lc.GetCtx(context.Background(), "bad")
+	assert.EqualError(t, err, "loader failed: can't load")
+	assert.ErrorIs(t, err, ErrLoaderFailed)
+	assert.False(t, lc.Contains("bad"), "errors are not cached")
+
+	_, err = NewCache[string, string]().GetCtx(context.Background(), "key1")
+	assert.ErrorIs(t, err, ErrNoLoader)
+}
+
+func TestCacheGetCtxValues(t *testing.T) {
+	type ctxKey struct{}
+	lc := NewCache[string, string]().WithLoader(func(ctx context.Context, key string) (string, error) {
+		v, _ := ctx.Value(ctxKey{}).(string)
+		return v + "-" + key, nil
+	})
+	v, err := lc.GetCtx(context.WithValue(context.Background(), ctxKey{}, "req1"), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "req1-key1", v, "loader gets values of the caller ctx")
+}
+
+func TestCacheGetCtxCancel(t *testing.T) {
+	loadCanceled := make(chan struct{})
+	lc := NewCache[string, string]().WithLoader(func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		close(loadCanceled)
+		return "", ctx.Err()
+	})
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel2()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := lc.GetCtx(ctx1, "key1")
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := lc.GetCtx(ctx2, "key1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}()
+
+	time.Sleep(time.Millisecond * 5)
+	cancel1()
+	select {
+	case <-loadCanceled:
+		t.Fatal("load canceled while a caller is still waiting")
+	case <-time.After(time.Millisecond * 5):
+	}
+	wg.Wait()
+
+	select {
+	case <-loadCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("load is not canceled after all callers gone")
+	}
+}
+
+func TestCacheGetCtxAfterCancel(t *testing.T) {
+	var calls atomic.Int32
+	lc := NewCache[string, string]().WithLoader(func(ctx context.Context, _ string) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			time.Sleep(time.Millisecond * 20) // canceled load finishes late
+			return "", ctx.Err()
+		}
+		return "val", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err := lc.GetCtx(ctx, "key1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	v, err := lc.GetCtx(context.Background(), "key1")
+	require.NoError(t, err, "new load started instead of joining the canceled one")
+	assert.Equal(t, "val", v)
+	assert.Equal(t, int32(2), calls.Load())
+
+	time.Sleep(time.Millisecond * 30)
+	v, ok := lc.Get("key1")
+	assert.True(t, ok, "late canceled load doesn't affect the cache")
+	assert.Equal(t, "val", v)
+}
+
+func TestCacheGetCtxDone(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	v, err := lc.GetCtx(ctx, "key1")
+	require.NoError(t, err, "cached value doesn't need loader")
+	assert.Equal(t, "val1", v)
+
+	cancel()
+	_, err = lc.GetCtx(ctx, "key1")
+	assert.ErrorIs(t, err, context.Canceled, "done ctx is respected for cached value")
+	assert.Equal(t, uint64(1), lc.Stat().Hits, "cache is not touched")
+}
+
+func TestCacheGetCtxLoaderPanic(t *testing.T) {
+	lc := NewCache[string, string]().WithLoader(func(context.Context, string) (string, error) {
+		panic("boom")
+	})
+	_, err := lc.GetCtx(context.Background(), "key1")
+	assert.EqualError(t, err, "loader failed: loader panic: boom")
+}
+
+func TestCacheLoaderPprofLabels(t *testing.T) {
+	lc := NewCache[string, string]().WithName("users").WithLoader(func(ctx context.Context, _ string) (string, error) {
+		name, _ := pprof.Label(ctx, "cache")
+		bucket, _ := pprof.Label(ctx, "key_bucket")
+		return name + ":" + bucket, nil
+	})
+	v, err := lc.GetCtx(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "users:"+strconv.FormatUint(hashKey("key1")%loaderKeyBuckets, 10), v)
+}
+
+func TestCacheWithResultLoader(t *testing.T) {
+	lc := NewCache[string, string]().WithTTL(time.Hour).WithMaxCost(10, nil).
+		WithResultLoader(func(_ context.Context, key string) (LoadResult[string], error) {
+			switch key {
+			case "fresh":
+				return LoadResult[string]{Value: "val1", TTL: time.Minute, Cost: 3}, nil
+			case "private":
+				return LoadResult[string]{Value: "val2", NoStore: true}, nil
+			}
+			return LoadResult[string]{Value: "val3"}, nil
+		})
+
+	v, err := lc.GetCtx(context.Background(), "fresh")
+	require.NoError(t, err)
+	assert.Equal(t, "val1", v)
+	exp, ok := lc.GetExpiration("fresh")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, time.Second, "TTL set by loader")
+
+	v, err = lc.GetCtx(context.Background(), "private")
+	require.NoError(t, err)
+	assert.Equal(t, "val2", v, "value returned to caller")
+	assert.False(t, lc.Contains("private"), "value not cached")
+
+	v, err = lc.GetCtx(context.Background(), "other")
+	require.NoError(t, err)
+	assert.Equal(t, "val3", v)
+	exp, ok = lc.GetExpiration("other")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second, "cache-wide TTL by default")
+	assert.Equal(t, int64(4), lc.(*cacheImpl[string, string]).totalCost, "cost set by loader, default cost otherwise")
+}
+
+func TestCacheWait(t *testing.T) {
+	release := make(chan struct{})
+	lc := NewCache[string, string]().WithLoader(func(_ context.Context, key string) (string, error) {
+		<-release
+		if key == "bad" {
+			return "", errors.New("can't load")
+		}
+		return "val-" + key, nil
+	})
+
+	v, ok, err := lc.Wait(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.False(t, ok, "no in-flight load and no cached value")
+	assert.Empty(t, v)
+
+	go func() { _, _ = lc.GetCtx(context.Background(), "key1") }()
+	go func() { _, _ = lc.GetCtx(context.Background(), "bad") }()
+	time.Sleep(time.Millisecond * 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*5)
+	defer cancel()
+	_, ok, err = lc.Wait(ctx, "key1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, ok)
+
+	go func() {
+		time.Sleep(time.Millisecond * 5)
+		close(release)
+	}()
+	v, ok, err = lc.Wait(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "val-key1", v)
+
+	_, ok, err = lc.Wait(context.Background(), "bad")
+	if err != nil { // load could be completed before Wait call
+		assert.EqualError(t, err, "loader failed: can't load")
+		assert.False(t, ok)
+	}
+
+	v, ok, err = lc.Wait(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.True(t, ok, "no in-flight load, cached value returned")
+	assert.Equal(t, "val-key1", v)
+}
+
+func TestCacheGetAsync(t *testing.T) {
+	var calls int32
+	lc := NewCache[string, string]().WithLoader(func(_ context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond * 10)
+		if key == "bad" {
+			return "", errors.New("can't load")
+		}
+		return "val-" + key, nil
+	})
+	lc.Set("cached", "val-cached", 0)
+
+	res := lc.GetAsync("cached")
+	select {
+	case r := <-res:
+		assert.Equal(t, Result[string]{Value: "val-cached"}, r)
+	default:
+		t.Fatal("cached value should be available right away")
+	}
+
+	results := []<-chan Result[string]{lc.GetAsync("key1"), lc.GetAsync("key1"), lc.GetAsync("bad")}
+	assert.Equal(t, Result[string]{Value: "val-key1"}, <-results[0])
+	assert.Equal(t, Result[string]{Value: "val-key1"}, <-results[1])
+	r := <-results[2]
+	assert.EqualError(t, r.Err, "loader failed: can't load")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	r = <-NewCache[string, string]().GetAsync("key1")
+	assert.ErrorIs(t, r.Err, ErrNoLoader)
+}
+
+// tokenLimiter allows a call for each token sent to it
+type tokenLimiter chan struct{}
+
+func (l tokenLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-l:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestCacheWithLoaderLimiter(t *testing.T) {
+	var calls int32
+	limiter := make(tokenLimiter, 10)
+	lc := NewCache[string, string]().WithLoaderLimiter(limiter).WithLoader(func(_ context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "val-" + key, nil
+	})
+
+	limiter <- struct{}{}
+	v, err := lc.GetCtx(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "val-key1", v)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err = lc.GetCtx(ctx, "key2")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "no tokens left")
+
+	res := lc.GetAsync("key3")
+	time.Sleep(time.Millisecond * 5)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	limiter <- struct{}{}
+	assert.Equal(t, Result[string]{Value: "val-key3"}, <-res)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// LockTimes are lock wait and hold times of an operation, collected with WithLockProfiling
+type LockTimes struct {
+	Calls   uint64        // number of calls
+	Wait    time.Duration // total time spent waiting for the lock
+	Hold    time.Duration // total time the lock was held
+	MaxWait time.Duration // the longest wait
+	MaxHold time.Duration // the longest hold
+}
+
+// LogValue implements slog.LogValuer, logging all the fields as a group
+func (t LockTimes) LogValue() slog.Value {
+	return slog.GroupValue(slog.Uint64("calls", t.Calls), slog.Duration("wait", t.Wait), slog.Duration("hold", t.Hold),
+		slog.Duration("max_wait", t.MaxWait), slog.Duration("max_hold", t.MaxHold))
+}
+
+// lockProfile collects lock times per operation, set by WithLockProfiling
+type lockProfile struct {
+	enabled  bool
+	ops      [OpOther + 1]LockTimes
+	acquired time.Time // when the lock was taken by the current holder
+}
+
+// LockProfile returns lock wait and hold times of Get, Peek, Set and DeleteExpired calls, with all the other
+// calls taking the lock counted under OpOther, collected since the cache was made, or nil if WithLockProfiling
+// is not set. Waits much longer than holds mean lock contention, which sharding solves, while long holds mean
+// the time is spent inside the cache, e.g. on hashing or callbacks.
+func (c *cacheImpl[K, V]) LockProfile() map[Op]LockTimes {
+	if !c.lockProf.enabled {
+		return nil
+	}
+	c.Lock() // not profiled, as the profile is copied before the hold time is known
+	defer c.Unlock()
+	res := make(map[Op]LockTimes, len(c.lockProf.ops))
+	for op, t := range c.lockProf.ops {
+		res[Op(op)] = t
+	}
+	return res
+}
+
+// lock takes the lock for the operation, counting the wait time if WithLockProfiling is set
+func (c *cacheImpl[K, V]) lock(op Op) {
+	if !c.lockProf.enabled {
+		c.Lock()
+		return
+	}
+	start := time.Now()
+	c.Lock()
+	c.lockProf.acquired = time.Now()
+	t := &c.lockProf.ops[op]
+	wait := c.lockProf.acquired.Sub(start)
+	t.Calls++
+	t.Wait += wait
+	t.MaxWait = max(t.MaxWait, wait)
+}
+
+// unlock releases the lock taken by lock, counting the hold time if WithLockProfiling is set,
+// and pushes stats to the sink set by WithStatsSink in case they are due
+func (c *cacheImpl[K, V]) unlock(op Op) {
+	if c.lockProf.enabled {
+		t := &c.lockProf.ops[op]
+		hold := time.Since(c.lockProf.acquired)
+		t.Hold += hold
+		t.MaxHold = max(t.MaxHold, hold)
+	}
+	due := c.takeDueStats()
+	c.Unlock()
+	if due != nil {
+		c.sendStats(due)
+	}
+}
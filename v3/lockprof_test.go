@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheLockProfile(t *testing.T) {
+	assert.Nil(t, NewCache[string, int]().LockProfile(), "disabled by default")
+
+	lc := NewCache[string, int]().WithLockProfiling().
+		WithOnEvicted(func(string, int) { time.Sleep(time.Millisecond * 20) })
+	lc.Set("key1", 1, time.Millisecond)
+	lc.Add("key2", 2)
+	lc.Get("key1")
+	lc.Get("key2")
+	lc.Peek("key2")
+	lc.Len()
+	lc.Keys()
+	time.Sleep(time.Millisecond * 5)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lc.DeleteExpired() // holds the lock in OnEvicted
+	}()
+	time.Sleep(time.Millisecond * 5)
+	lc.Get("key2") // waits for DeleteExpired
+	wg.Wait()
+
+	prof := lc.LockProfile()
+	require.Len(t, prof, 5)
+	assert.Equal(t, uint64(2), prof[OpSet].Calls)
+	assert.Equal(t, uint64(3), prof[OpGet].Calls)
+	assert.Equal(t, uint64(1), prof[OpPeek].Calls)
+	assert.Equal(t, uint64(1), prof[OpDeleteExpired].Calls)
+	assert.Equal(t, uint64(2), prof[OpOther].Calls, "Len and Keys")
+	assert.GreaterOrEqual(t, prof[OpDeleteExpired].Hold, time.Millisecond*20)
+	assert.Equal(t, prof[OpDeleteExpired].Hold, prof[OpDeleteExpired].MaxHold)
+	assert.GreaterOrEqual(t, prof[OpGet].MaxWait, time.Millisecond*10, "Get waited for DeleteExpired")
+	assert.Less(t, prof[OpGet].MaxHold, time.Millisecond*10)
+	assert.GreaterOrEqual(t, prof[OpGet].Wait, prof[OpGet].MaxWait)
+}
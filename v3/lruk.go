@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"sort"
+)
+
+// lruK keeps history of the last k accesses of each entry for LRU-K eviction, evicting the entry
+// with the oldest k-th most recent access. Entries accessed less than k times are evicted first,
+// in LRU order. Accesses are counted with a logical clock.
+type lruK[K comparable] struct {
+	k       int
+	tick    uint64
+	queue   lruKQueue[K]
+	entries map[K]*lruKEntry[K]
+}
+
+type lruKEntry[K comparable] struct {
+	key     K
+	history []uint64 // ticks of the last k accesses from the oldest, capacity is k
+	index   int      // position in the queue
+}
+
+// kth returns tick of the k-th most recent access, 0 in case the entry is accessed less than k times
+func (e *lruKEntry[K]) kth() uint64 {
+	if len(e.history) < cap(e.history) {
+		return 0
+	}
+	return e.history[0]
+}
+
+// access records access of the key
+func (l *lruK[K]) access(key K) {
+	l.tick++
+	e, ok := l.entries[key]
+	if !ok {
+		e = &lruKEntry[K]{key: key, history: make([]uint64, 0, l.k)}
+		l.entries[key] = e
+		e.history = append(e.history, l.tick)
+		heap.Push(&l.queue, e)
+		return
+	}
+	if len(e.history) == l.k {
+		copy(e.history, e.history[1:])
+		e.history = e.history[:l.k-1]
+	}
+	e.history = append(e.history, l.tick)
+	heap.Fix(&l.queue, e.index)
+}
+
+// remove drops history of the removed key
+func (l *lruK[K]) remove(key K) {
+	if e, ok := l.entries[key]; ok {
+		heap.Remove(&l.queue, e.index)
+		delete(l.entries, key)
+	}
+}
+
+// reset drops history of all keys
+func (l *lruK[K]) reset() {
+	l.queue = nil
+	l.entries = map[K]*lruKEntry[K]{}
+}
+
+// victim returns the entry with the oldest k-th most recent access. The entry accessed the last,
+// i.e. just added, is skipped, as otherwise a new entry would always be evicted once other entries
+// are accessed k times. Entries for which skip returns true are passed over in favor of the next
+// candidate in the eviction order.
+func (l *lruK[K]) victim(items map[K]*list.Element, skip func(*list.Element) bool) *list.Element {
+	if len(l.queue) == 0 {
+		return nil
+	}
+	e := l.queue[0]
+	if len(l.queue) > 1 && e.history[len(e.history)-1] == l.tick {
+		e = l.queue[1]
+		if len(l.queue) > 2 && l.queue.Less(2, 1) {
+			e = l.queue[2]
+		}
+	}
+	if ent := items[e.key]; ent == nil || !skip(ent) {
+		return ent
+	}
+	// skipped entries are rare, e.g. pinned by Range, so the full eviction order is made only for them
+	for _, key := range l.order() {
+		if ent := items[key]; ent != nil && !skip(ent) {
+			return ent
+		}
+	}
+	return nil
+}
+
+// order returns keys in the order they would be evicted, the same way victim picks them
+func (l *lruK[K]) order() []K {
+	queue := append(lruKQueue[K](nil), l.queue...)
+	sort.Slice(queue, queue.Less) // swaps elements only, unlike Swap which updates their positions in the heap
+	res := make([]K, 0, len(queue))
+	var last []K // the entry accessed the last is skipped by victim while there are others
+	for _, e := range queue {
+		if len(queue) > 1 && e.history[len(e.history)-1] == l.tick {
+			last = append(last, e.key)
+			continue
+		}
+		res = append(res, e.key)
+	}
+	return append(res, last...)
+}
+
+// lruKQueue implements heap.Interface, ordered by k-th most recent access and then by the last access
+type lruKQueue[K comparable] []*lruKEntry[K]
+
+func (q lruKQueue[K]) Len() int { return len(q) }
+
+func (q lruKQueue[K]) Less(i, j int) bool {
+	ki, kj := q[i].kth(), q[j].kth()
+	if ki != kj {
+		return ki < kj
+	}
+	hi, hj := q[i].history, q[j].history
+	return hi[len(hi)-1] < hj[len(hj)-1]
+}
+
+func (q lruKQueue[K]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *lruKQueue[K]) Push(x any) {
+	e := x.(*lruKEntry[K])
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *lruKQueue[K]) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
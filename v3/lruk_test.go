@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_LRUK(t *testing.T) {
+	lc := NewCache[string, int]().WithLRUK(2).WithMaxKeys(3)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	lc.Get("key1")
+	lc.Get("key2")
+	lc.Set("key3", 3, 0)
+	lc.Set("key4", 4, 0)
+	assert.ElementsMatch(t, []string{"key1", "key2", "key4"}, lc.Keys(), "key3 accessed once evicted first")
+
+	lc.Set("key5", 5, 0)
+	assert.ElementsMatch(t, []string{"key1", "key2", "key5"}, lc.Keys())
+
+	lc.Get("key5")
+	lc.Get("key5")
+	lc.Set("key6", 6, 0)
+	assert.ElementsMatch(t, []string{"key2", "key5", "key6"}, lc.Keys(), "key1 has the oldest 2nd most recent access")
+
+	lc.Remove("key6")
+	lc.Set("key7", 7, 0)
+	lc.Set("key8", 8, 0)
+	assert.ElementsMatch(t, []string{"key2", "key5", "key8"}, lc.Keys())
+
+	lc.Purge()
+	assert.Empty(t, lc.(*cacheImpl[string, int]).lruK.entries)
+}
+
+func TestCache_LRUKPinned(t *testing.T) {
+	lc := NewCache[string, int]().WithLRUK(2).WithMaxKeys(4)
+	lc.Set("key3", 3, 0)
+	lc.Set("key4", 4, 0)
+	lc.Get("key4")
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	assert.Equal(t, []string{"key3", "key1", "key4", "key2"}, lc.EvictionOrder())
+
+	lc.Range(func(e Entry[string, int]) bool {
+		assert.Equal(t, "key3", e.Key)
+		lc.Set("key5", 5, 0) // key3 is pinned, the next LRU-K candidate is evicted instead
+		return false
+	})
+	assert.ElementsMatch(t, []string{"key2", "key3", "key4", "key5"}, lc.Keys(), "key1 evicted, not key4 next to key3 by recency")
+}
+
+func TestCache_LRUKScan(t *testing.T) {
+	// hot keys accessed repeatedly survive scans of keys accessed once after the first round, plain LRU evicts them
+	for _, tt := range []struct {
+		cache Cache[int, int]
+		hits  int
+	}{
+		{cache: NewCache[int, int]().WithLRUK(2).WithMaxKeys(20), hits: 90},
+		{cache: NewCache[int, int]().WithLRU().WithMaxKeys(20), hits: 0},
+	} {
+		lc := tt.cache
+		hits := 0
+		for round := 0; round < 10; round++ {
+			for hot := 0; hot < 10; hot++ {
+				if _, ok := lc.Get(hot); ok {
+					hits++
+					continue
+				}
+				lc.Set(hot, hot, 0)
+				lc.Get(hot)
+			}
+			for i := 0; i < 50; i++ {
+				lc.Set(1000+round*50+i, i, 0)
+			}
+		}
+		assert.Equal(t, tt.hits, hits, fmt.Sprintf("%T", lc))
+	}
+}
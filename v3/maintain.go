@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"container/list"
+	"log/slog"
+	"time"
+)
+
+// MaintenanceReport is a result of Maintain call
+type MaintenanceReport struct {
+	Expired   int           // expired entries deleted
+	Trimmed   int           // entries evicted to fit the size and cost limits
+	Compacted bool          // entries map rebuilt to release memory held after deletions
+	Size      int           // number of entries after maintenance
+	Duration  time.Duration // time spent, including the lock wait
+}
+
+// LogValue implements slog.LogValuer, logging all the report fields as a group
+func (r MaintenanceReport) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("expired", r.Expired), slog.Int("trimmed", r.Trimmed),
+		slog.Bool("compacted", r.Compacted), slog.Int("size", r.Size), slog.Duration("duration", r.Duration))
+}
+
+// compactRatio is a ratio of the peak number of entries to the current one which makes Maintain rebuild the map
+const compactRatio = 2
+
+// Maintain deletes expired entries, evicts entries over the size and cost limits, e.g. left after
+// pinned entries were skipped, and rebuilds the entries map in case it shrank to less than half of its peak size,
+// as Go maps don't release memory on deletion. It's designed to be called from a cron job or operator
+// endpoint, with the report logged. The cache is locked for the whole call.
+func (c *cacheImpl[K, V]) Maintain() MaintenanceReport {
+	start := time.Now()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	res := MaintenanceReport{Expired: c.deleteExpired()}
+	for c.maxKeys > 0 && c.evictList.Len() > c.maxKeys && c.removeOldest() {
+		res.Trimmed++
+	}
+	for c.maxCost > 0 && c.totalCost > c.maxCost && c.removeOldest() {
+		res.Trimmed++
+	}
+	if c.peakSize > compactRatio*len(c.items) {
+		items := make(map[K]*list.Element, len(c.items))
+		for key, ent := range c.items {
+			items[key] = ent
+		}
+		c.items, res.Compacted = items, true
+		c.peakSize = len(c.items)
+	}
+	res.Size = c.evictList.Len()
+	res.Duration = time.Since(start)
+	c.logDebug("cache maintained", slog.Any("report", res))
+	return res
+}
+
+// LastCleanup returns time of the last deletion of expired entries by DeleteExpired or Maintain,
+// zero time if there was none.
+func (c *cacheImpl[K, V]) LastCleanup() time.Time {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	return c.cleanedAt
+}
+
+// NextSuggestedCleanup returns the soonest expiration time of the entries, which is the time DeleteExpired
+// has something to delete, so external schedulers can call it exactly when needed instead of by a fixed ticker.
+// The time is in the past if there are expired entries already, and zero if no entries expire.
+// Entries are scanned under the lock, so it takes time proportional to the cache size.
+func (c *cacheImpl[K, V]) NextSuggestedCleanup() time.Time {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	var next time.Time
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		item := ent.Value.(*cacheItem[K, V])
+		if item.ttl == noEvictionTTL || c.pinned(ent) {
+			continue
+		}
+		if next.IsZero() || c.hardExpiration(item).Before(next) {
+			next = c.hardExpiration(item)
+		}
+	}
+	return next
+}
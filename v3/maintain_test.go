@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheMaintain(t *testing.T) {
+	lc := NewCache[string, int]().WithMaxKeys(10)
+	for i := 0; i < 10; i++ {
+		ttl := time.Hour
+		if i < 6 {
+			ttl = time.Millisecond
+		}
+		lc.Set(fmt.Sprintf("key%d", i), i, ttl)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	res := lc.Maintain()
+	assert.Equal(t, 6, res.Expired)
+	assert.Equal(t, 0, res.Trimmed)
+	assert.True(t, res.Compacted, "map shrank from 10 to 4 entries")
+	assert.Equal(t, 4, res.Size)
+	assert.Positive(t, res.Duration)
+
+	res = lc.Maintain()
+	assert.Equal(t, MaintenanceReport{Size: 4, Duration: res.Duration}, res, "nothing to do")
+
+	// entries over the limit left by pinning are trimmed
+	lc.Range(func(Entry[string, int]) bool {
+		lc.Range(func(e Entry[string, int]) bool {
+			if e.Key == "key7" {
+				assert.Equal(t, 2, lc.Resize(1), "key6 and key7 are pinned")
+				return false
+			}
+			return true
+		})
+		return false
+	})
+	assert.Equal(t, 2, lc.Len())
+	res = lc.Maintain()
+	assert.Equal(t, 1, res.Trimmed)
+	assert.Equal(t, 1, res.Size)
+
+	buf := bytes.Buffer{}
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("maintained", "report", res)
+	assert.Contains(t, buf.String(), "report.expired=0 report.trimmed=1 report.compacted=true report.size=1")
+}
+
+func TestCacheCleanupTime(t *testing.T) {
+	lc := NewCache[string, int]()
+	assert.True(t, lc.LastCleanup().IsZero())
+	assert.True(t, lc.NextSuggestedCleanup().IsZero(), "empty cache")
+	lc.Set("key1", 1, 0)
+	assert.True(t, lc.NextSuggestedCleanup().IsZero(), "entries never expire")
+
+	lc.Set("key2", 2, time.Hour)
+	lc.Set("key3", 3, time.Minute)
+	lc.Set("key4", 4, 2*time.Minute)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lc.NextSuggestedCleanup(), time.Second)
+
+	lc.Set("key5", 5, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	assert.True(t, lc.NextSuggestedCleanup().Before(time.Now()), "expired entry waits for cleanup")
+
+	lc.DeleteExpired()
+	assert.WithinDuration(t, time.Now(), lc.LastCleanup(), time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lc.NextSuggestedCleanup(), time.Second)
+	prev := lc.LastCleanup()
+	time.Sleep(time.Millisecond)
+	lc.Maintain()
+	assert.True(t, lc.LastCleanup().After(prev), "Maintain deletes expired entries as well")
+}
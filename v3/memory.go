@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"time"
+)
+
+// memoryUsage returns memory used by the process and its memory limit, set by debug.SetMemoryLimit or GOMEMLIMIT
+var memoryUsage = func() (used, limit uint64) {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+		{Name: "/gc/gomemlimit:bytes"},
+	}
+	metrics.Read(samples)
+	for _, s := range samples {
+		if s.Value.Kind() != metrics.KindUint64 {
+			return 0, math.MaxInt64
+		}
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64(), samples[2].Value.Uint64()
+}
+
+// gcCycles returns number of completed GC cycles
+var gcCycles = func() uint64 {
+	samples := []metrics.Sample{{Name: "/gc/cycles/total:gc-cycles"}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64()
+}
+
+// gcGate lets an action taken on memory pressure run again only after a GC cycle completes,
+// as memory used by removed entries is not released, and the usage doesn't drop, until GC runs
+type gcGate struct {
+	waiting bool   // action is taken, waiting for GC
+	gc      uint64 // GC cycles completed when the action was taken
+}
+
+// ready checks if the action can be taken
+func (g *gcGate) ready() bool {
+	return !g.waiting || gcCycles() > g.gc
+}
+
+// taken records the action taken, so the next one waits for GC
+func (g *gcGate) taken() {
+	g.waiting, g.gc = true, gcCycles()
+}
+
+// TrimToSize removes the oldest entries until the cache has no more than size entries, returning
+// the number of removed entries. Unlike Resize, it doesn't change the cache size limit.
+func (c *cacheImpl[K, V]) TrimToSize(size int) int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if size < 0 {
+		size = 0
+	}
+	removed := 0
+	for c.evictList.Len() > size && c.removeOldest() {
+		removed++
+	}
+	return removed
+}
+
+// WatchMemory checks the process memory usage every interval until ctx is done, and trims trimRatio (0..1)
+// of the cache entries each time the usage exceeds highWatermark (0..1) of the memory limit set with
+// debug.SetMemoryLimit or GOMEMLIMIT. After a trim the next one waits for a GC cycle to complete, as the usage
+// doesn't drop until GC releases memory of the removed entries. It's useful for caches limited in entries
+// rather than bytes. Does nothing without memory limit. It's blocking, so should be started in a separate goroutine.
+func WatchMemory[K comparable, V any](ctx context.Context, c Cache[K, V], interval time.Duration, highWatermark, trimRatio float64) {
+	var gate gcGate
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			trimOnMemoryPressure(c, &gate, highWatermark, trimRatio)
+		}
+	}
+}
+
+// trimOnMemoryPressure trims trimRatio of the cache entries in case memory usage exceeds highWatermark of the limit,
+// unless the previous trim is still waiting for GC
+func trimOnMemoryPressure[K comparable, V any](c Cache[K, V], gate *gcGate, highWatermark, trimRatio float64) int {
+	used, limit := memoryUsage()
+	if limit == math.MaxInt64 || float64(used) < highWatermark*float64(limit) || !gate.ready() {
+		return 0
+	}
+	gate.taken()
+	size := c.Len()
+	return c.TrimToSize(size - int(math.Ceil(float64(size)*trimRatio)))
+}
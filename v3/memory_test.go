@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_TrimToSize(t *testing.T) {
+	var demoted []string
+	lc := NewCache[string, string]().WithMaxKeys(10).WithOnDemote(func(key string, _ string, _ time.Time) {
+		demoted = append(demoted, key)
+	})
+	for i := 0; i < 5; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+	assert.Equal(t, 2, lc.TrimToSize(3))
+	assert.Equal(t, []string{"key2", "key3", "key4"}, lc.Keys())
+	assert.Equal(t, []string{"key0", "key1"}, demoted)
+	assert.Equal(t, 0, lc.TrimToSize(5))
+	assert.Equal(t, 3, lc.TrimToSize(-1))
+	assert.Equal(t, 0, lc.Len())
+
+	for i := 0; i < 15; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), "val", 0)
+	}
+	assert.Equal(t, 10, lc.Len(), "size limit not changed")
+}
+
+func TestWatchMemory(t *testing.T) {
+	origUsage, origGC := memoryUsage, gcCycles
+	defer func() { memoryUsage, gcCycles = origUsage, origGC }()
+
+	used, limit := uint64(50), uint64(100)
+	memoryUsage = func() (uint64, uint64) { return used, limit }
+	var gc atomic.Uint64
+	gcCycles = gc.Load
+
+	lc := NewCache[int, int]()
+	for i := 0; i < 100; i++ {
+		lc.Set(i, i, 0)
+	}
+	var gate gcGate
+	assert.Equal(t, 0, trimOnMemoryPressure(lc, &gate, 0.9, 0.25), "below high watermark")
+
+	used = 95
+	assert.Equal(t, 25, trimOnMemoryPressure(lc, &gate, 0.9, 0.25))
+	assert.Equal(t, 75, lc.Len())
+	assert.Equal(t, 0, trimOnMemoryPressure(lc, &gate, 0.9, 0.25), "waiting for GC")
+	gc.Add(1)
+	assert.Equal(t, 19, trimOnMemoryPressure(lc, &gate, 0.9, 0.25), "trimmed again after GC")
+	assert.Equal(t, 56, lc.Len())
+
+	gc.Add(1)
+	limit = math.MaxInt64
+	assert.Equal(t, 0, trimOnMemoryPressure(lc, &gate, 0.9, 0.25), "no memory limit")
+
+	limit = 100
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchMemory[int, int](ctx, lc, time.Millisecond, 0.9, 0.5)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return lc.Len() == 28 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(t, 28, lc.Len(), "trimmed once until GC")
+	gc.Add(1)
+	assert.Eventually(t, func() bool { return lc.Len() == 14 }, time.Second, time.Millisecond, "trimmed again after GC")
+	cancel()
+	<-done
+}
+
+func TestMemoryUsage(t *testing.T) {
+	used, limit := memoryUsage()
+	assert.Greater(t, used, uint64(0))
+	assert.Greater(t, limit, uint64(0))
+}
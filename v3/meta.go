@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"maps"
+	"time"
+)
+
+// SetWithMeta sets the key value with ttl, the same way Set does, and attaches opaque metadata to the entry,
+// e.g. which component added it and from which upstream version, returned by GetEntry for debugging.
+// Metadata is copied, and it's dropped once the key is written again without metadata.
+func (c *cacheImpl[K, V]) SetWithMeta(key K, value V, ttl time.Duration, meta map[string]string) {
+	defer c.writeThrough()
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	c.lock(OpSet)
+	defer c.unlock(OpSet)
+	c.add(key, value, ttl, true)
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*cacheItem[K, V]).meta = maps.Clone(meta)
+	}
+}
+
+// GetEntry returns the entry of the key with its expiration time and metadata set by SetWithMeta,
+// without updating the "recently used"-ness of the key and stats, the same way GetQuiet does.
+// Expired entry is returned with ok set to false.
+func (c *cacheImpl[K, V]) GetEntry(key K) (e Entry[K, V], ok bool) {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	ent, found := c.items[key]
+	if !found {
+		return e, false
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	e = Entry[K, V]{Key: key, Value: c.copyValue(item.value), ExpiresAt: c.expiration(item), Meta: maps.Clone(item.meta)}
+	return e, !time.Now().After(c.expiration(item))
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheSetWithMeta(t *testing.T) {
+	lc := NewCache[string, string]()
+	meta := map[string]string{"component": "billing", "version": "v1.2.3"}
+	lc.SetWithMeta("key1", "val1", time.Minute, meta)
+	meta["component"] = "changed"
+
+	e, ok := lc.GetEntry("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "key1", e.Key)
+	assert.Equal(t, "val1", e.Value)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), e.ExpiresAt, time.Second)
+	assert.Equal(t, map[string]string{"component": "billing", "version": "v1.2.3"}, e.Meta, "meta copied on set")
+	e.Meta["version"] = "changed"
+	e, _ = lc.GetEntry("key1")
+	assert.Equal(t, "v1.2.3", e.Meta["version"], "meta copied on get")
+	assert.Equal(t, Stats{Added: 1}, lc.Stat(), "GetEntry doesn't count hits")
+
+	lc.Set("key1", "val2", 0)
+	e, ok = lc.GetEntry("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val2", e.Value)
+	assert.Nil(t, e.Meta, "write without meta drops it")
+
+	lc.SetWithMeta("key2", "val2", ExpireNow, map[string]string{"a": "b"})
+	e, ok = lc.GetEntry("key2")
+	assert.False(t, ok, "expired")
+	assert.Equal(t, "b", e.Meta["a"])
+	_, ok = lc.GetEntry("missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// TTLItem is an entry of another TTL cache, *ttlcache.Item from jellydator/ttlcache/v3 satisfies it
+type TTLItem[K comparable, V any] interface {
+	Key() K
+	Value() V
+	ExpiresAt() time.Time
+}
+
+// FromTTLCache adds entries of jellydator/ttlcache, passed as items returned by its Items method, to the cache,
+// keeping their remaining TTL, and returns the cache. Entries are added in order of their expiration,
+// entries without expiration are added the last with the cache default TTL, and expired entries are skipped.
+// Can be used to migrate the entries to the cache, set up with the needed options, in a running service.
+func FromTTLCache[K comparable, V any, I TTLItem[K, V]](c Cache[K, V], items map[K]I) Cache[K, V] {
+	sorted := make([]I, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, item)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		ei, ej := sorted[i].ExpiresAt(), sorted[j].ExpiresAt()
+		if ei.IsZero() || ej.IsZero() {
+			return !ei.IsZero() && ej.IsZero()
+		}
+		return ei.Before(ej)
+	})
+
+	now := time.Now()
+	for _, item := range sorted {
+		ttl := DefaultTTL
+		if !item.ExpiresAt().IsZero() {
+			if ttl = item.ExpiresAt().Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		c.Set(item.Key(), item.Value(), ttl)
+	}
+	return c
+}
+
+// FromHashicorpLRU adds entries of hashicorp/golang-lru/v2 cache, either simplelru.LRU, expirable.LRU or
+// the thread-safe lru.Cache, to the cache, keeping their order from the oldest to the newest, and returns
+// the cache. The source cache doesn't expose expiration time, so the entries are added with the cache
+// default TTL. The source cache is not modified and its recency is not updated.
+func FromHashicorpLRU[K comparable, V any](c Cache[K, V], src interface {
+	Keys() []K
+	Peek(key K) (V, bool)
+}) Cache[K, V] {
+	for _, key := range src.Keys() {
+		if value, ok := src.Peek(key); ok {
+			c.Set(key, value, DefaultTTL)
+		}
+	}
+	return c
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/stretchr/testify/assert"
+)
+
+// ttlItem mimics *ttlcache.Item from jellydator/ttlcache/v3
+type ttlItem struct {
+	key       string
+	value     int
+	expiresAt time.Time
+}
+
+func (i *ttlItem) Key() string          { return i.key }
+func (i *ttlItem) Value() int           { return i.value }
+func (i *ttlItem) ExpiresAt() time.Time { return i.expiresAt }
+
+func TestFromTTLCache(t *testing.T) {
+	now := time.Now()
+	items := map[string]*ttlItem{
+		"key1": {key: "key1", value: 1, expiresAt: now.Add(time.Hour)},
+		"key2": {key: "key2", value: 2, expiresAt: now.Add(time.Minute)},
+		"key3": {key: "key3", value: 3},
+		"key4": {key: "key4", value: 4, expiresAt: now.Add(-time.Minute)},
+	}
+	lc := FromTTLCache(NewCache[string, int]().WithTTL(time.Second), items)
+	assert.Equal(t, []string{"key2", "key1", "key3"}, lc.Keys(), "ordered by expiration, expired skipped")
+
+	exp, ok := lc.GetExpiration("key1")
+	assert.True(t, ok)
+	assert.WithinDuration(t, now.Add(time.Hour), exp, time.Second)
+	exp, ok = lc.GetExpiration("key3")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), exp, time.Second, "default TTL for no expiration")
+
+	lc = FromTTLCache(NewCache[string, int]().WithMaxKeys(1), items)
+	assert.Equal(t, []string{"key3"}, lc.Keys())
+}
+
+func TestFromHashicorpLRU(t *testing.T) {
+	src := expirable.NewLRU[string, int](10, nil, time.Hour)
+	src.Add("key1", 1)
+	src.Add("key2", 2)
+	src.Add("key3", 3)
+	src.Get("key1")
+
+	lc := FromHashicorpLRU(NewCache[string, int]().WithLRU().WithMaxKeys(2), src)
+	assert.Equal(t, []string{"key3", "key1"}, lc.Keys(), "the oldest evicted")
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, []string{"key2", "key3", "key1"}, src.Keys(), "source not modified")
+
+	tsrc, err := lru.New[string, int](10)
+	assert.NoError(t, err)
+	tsrc.Add("key1", 1)
+	lc = FromHashicorpLRU(NewCache[string, int](), tsrc)
+	assert.Equal(t, []string{"key1"}, lc.Keys())
+}
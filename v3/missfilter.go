@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// missFilter is a bloom filter of all keys ever added to the cache, checked by Get without the lock.
+// Unlike doorkeeper it's never cleared, as forgetting a key would make Get miss the cached entry.
+type missFilter struct {
+	bits     []atomic.Uint64
+	m        uint64        // number of bits
+	k        uint64        // number of hash functions
+	rejected atomic.Uint64 // Get misses counted without the lock, added to cache stats on Stat call
+}
+
+// newMissFilter makes bloom filter sized for expected keys with fpRate false positive rate
+func newMissFilter(expected int, fpRate float64) *missFilter {
+	d := newDoorkeeper(expected, fpRate) // same sizing as doorkeeper
+	return &missFilter{bits: make([]atomic.Uint64, len(d.bits)), m: d.m, k: d.k}
+}
+
+func (f *missFilter) contains(h uint64) bool {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64].Load()&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *missFilter) add(h uint64) {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		word, bit := &f.bits[pos/64], uint64(1)<<(pos%64)
+		for {
+			old := word.Load()
+			if old&bit != 0 || word.CompareAndSwap(old, old|bit) {
+				break
+			}
+		}
+	}
+}
+
+// rejectMiss returns true if the key was never added to the cache, so Get can return a miss without the lock
+func (c *cacheImpl[K, V]) rejectMiss(key K) bool {
+	if c.missFilter == nil || c.parent != nil || c.missFilter.contains(hashKey(key)) {
+		return false
+	}
+	c.missFilter.rejected.Add(1)
+	return true
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWithMissFilter(t *testing.T) {
+	lc := NewCache[string, int]().WithMissFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), i, 0)
+	}
+	for i := 0; i < 1000; i++ {
+		v, ok := lc.Get(fmt.Sprintf("key%d", i))
+		assert.True(t, ok, "no false negatives")
+		assert.Equal(t, i, v)
+	}
+
+	impl := lc.(*cacheImpl[string, int])
+	rejected := uint64(0)
+	for i := 0; i < 1000; i++ {
+		if impl.rejectMiss(fmt.Sprintf("missing%d", i)) {
+			rejected++
+		}
+	}
+	assert.Greater(t, rejected, uint64(950), "most misses rejected without the lock")
+	assert.Equal(t, Stats{Hits: 1000, Misses: rejected, Added: 1000}, lc.Stat())
+
+	lc.Invalidate("key1")
+	_, ok := lc.Get("key1")
+	assert.False(t, ok, "invalidated key is a miss")
+
+	lc = NewCache[string, int]()
+	lc.Set("key1", 1, 0)
+	lc = lc.WithMissFilter(1000, 0.01)
+	v, ok := lc.Get("key1")
+	assert.True(t, ok, "key added before the filter is set")
+	assert.Equal(t, 1, v)
+}
+
+func TestCacheWithMissFilterConcurrent(t *testing.T) {
+	lc := NewCache[int, int]().WithMissFilter(10000, 0.01)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < 4000; i += 4 {
+				lc.Set(i, i, 0)
+				v, ok := lc.Get(i)
+				assert.True(t, ok)
+				assert.Equal(t, i, v)
+				lc.Get(i + 100000)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(4000), lc.Stat().Hits)
+	assert.Equal(t, uint64(4000), lc.Stat().Misses)
+}
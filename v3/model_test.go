@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// cacheModel is a naive reference implementation of the cache with a fixed number of keys, where entries
+// either never expire or are already expired, so the behavior doesn't depend on time.
+type cacheModel struct {
+	lru     bool
+	maxKeys int
+	keys    []int // from the oldest to the newest
+	values  map[int]int
+	expired map[int]bool
+	stat    Stats
+}
+
+func newCacheModel(lru bool, maxKeys int) *cacheModel {
+	return &cacheModel{lru: lru, maxKeys: maxKeys, values: map[int]int{}, expired: map[int]bool{}}
+}
+
+func (m *cacheModel) moveToNewest(key int) {
+	m.keys = slices.DeleteFunc(m.keys, func(k int) bool { return k == key })
+	m.keys = append(m.keys, key)
+}
+
+func (m *cacheModel) remove(key int) {
+	m.keys = slices.DeleteFunc(m.keys, func(k int) bool { return k == key })
+	delete(m.values, key)
+	delete(m.expired, key)
+	m.stat.Evicted++
+}
+
+func (m *cacheModel) set(key, value int, expired bool) {
+	if _, ok := m.values[key]; ok {
+		m.moveToNewest(key)
+		m.values[key], m.expired[key] = value, expired
+		return
+	}
+	m.keys = append(m.keys, key)
+	m.values[key], m.expired[key] = value, expired
+	m.stat.Added++
+	if expired && m.expired[m.keys[0]] { // the oldest expired entry is removed on write with non-default TTL
+		m.remove(m.keys[0])
+	}
+	if m.maxKeys > 0 && len(m.keys) > m.maxKeys {
+		if !m.expired[m.keys[0]] {
+			m.stat.EvictedEarly++ // never expiring entry has all of its TTL remaining
+		}
+		m.remove(m.keys[0])
+	}
+}
+
+func (m *cacheModel) get(key int, peek bool) (int, bool) {
+	value, ok := m.values[key]
+	if !ok || m.expired[key] {
+		m.stat.Misses++
+		if peek {
+			m.stat.PeekMisses++
+		}
+		return value, false
+	}
+	m.stat.Hits++
+	if peek {
+		m.stat.PeekHits++
+	} else if m.lru {
+		m.moveToNewest(key)
+	}
+	return value, true
+}
+
+// runModel decodes operations from ops and runs them against both the cache and the model,
+// returning an error on the first difference in visible behavior
+func runModel(ops []byte, lru bool, maxKeys int) error {
+	c := NewCache[int, int]().WithMaxKeys(maxKeys)
+	if lru {
+		c = c.WithLRU()
+	}
+	m := newCacheModel(lru, maxKeys)
+	for i := 0; i+1 < len(ops); i += 2 {
+		key, value := int(ops[i+1]%16), int(ops[i+1])
+		var desc string
+		switch ops[i] % 9 {
+		case 0, 1:
+			desc = fmt.Sprintf("Set(%d, %d)", key, value)
+			c.Set(key, value, 0)
+			m.set(key, value, false)
+		case 2:
+			desc = fmt.Sprintf("Set(%d, %d, ExpireNow)", key, value)
+			c.Set(key, value, ExpireNow)
+			m.set(key, value, true)
+		case 3, 4:
+			desc = fmt.Sprintf("Get(%d)", key)
+			v, ok := c.Get(key)
+			mv, mok := m.get(key, false)
+			if ok != mok || ok && v != mv {
+				return fmt.Errorf("op %d %s: got %d, %v, expected %d, %v", i/2, desc, v, ok, mv, mok)
+			}
+		case 5:
+			desc = fmt.Sprintf("Peek(%d)", key)
+			v, ok := c.Peek(key)
+			mv, mok := m.get(key, true)
+			if ok != mok || ok && v != mv {
+				return fmt.Errorf("op %d %s: got %d, %v, expected %d, %v", i/2, desc, v, ok, mv, mok)
+			}
+		case 6:
+			desc = fmt.Sprintf("Remove(%d)", key)
+			_, mok := m.values[key]
+			if mok {
+				m.remove(key)
+			}
+			if ok := c.Remove(key); ok != mok {
+				return fmt.Errorf("op %d %s: got %v, expected %v", i/2, desc, ok, mok)
+			}
+		case 7:
+			desc = fmt.Sprintf("Expire(%d)", key)
+			_, mok := m.values[key]
+			if mok {
+				m.expired[key] = true
+			}
+			if ok := c.Expire(key, true); ok != mok {
+				return fmt.Errorf("op %d %s: got %v, expected %v", i/2, desc, ok, mok)
+			}
+		case 8:
+			desc = "DeleteExpired()"
+			c.DeleteExpired()
+			for _, k := range slices.Clone(m.keys) {
+				if m.expired[k] {
+					m.remove(k)
+				}
+			}
+		}
+		if keys := c.Keys(); !slices.Equal(keys, m.keys) && (len(keys) > 0 || len(m.keys) > 0) {
+			return fmt.Errorf("op %d %s: keys %v, expected %v", i/2, desc, keys, m.keys)
+		}
+		if st := c.Stat(); st != m.stat {
+			return fmt.Errorf("op %d %s: stats %+v, expected %+v", i/2, desc, st, m.stat)
+		}
+	}
+	return nil
+}
+
+func TestCacheModel(t *testing.T) {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic sequences are reproducible
+	for seed := 0; seed < 200; seed++ {
+		ops := make([]byte, 400)
+		r.Read(ops)
+		for _, lru := range []bool{false, true} {
+			for _, maxKeys := range []int{0, 1, 5} {
+				if err := runModel(ops, lru, maxKeys); err != nil {
+					t.Fatalf("sequence %d, lru %v, max keys %d: %v", seed, lru, maxKeys, err)
+				}
+			}
+		}
+	}
+}
+
+func FuzzCacheModel(f *testing.F) {
+	f.Add([]byte{0, 1, 3, 1, 2, 2, 8, 0, 4, 2}, false, 2)
+	f.Add([]byte{0, 1, 0, 2, 0, 3, 3, 1, 0, 4, 7, 2, 6, 3}, true, 3)
+	f.Fuzz(func(t *testing.T, ops []byte, lru bool, maxKeys int) {
+		if maxKeys < 0 || maxKeys > 16 {
+			return
+		}
+		if err := runModel(ops, lru, maxKeys); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
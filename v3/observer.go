@@ -0,0 +1,39 @@
+package cache
+
+import "time"
+
+// Op is a cache operation reported to the observer
+type Op int
+
+// Operations reported to the observer
+const (
+	OpGet Op = iota
+	OpPeek
+	OpSet
+	OpDeleteExpired
+	OpOther // any other operation, reported by LockProfile only
+)
+
+// String returns operation name
+func (o Op) String() string {
+	switch o {
+	case OpGet:
+		return "get"
+	case OpPeek:
+		return "peek"
+	case OpSet:
+		return "set"
+	case OpDeleteExpired:
+		return "delete_expired"
+	case OpOther:
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+
+// observe reports duration of the operation started at start to the observer.
+// Should be deferred before the lock is taken, so the lock wait is included in the duration.
+func (c *cacheImpl[K, V]) observe(op Op, start time.Time) {
+	c.observer(op, time.Since(start))
+}
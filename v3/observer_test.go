@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWithObserver(t *testing.T) {
+	var mu sync.Mutex
+	ops := map[Op]int{}
+	lc := NewCache[string, string]().WithObserver(func(op Op, d time.Duration) {
+		assert.Greater(t, d, time.Duration(0))
+		mu.Lock()
+		ops[op]++
+		mu.Unlock()
+	})
+
+	lc.Set("key1", "val1", 0)
+	lc.Add("key2", "val2")
+	lc.Swap("key2", "val3", 0)
+	lc.Get("key1")
+	_, _ = lc.GetE("key1")
+	lc.Peek("key1")
+	lc.DeleteExpired()
+	lc.Keys()
+	assert.Equal(t, map[Op]int{OpSet: 3, OpGet: 2, OpPeek: 1, OpDeleteExpired: 1}, ops)
+}
+
+func TestOp_String(t *testing.T) {
+	assert.Equal(t, "get", OpGet.String())
+	assert.Equal(t, "peek", OpPeek.String())
+	assert.Equal(t, "set", OpSet.String())
+	assert.Equal(t, "delete_expired", OpDeleteExpired.String())
+	assert.Equal(t, "unknown", Op(42).String())
+}
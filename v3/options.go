@@ -1,36 +1,595 @@
 package cache
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+)
 
 type options[K comparable, V any] interface {
+	WithName(name string) Cache[K, V]
+	WithStringStats(include bool) Cache[K, V]
+	WithLabels(labels map[string]string) Cache[K, V]
 	WithTTL(ttl time.Duration) Cache[K, V]
+	WithZeroTTL(ttl time.Duration) Cache[K, V]
+	WithTTLJitter(jitter float64) Cache[K, V]
+	WithRand(src rand.Source) Cache[K, V]
 	WithMaxKeys(maxKeys int) Cache[K, V]
+	WithMaxCost(maxCost int64, costFn func(key K, value V) int64) Cache[K, V]
+	WithMaxEvictionsPerOp(n int) Cache[K, V]
+	WithOverwriteEviction(mode OverwriteEviction) Cache[K, V]
+	WithMaxEntryCost(maxEntryCost int64) Cache[K, V]
 	WithLRU() Cache[K, V]
+	WithClock() Cache[K, V]
+	WithLRUK(k int) Cache[K, V]
+	WithGhostCache() Cache[K, V]
+	WithAdaptiveTTL(minTTL, maxTTL time.Duration, apply bool) Cache[K, V]
+	WithIndex(name string, fn func(value V) string) Cache[K, V]
+	WithReverseLookup(hash func(value V) any) Cache[K, V]
+	WithKeyOrder(compare func(a, b K) int) Cache[K, V]
+	WithLRUPromotionBuffer(size int) Cache[K, V]
+	WithLockFreeReads() Cache[K, V]
+	WithPeekStatsSeparated() Cache[K, V]
+	WithEarlyEvictionThreshold(remaining float64) Cache[K, V]
 	WithOnEvicted(fn func(key K, value V)) Cache[K, V]
+	WithAsyncOnEvicted(workers int) Cache[K, V]
+	WithTombstones(window time.Duration) Cache[K, V]
+	WithStatsRateWindow(window time.Duration) Cache[K, V]
+	WithStatsResetOnPurge() Cache[K, V]
+	WithBurstExtension(misses int, extension, limit time.Duration) Cache[K, V]
+	WithValidator(fn func(key K, value V) bool) Cache[K, V]
+	WithLogger(logger *slog.Logger) Cache[K, V]
+	WithObserver(fn func(op Op, d time.Duration)) Cache[K, V]
+	WithLockProfiling() Cache[K, V]
+	WithStatsSink(fn func(s Stats), interval time.Duration, ops int) Cache[K, V]
+	WithPanicHandler(fn func(key K, value V, recovered any)) Cache[K, V]
+	WithCopyOnGet(fn func(value V) V) Cache[K, V]
+	WithNormalizeValue(fn func(value V) V) Cache[K, V]
+	WithSnapshotEncryption(key []byte) Cache[K, V]
+	WithChecksum(sum Checksum) Cache[K, V]
+	WithKeyCodec(encode func(key K) ([]byte, error), decode func(data []byte) (K, error)) Cache[K, V]
+	WithNamespace(fn func(key K) string) Cache[K, V]
+	WithDoorkeeper(expectedInserts int, fpRate float64) Cache[K, V]
+	WithMissFilter(expected int, fpRate float64) Cache[K, V]
+	WithFrequencySketch(expectedKeys int) Cache[K, V]
+	WithAdmission(fn func(key K, value V, cost int64) bool) Cache[K, V]
+	WithOnDemote(fn func(key K, value V, expiresAt time.Time)) Cache[K, V]
+	WithOnReplaced(fn func(key K, old, value V)) Cache[K, V]
+	WithLoader(fn func(ctx context.Context, key K) (V, error)) Cache[K, V]
+	WithResultLoader(fn func(ctx context.Context, key K) (LoadResult[V], error)) Cache[K, V]
+	WithLoaderLimiter(limiter Limiter) Cache[K, V]
+	WithMissCost(fn func(key K) time.Duration) Cache[K, V]
+	WithRefreshAhead(window time.Duration) Cache[K, V]
+	WithRefreshConcurrency(n int) Cache[K, V]
+	WithRefreshQueue(size int) Cache[K, V]
+	WithWriteCoalescing(window time.Duration) Cache[K, V]
+	WithWriteThrough(store Backend[K, V]) Cache[K, V]
+	WithWriteBehind(store Backend[K, V], flushInterval time.Duration, batchSize int) Cache[K, V]
+}
+
+// WithName sets the cache name, used in String, DebugString and log output to tell caches apart.
+func (c *cacheImpl[K, V]) WithName(name string) Cache[K, V] {
+	c.name = name
+	return c
+}
+
+// WithLabels sets the cache labels, e.g. service or tier, added to log output and returned by Labels
+// to be attached to exported metrics, so multiple caches in one process are distinguishable.
+func (c *cacheImpl[K, V]) WithLabels(labels map[string]string) Cache[K, V] {
+	c.labels = make(map[string]string, len(labels))
+	for k, v := range labels {
+		c.labels[k] = v
+	}
+	return c
+}
+
+// WithStringStats defines if String output includes stats, it does by default.
+func (c *cacheImpl[K, V]) WithStringStats(include bool) Cache[K, V] {
+	c.noStringStats = !include
+	return c
 }
 
 // WithTTL functional option defines TTL for all cache entries.
 // By default, it is set to 10 years, sane option for expirable cache might be 5 minutes.
 func (c *cacheImpl[K, V]) WithTTL(ttl time.Duration) Cache[K, V] {
+	if ttl <= 0 {
+		c.logDebug("non-positive ttl set, all entries will expire immediately", slog.Duration("ttl", ttl))
+	}
 	c.ttl = ttl
 	return c
 }
 
+// WithZeroTTL sets what ttl of 0 passed to Set, Swap, SetWithDeps and Txn means: DefaultTTL (the default)
+// for cache-wide TTL, NoTTL for no expiration, or ExpireNow for immediate expiration.
+// Panics on any other value, as silently picking one of them is what makes 0 confusing in the first place.
+func (c *cacheImpl[K, V]) WithZeroTTL(ttl time.Duration) Cache[K, V] {
+	switch ttl {
+	case DefaultTTL:
+		c.zeroTTL = 0
+	case NoTTL, ExpireNow:
+		c.zeroTTL = ttl
+	default:
+		panic(fmt.Sprintf("cache: WithZeroTTL requires DefaultTTL, NoTTL or ExpireNow, got %v", ttl))
+	}
+	return c
+}
+
+// WithTTLJitter sets part of TTL (0..1) randomly added to or subtracted from TTL of each written entry,
+// so entries written together don't expire all at once. Entries without expiration are not affected.
+// By default, it is 0, which means no jitter.
+func (c *cacheImpl[K, V]) WithTTLJitter(jitter float64) Cache[K, V] {
+	c.jitter = jitter
+	return c
+}
+
+// WithRand sets random source used for the jitter set by WithTTLJitter, e.g. seeded one so TTLs are
+// deterministic in tests, or cachex.CryptoSource in case expiration time of the entries shouldn't be predictable.
+// The source is used with the cache lock held, so it has to be safe for concurrent use if shared by caches.
+// By default, the source is seeded with the current time.
+func (c *cacheImpl[K, V]) WithRand(src rand.Source) Cache[K, V] {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.rand = rand.New(src) //nolint:gosec // source is chosen by the caller
+	return c
+}
+
 // WithMaxKeys functional option defines how many keys to keep.
 // By default, it is 0, which means unlimited.
 func (c *cacheImpl[K, V]) WithMaxKeys(maxKeys int) Cache[K, V] {
+	if maxKeys < 0 {
+		c.logDebug("negative max keys set, cache size is unlimited", slog.Int("max_keys", maxKeys))
+	}
 	c.maxKeys = maxKeys
 	return c
 }
 
+// WithMaxCost functional option defines maximum total cost of the entries, e.g. size in bytes,
+// with costFn calculating cost of each entry, cost of 1 is used in case costFn is nil.
+// The oldest entries are evicted once the total cost exceeds maxCost, in addition to MaxKeys limit.
+// By default, it is 0, which means unlimited.
+func (c *cacheImpl[K, V]) WithMaxCost(maxCost int64, costFn func(key K, value V) int64) Cache[K, V] {
+	c.maxCost = maxCost
+	c.costFn = costFn
+	return c
+}
+
+// WithMaxEvictionsPerOp limits number of entries a single Set, Add or UpdateCost evicts, with OnEvicted
+// called for each of them, to fit the cost limit set by WithMaxCost. Without the limit a write of a large
+// entry may evict many small ones while holding the lock. The cache stays over the limit until next writes
+// or Maintain evict the rest. Values below 1 remove the limit.
+func (c *cacheImpl[K, V]) WithMaxEvictionsPerOp(n int) Cache[K, V] {
+	c.maxEvictionsPerOp = n
+	return c
+}
+
+// WithMaxEntryCost limits cost of a single entry, calculated by the cost function set by WithMaxCost,
+// so a single enormous value can't evict the entire working set. Writes of costlier entries are rejected
+// and counted in RejectedTooLarge stats, with the existing entry of the key removed, as its value is outdated.
+// By default, it is 0, which means unlimited.
+func (c *cacheImpl[K, V]) WithMaxEntryCost(maxEntryCost int64) Cache[K, V] {
+	c.maxEntryCost = maxEntryCost
+	return c
+}
+
+// WithOverwriteEviction sets which entries are evicted when Set, Add or Swap overwrites an entry with a costlier
+// value and the total cost exceeds the limit set by WithMaxCost. By default, it's OverwriteEvictOldest, evicting
+// the oldest entries the same way as for new entries. OverwriteEvictSelf evicts the overwritten entry instead,
+// so a value which grew too large doesn't push out the rest of the cache; pinned entries are never evicted
+// this way. Entries evicted in both modes are counted in EvictedOnOverwrite stats.
+func (c *cacheImpl[K, V]) WithOverwriteEviction(mode OverwriteEviction) Cache[K, V] {
+	c.overwriteEviction = mode
+	return c
+}
+
 // WithLRU sets cache to LRU (Least Recently Used) eviction mode.
 func (c *cacheImpl[K, V]) WithLRU() Cache[K, V] {
 	c.isLRU = true
 	return c
 }
 
+// WithClock sets cache to CLOCK (second chance) eviction mode, approximating LRU without reordering entries on Get.
+// Get only marks the entry as referenced, and eviction moves referenced entries to the front instead of evicting
+// them, clearing the mark, so Get doesn't write to the eviction list. Takes precedence over WithLRU.
+func (c *cacheImpl[K, V]) WithClock() Cache[K, V] {
+	c.isClock = true
+	return c
+}
+
+// WithLRUK sets cache to LRU-K eviction mode, evicting the entry with the oldest k-th most recent access,
+// where both Get and write count as access. Entries accessed less than k times are evicted first, in LRU order,
+// so entries accessed once by a scan don't push out the ones accessed repeatedly. Eviction order reported
+// by Keys and GetOldest stays the order of writes. Takes precedence over WithLRU and WithClock.
+func (c *cacheImpl[K, V]) WithLRUK(k int) Cache[K, V] {
+	if k < 1 {
+		c.logDebug("LRU-K requires k >= 1, ignored", slog.Int("k", k))
+		return c
+	}
+	c.lruK = lruK[K]{k: k, entries: map[K]*lruKEntry[K]{}}
+	return c
+}
+
+// WithGhostCache enables tracking of up to MaxKeys keys evicted to maintain the cache size, without values,
+// to estimate how many misses would be hits with a larger cache, reported by WouldHaveHit.
+// Estimates are exact for LRU mode and approximate for other eviction modes.
+func (c *cacheImpl[K, V]) WithGhostCache() Cache[K, V] {
+	c.ghost = newGhostCache[K]()
+	return c
+}
+
+// WithAdaptiveTTL enables tracking of reuse intervals, i.e. time between Get calls, of each entry to suggest
+// its TTL within minTTL and maxTTL bounds, reported by SuggestedTTL. Entries reused often within the default TTL
+// set by WithTTL get longer TTL, and entries reused rarely get shorter one. In case apply is true, suggested TTL
+// is used instead of the default one when the existing entry is written with default TTL, e.g. re-fetched.
+func (c *cacheImpl[K, V]) WithAdaptiveTTL(minTTL, maxTTL time.Duration, apply bool) Cache[K, V] {
+	c.adaptive = adaptiveTTL{enabled: true, min: minTTL, max: maxTTL, apply: apply}
+	return c
+}
+
+// WithIndex adds an index of entries by the value attribute returned by fn, e.g. tenant ID, so KeysByIndex
+// and InvalidateByIndex find entries with the same attribute without scanning all entries.
+// It has to be set before entries are added, and fn is called on each write and removal of an entry.
+func (c *cacheImpl[K, V]) WithIndex(name string, fn func(value V) string) Cache[K, V] {
+	if c.indexes == nil {
+		c.indexes = map[string]*valueIndex[K, V]{}
+	}
+	c.indexes[name] = &valueIndex[K, V]{fn: func(value V) any { return fn(value) }, keys: map[any]map[K]struct{}{}, attrs: map[K]any{}}
+	return c
+}
+
+// WithReverseLookup adds an index of entries by their value, so FindKeys finds all keys with the same value,
+// e.g. aliases of the same object. In case hash is nil, the value itself is used and V has to be comparable,
+// otherwise the cache panics on write, the same way map does. For other value types, hash has to return a
+// comparable identity of the value, e.g. its ID. It has to be set before entries are added.
+func (c *cacheImpl[K, V]) WithReverseLookup(hash func(value V) any) Cache[K, V] {
+	if hash == nil {
+		hash = func(value V) any { return value }
+	}
+	c.reverse = &valueIndex[K, V]{fn: hash, keys: map[any]map[K]struct{}{}, attrs: map[K]any{}}
+	return c
+}
+
+// WithKeyOrder adds an index of keys sorted with compare function, e.g. cmp.Compare for ordered key types,
+// so SortedKeys and KeysBetween serve sorted and range lookups, e.g. of time-bucketed keys, without scanning
+// all keys. Adding and removing a key costs O(n) in the worst case, as the index is a sorted slice.
+// It has to be set before entries are added.
+func (c *cacheImpl[K, V]) WithKeyOrder(compare func(a, b K) int) Cache[K, V] {
+	c.ordered = &orderedIndex[K]{compare: compare}
+	return c
+}
+
+// WithLRUPromotionBuffer defers moving entries to the front of the eviction list on Get in LRU mode,
+// recording up to size accessed entries and applying them in a batch, once the buffer is full, on write or before
+// the eviction order is used, e.g. by Keys or GetOldest. Each entry is recorded once per batch, repeated reads
+// of a hot entry only update its access sequence, so they don't fill the buffer. Reads don't reorder the list
+// one by one, while the eviction order stays the same as without the buffer.
+func (c *cacheImpl[K, V]) WithLRUPromotionBuffer(size int) Cache[K, V] {
+	c.promoteSize = size
+	return c
+}
+
+// WithLockFreeReads makes Peek and Contains read an immutable copy of the entries published after writes,
+// so they never take the lock and don't wait for writers or each other. A write publishes a new copy with
+// the changed entry kept apart from the entries copied before, and after about square root of the cache size
+// such writes, or a change of all the entries like Purge, the first read makes a new full copy under the lock.
+// It fits read-mostly caches, where many reads follow each write. Lock-free Peek is not counted in namespace stats and frequency sketch.
+// Peek of a child cache made by NewChild takes the lock anyway, to read through to the parent on local miss.
+func (c *cacheImpl[K, V]) WithLockFreeReads() Cache[K, V] {
+	c.lockFree.enabled = true
+	return c
+}
+
+// WithPeekStatsSeparated excludes Peek calls from Hits and Misses stats, so they are counted in
+// PeekHits and PeekMisses only. By default, Peek calls are counted in both.
+func (c *cacheImpl[K, V]) WithPeekStatsSeparated() Cache[K, V] {
+	c.peekApart = true
+	return c
+}
+
+// WithEarlyEvictionThreshold defines part of TTL (from 0 to 1) which should remain for entry
+// evicted to maintain the cache size to be counted in EvictedEarly stats. By default, it is 0.5.
+func (c *cacheImpl[K, V]) WithEarlyEvictionThreshold(remaining float64) Cache[K, V] {
+	c.earlyRate = remaining
+	return c
+}
+
 // WithOnEvicted defined function which would be called automatically for automatically and manually deleted entries
 func (c *cacheImpl[K, V]) WithOnEvicted(fn func(key K, value V)) Cache[K, V] {
 	c.onEvicted = fn
 	return c
 }
+
+// WithAsyncOnEvicted makes OnEvicted calls in background by the given number of worker goroutines, started
+// on the first eviction, instead of synchronously with the cache lock held, so slow callbacks don't block the cache
+// and may call it. Keys are spread between workers by hash, so calls for the same key are made in eviction order
+// by the same worker, while calls for different keys run in parallel. Calls are queued without a limit, so
+// evictions never wait for callbacks nor are dropped. Workers run until Close, which has to be called to stop
+// them once they make queued calls, and OnEvicted calls after Close are made synchronously. OnEvicted must
+// not call Close, as Close waits for the workers.
+func (c *cacheImpl[K, V]) WithAsyncOnEvicted(workers int) Cache[K, V] {
+	c.evictPool.size = workers
+	return c
+}
+
+// WithTombstones enables tombstones of invalidated keys: Invalidate, InvalidateMany, InvalidateOlderThan, Remove,
+// InvalidateFn and InvalidateByIndex record a tombstone kept for window, and writes of the key are rejected until
+// it expires.
+// It prevents resurrection of deleted keys by late writes, e.g. from async loads or replication started before the delete.
+// Expired tombstones are deleted by DeleteExpired and on the next write of the key, Purge deletes all of them.
+func (c *cacheImpl[K, V]) WithTombstones(window time.Duration) Cache[K, V] {
+	c.tombstones.window = window
+	return c
+}
+
+// WithLogger sets logger for cache events: evictions, expired entries deletion, resizes and options misuse.
+// All messages are logged at debug level. Set it first in the options chain to have other options checked.
+func (c *cacheImpl[K, V]) WithLogger(logger *slog.Logger) Cache[K, V] {
+	c.logger = logger
+	return c
+}
+
+// WithPanicHandler sets function which would be called with the recovered value in case eviction callback panics.
+// Panics in eviction callback are always recovered, with or without the handler, and logged if logger is set.
+func (c *cacheImpl[K, V]) WithPanicHandler(fn func(key K, value V, recovered any)) Cache[K, V] {
+	c.onPanic = fn
+	return c
+}
+
+// WithCopyOnGet sets function used to copy values returned by Get, Peek, GetOldest and Values,
+// so callers can't modify the cached entry through the returned pointer-typed (or otherwise shared) value.
+func (c *cacheImpl[K, V]) WithCopyOnGet(fn func(value V) V) Cache[K, V] {
+	c.copyOnGet = fn
+	return c
+}
+
+// WithNormalizeValue sets function applied to values before they are stored by Set, Add, Swap, loaders and other
+// writes, e.g. trimming strings, interning them or copying slices to release their larger backing arrays.
+// The cost set by WithMaxCost is calculated for the normalized value, and it's what Get returns, while loader
+// callers waiting for the load get the loaded value as is.
+// It's called with the lock held, so it must be fast and not call the cache.
+func (c *cacheImpl[K, V]) WithNormalizeValue(fn func(value V) V) Cache[K, V] {
+	c.normalize = fn
+	return c
+}
+
+// WithSnapshotEncryption sets AES key (16, 24 or 32 bytes long) used to encrypt snapshots with AES-GCM
+// in WriteSnapshot and decrypt them in ReadSnapshot. Only the serialized stream is encrypted,
+// in-memory entries are kept as is. Invalid key length is reported by snapshot methods.
+func (c *cacheImpl[K, V]) WithSnapshotEncryption(key []byte) Cache[K, V] {
+	c.snapshotKey = key
+	return c
+}
+
+// WithChecksum sets checksum of entries in WriteSnapshot, validated by ReadSnapshot: corrupted entries
+// are skipped, i.e. treated as misses rather than served, and logged at error level. Snapshot written
+// with checksum has to be read by the cache with the same checksum.
+func (c *cacheImpl[K, V]) WithChecksum(sum Checksum) Cache[K, V] {
+	c.checksum = sum
+	return c
+}
+
+// WithKeyCodec sets functions used to serialize keys in WriteSnapshot and deserialize them in ReadSnapshot,
+// for keys which can't be gob-encoded, e.g. structs with unexported fields. Snapshot written with
+// key codec has to be read by the cache with the same codec.
+func (c *cacheImpl[K, V]) WithKeyCodec(encode func(key K) ([]byte, error), decode func(data []byte) (K, error)) Cache[K, V] {
+	c.keyEncode, c.keyDecode = encode, decode
+	return c
+}
+
+// WithNamespace sets function which defines namespace (e.g. tenant) of the key.
+// Stats are collected for each namespace separately and available with StatsByNamespace,
+// in addition to the cache-wide Stat.
+func (c *cacheImpl[K, V]) WithNamespace(fn func(key K) string) Cache[K, V] {
+	c.namespaceFn = fn
+	c.nsStat = map[string]*Stats{}
+	return c
+}
+
+// WithDoorkeeper enables bloom filter sized for expectedInserts keys with fpRate false positive rate.
+// When the cache is full, a new key is admitted only on the second attempt to add it, which protects
+// the working set from keys requested only once (crawlers, scans). Has no effect on unlimited cache.
+func (c *cacheImpl[K, V]) WithDoorkeeper(expectedInserts int, fpRate float64) Cache[K, V] {
+	c.doorkeeper = newDoorkeeper(expectedInserts, fpRate)
+	return c
+}
+
+// WithMissFilter enables bloom filter of all keys ever added, sized for expected keys with fpRate false
+// positive rate, so Get of a key never cached returns a miss without taking the lock. Useful when misses
+// dominate, e.g. cache in front of a sparse dataset. The filter is never cleared, false positive rate grows
+// once more than expected distinct keys are added. Keys already in the cache are added to the filter when
+// it's set. Misses rejected by the filter are counted in Misses only: they are not counted per namespace,
+// in the ghost cache set by WithGhostCache, nor in the frequency sketch set by WithFrequencySketch.
+func (c *cacheImpl[K, V]) WithMissFilter(expected int, fpRate float64) Cache[K, V] {
+	c.missFilter = newMissFilter(expected, fpRate)
+	for key := range c.items {
+		c.missFilter.add(hashKey(key))
+	}
+	return c
+}
+
+// WithFrequencySketch enables count-min sketch sized for expectedKeys distinct keys,
+// which tracks key access frequency and makes it available with EstimateFrequency.
+func (c *cacheImpl[K, V]) WithFrequencySketch(expectedKeys int) Cache[K, V] {
+	c.sketch = newCountMinSketch(expectedKeys)
+	return c
+}
+
+// WithAdmission sets function consulted before adding a new entry to the full cache.
+// In case it returns false, the entry is not added and nothing is evicted.
+// Cost is calculated by the function set with WithMaxCost, 1 by default.
+func (c *cacheImpl[K, V]) WithAdmission(fn func(key K, value V, cost int64) bool) Cache[K, V] {
+	c.admission = fn
+	return c
+}
+
+// WithOnDemote sets function which would be called for not expired entries evicted to maintain the cache size,
+// on Add, Set or Resize. Unlike OnEvicted, it's not called for expired, invalidated, removed or purged entries,
+// which makes it suitable for pushing still valid entries to a larger and slower cache tier.
+func (c *cacheImpl[K, V]) WithOnDemote(fn func(key K, value V, expiresAt time.Time)) Cache[K, V] {
+	c.onDemote = fn
+	return c
+}
+
+// WithLoader sets function used by GetCtx to load values missing in the cache.
+// Loaded values are added to the cache with cache-wide TTL, errors are not cached.
+func (c *cacheImpl[K, V]) WithLoader(fn func(ctx context.Context, key K) (V, error)) Cache[K, V] {
+	if fn == nil {
+		c.loader = nil
+		return c
+	}
+	c.loader = func(ctx context.Context, key K) (LoadResult[V], error) {
+		value, err := fn(ctx, key)
+		return LoadResult[V]{Value: value}, err
+	}
+	return c
+}
+
+// WithResultLoader sets loader the same way WithLoader does, with loader controlling how the loaded value
+// is cached, see LoadResult. It allows to propagate freshness provided by the origin, e.g. HTTP max-age,
+// and to skip caching of specific responses.
+func (c *cacheImpl[K, V]) WithResultLoader(fn func(ctx context.Context, key K) (LoadResult[V], error)) Cache[K, V] {
+	c.loader = fn
+	return c
+}
+
+// WithLoaderLimiter sets limiter for loader calls, so cache misses can't cause unbounded load on the backend,
+// e.g. on a cold start or mass expiration. Loads waiting for the limiter are canceled the same way as loader calls.
+func (c *cacheImpl[K, V]) WithLoaderLimiter(limiter Limiter) Cache[K, V] {
+	c.loaderLimiter = limiter
+	return c
+}
+
+// WithMissCost sets function returning estimated cost of Get miss of the key, e.g. the time to fetch it
+// from the database. Costs are summed in Stats.MissCost, which makes Stats.TimeSaved possible to estimate.
+// Without it, durations of loader calls made by GetCtx and GetAsync are counted as miss costs instead.
+func (c *cacheImpl[K, V]) WithMissCost(fn func(key K) time.Duration) Cache[K, V] {
+	c.missCost = fn
+	return c
+}
+
+// WithRefreshAhead enables reload of the entries with less than window of TTL remaining, so hot entries
+// are refreshed before they expire. Get returns cached value right away, while the loader set by WithLoader
+// is called in background. By default, each refresh runs in a separate goroutine, see WithRefreshConcurrency.
+func (c *cacheImpl[K, V]) WithRefreshAhead(window time.Duration) Cache[K, V] {
+	c.refreshAhead = window
+	return c
+}
+
+// WithBurstExtension protects just expired entries from dogpiling: once misses Get calls miss the expired
+// entry within extension after its expiration, the entry is extended by extension and refreshed in background
+// by the loader set by WithLoader, the same way WithRefreshAhead does. Get returns the stale value while
+// a single refresh runs, instead of every caller waiting for the load. Extensions are limited to limit in total
+// until the entry is written again, so the entry expires for good if refresh keeps failing.
+func (c *cacheImpl[K, V]) WithBurstExtension(misses int, extension, limit time.Duration) Cache[K, V] {
+	c.burst = burstExtension{misses: misses, extension: extension, limit: limit}
+	return c
+}
+
+// WithValidator sets function checking the entry on Get, e.g. for schema version stored inside the value.
+// Entry failing the check is evicted, with OnEvicted called, and Get treats it as a miss, so GetCtx loads
+// it again with the loader. Entry pinned by Range callback is not evicted until the callback returns, but Get
+// still treats it as a miss. Validator is called with the lock held, so it must be fast and not call the cache.
+func (c *cacheImpl[K, V]) WithValidator(fn func(key K, value V) bool) Cache[K, V] {
+	c.validator = fn
+	return c
+}
+
+// WithRefreshConcurrency limits background refreshes to n worker goroutines, so refreshes can't spawn
+// unbounded goroutines during an expiration storm. Refreshes wait for a free worker in a queue of size
+// set by WithRefreshQueue, n by default, and are dropped once the queue is full. Dropped entries are
+// loaded by GetCtx after they expire. By default, it is 0, which means unlimited.
+func (c *cacheImpl[K, V]) WithRefreshConcurrency(n int) Cache[K, V] {
+	c.refreshWorkers = n
+	return c
+}
+
+// WithRefreshQueue sets size of the queue of background refreshes waiting for a free worker,
+// used with WithRefreshConcurrency only.
+func (c *cacheImpl[K, V]) WithRefreshQueue(size int) Cache[K, V] {
+	c.refreshQueueSize = size
+	return c
+}
+
+// WithWriteCoalescing sets window for coalescing writes of the same key. Set of a key within the window
+// after its last write updates the entry in place without firing write hooks, which reduces churn for
+// rapidly updated keys. With backing store set, the latest value of coalesced writes is written to it once
+// the window closes.
+func (c *cacheImpl[K, V]) WithWriteCoalescing(window time.Duration) Cache[K, V] {
+	c.coalesceWindow = window
+	return c
+}
+
+// WithWriteThrough sets backing store every Set and Add is written to synchronously, before the call returns.
+// The store is called after the cache lock is released, so a slow store doesn't block other callers.
+// Values loaded by the loader or read from a snapshot are not written, while writes coalesced with
+// WithWriteCoalescing are written once the window closes. Store errors are logged at error level.
+// The store is not kept in sync on deletes: keys removed by Remove, Invalidate, Purge, expiration or eviction
+// are not deleted from it, so the application has to delete them from the store itself if needed.
+func (c *cacheImpl[K, V]) WithWriteThrough(store Backend[K, V]) Cache[K, V] {
+	c.store = store
+	c.writeBehind = writeBehind[K, V]{index: map[K]int{}}
+	return c
+}
+
+// WithWriteBehind sets backing store Sets and Adds are written to in background batches. Batch is flushed
+// by a timer goroutine once it has batchSize entries, or flushInterval after the previous flush.
+// Only the latest value of the key is written in case it's updated before the flush.
+// Flush or Close should be called before the application exit to write the remaining entries.
+// Store errors are logged at error level, entries failed to be written are not retried.
+// The store is not kept in sync on deletes, the same way as in WithWriteThrough mode.
+func (c *cacheImpl[K, V]) WithWriteBehind(store Backend[K, V], flushInterval time.Duration, batchSize int) Cache[K, V] {
+	c.store = store
+	c.writeBehind = writeBehind[K, V]{enabled: true, interval: flushInterval, batchSize: batchSize,
+		index: map[K]int{}, lastFlush: time.Now()}
+	return c
+}
+
+// WithObserver sets function called with duration of Get, Peek, Set, Add and DeleteExpired calls,
+// including the time spent waiting for the lock, e.g. to feed latency histograms.
+// It's called synchronously, so it should be fast.
+func (c *cacheImpl[K, V]) WithObserver(fn func(op Op, d time.Duration)) Cache[K, V] {
+	c.observer = fn
+	return c
+}
+
+// WithLockProfiling enables collection of lock wait and hold times of Get, Peek, Set and DeleteExpired calls,
+// and of all the other calls taking the lock together, returned by LockProfile, to tell lock contention
+// from time spent inside the cache. It adds two time.Now calls to each operation, so it's meant
+// for diagnostics rather than to be always on.
+func (c *cacheImpl[K, V]) WithLockProfiling() Cache[K, V] {
+	c.lockProf.enabled = true
+	return c
+}
+
+// WithStatsSink sets function called with the cache stats once interval passed or ops stats updates
+// are made since the last call, whichever comes first, zero interval or ops disables the trigger.
+// It's called from the cache operation which updated stats, once the operation releases the cache lock,
+// so it may call the cache, and its panic is recovered and logged. Stats are copied under the lock when
+// the push is due, and pushes from concurrent operations may come out of order. No background goroutine
+// is involved, idle cache doesn't push stats.
+func (c *cacheImpl[K, V]) WithStatsSink(fn func(s Stats), interval time.Duration, ops int) Cache[K, V] {
+	c.sink = statsSink{fn: fn, interval: interval, ops: ops}
+	return c
+}
+
+// WithStatsRateWindow sets sliding window StatsRate computes rates over. Stats are sampled
+// on updates, so it adds a clock read to every stats update. By default, it is 0, which disables StatsRate.
+func (c *cacheImpl[K, V]) WithStatsRateWindow(window time.Duration) Cache[K, V] {
+	c.rates = statsWindow{window: window}
+	return c
+}
+
+// WithStatsResetOnPurge makes Purge reset the cache-wide and namespace stats, so stats describe the cache
+// since the last purge. By default, stats are kept by Purge, with purged entries counted in Evicted.
+func (c *cacheImpl[K, V]) WithStatsResetOnPurge() Cache[K, V] {
+	c.resetStatsOnPurge = true
+	return c
+}
+
+// WithOnReplaced sets function which would be called when Set, Add or Swap overwrites not expired entry,
+// with the old and the new value. It's called for writes coalesced with WithWriteCoalescing as well.
+func (c *cacheImpl[K, V]) WithOnReplaced(fn func(key K, old, value V)) Cache[K, V] {
+	c.onReplaced = fn
+	return c
+}
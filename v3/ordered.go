@@ -0,0 +1,49 @@
+package cache
+
+import "slices"
+
+// orderedIndex keeps the cache keys sorted, set by WithKeyOrder
+type orderedIndex[K comparable] struct {
+	compare func(a, b K) int
+	keys    []K
+}
+
+func (o *orderedIndex[K]) add(key K) {
+	if i, found := slices.BinarySearchFunc(o.keys, key, o.compare); !found {
+		o.keys = slices.Insert(o.keys, i, key)
+	}
+}
+
+func (o *orderedIndex[K]) remove(key K) {
+	if i, found := slices.BinarySearchFunc(o.keys, key, o.compare); found {
+		o.keys = slices.Delete(o.keys, i, i+1)
+	}
+}
+
+// SortedKeys returns all keys in the cache, including expired ones the same way Keys does,
+// sorted in order set by WithKeyOrder. Returns nil if key order is not set.
+func (c *cacheImpl[K, V]) SortedKeys() []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.ordered == nil {
+		return nil
+	}
+	return slices.Clone(c.ordered.keys)
+}
+
+// KeysBetween returns sorted keys in the cache from lo, inclusive, to hi, exclusive, in order set by WithKeyOrder,
+// finding them without scanning all keys. It includes expired keys the same way Keys does.
+// Returns nil if key order is not set.
+func (c *cacheImpl[K, V]) KeysBetween(lo, hi K) []K {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.ordered == nil {
+		return nil
+	}
+	from, _ := slices.BinarySearchFunc(c.ordered.keys, lo, c.ordered.compare)
+	to, _ := slices.BinarySearchFunc(c.ordered.keys, hi, c.ordered.compare)
+	if to < from {
+		return []K{}
+	}
+	return slices.Clone(c.ordered.keys[from:to])
+}
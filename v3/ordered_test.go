@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_KeyOrder(t *testing.T) {
+	lc := NewCache[string, int]().WithKeyOrder(cmp.Compare[string]).WithMaxKeys(5)
+	for _, key := range []string{"2024-03", "2024-01", "2023-12", "2024-02", "2024-01"} {
+		lc.Set(key, 1, 0)
+	}
+	assert.Equal(t, []string{"2023-12", "2024-01", "2024-02", "2024-03"}, lc.SortedKeys())
+	assert.Equal(t, []string{"2024-01", "2024-02"}, lc.KeysBetween("2024-01", "2024-03"))
+	assert.Equal(t, []string{"2024-01", "2024-02", "2024-03"}, lc.KeysBetween("2024", "2025"), "prefix lookup")
+	assert.Empty(t, lc.KeysBetween("2025", "2024"))
+
+	lc.Set("2024-04", 1, 0)
+	lc.Set("2024-05", 1, 0)
+	assert.Equal(t, []string{"2023-12", "2024-01", "2024-02", "2024-04", "2024-05"}, lc.SortedKeys(), "the oldest 2024-03 evicted")
+	lc.Remove("2024-01")
+	assert.Equal(t, []string{"2024-02", "2024-04"}, lc.KeysBetween("2024-01", "2024-05"))
+
+	lc.Purge()
+	assert.Empty(t, lc.SortedKeys())
+	assert.Nil(t, NewCache[int, int]().SortedKeys())
+	assert.Nil(t, NewCache[int, int]().KeysBetween(1, 2))
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// peerHopHeader marks requests made by Peers loader. The peer serving such request loads missing values
+// with origin even if it's not the owner of the key by its own list of peers, so misconfigured instances
+// don't forward requests to each other in a loop.
+const peerHopHeader = "X-Cache-Peer-Hop"
+
+// peerHopKey is a context key marking loads made for requests of other peers
+type peerHopKey struct{}
+
+// Peers fills cache misses from peer instances, groupcache-style. Each key is owned by a single peer
+// chosen with consistent hashing, and only the owner loads the key from the origin, while other peers
+// request it from the owner. As GetCtx shares a single load between concurrent callers, a key missing
+// in the whole fleet causes a single origin load.
+// All instances have to be configured with the same list of peers.
+type Peers[K comparable, V any] struct {
+	self   string
+	ring   *hashRing
+	client *http.Client
+}
+
+// NewPeers returns Peers for the fleet of instances with given base URLs, self is the URL of the current instance.
+// Each instance should serve PeerHandler on its base URL.
+func NewPeers[K comparable, V any](self string, peers []string) *Peers[K, V] {
+	return &Peers[K, V]{self: self, ring: newHashRing(peers), client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Loader returns loader to be set with WithLoader, which requests the key from the peer owning it,
+// or loads it with origin in case the current instance is the owner. Origin is used as well in case
+// the owner peer request fails, and for keys requested by other peers, which are never forwarded further.
+func (p *Peers[K, V]) Loader(origin func(ctx context.Context, key K) (V, error)) func(ctx context.Context, key K) (V, error) {
+	return func(ctx context.Context, key K) (V, error) {
+		if hop, _ := ctx.Value(peerHopKey{}).(bool); hop {
+			return origin(ctx, key)
+		}
+		owner, ok := p.ring.get(hashKey(key))
+		if !ok || owner == p.self {
+			return origin(ctx, key)
+		}
+		value, err := p.fetch(ctx, owner, key)
+		if err != nil {
+			return origin(ctx, key)
+		}
+		return value, nil
+	}
+}
+
+// fetch requests the key value from the peer
+func (p *Peers[K, V]) fetch(ctx context.Context, peer string, key K) (value V, err error) {
+	u := strings.TrimSuffix(peer, "/") + "/?key=" + url.QueryEscape(fmt.Sprint(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return value, fmt.Errorf("failed to make peer request: %w", err)
+	}
+	req.Header.Set(peerHopHeader, "1")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return value, fmt.Errorf("failed to request peer %s: %w", peer, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return value, fmt.Errorf("peer %s responded with %d: %s", peer, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&value); err != nil {
+		return value, fmt.Errorf("failed to decode peer %s response: %w", peer, err)
+	}
+	return value, nil
+}
+
+// PeerHandler returns http.Handler serving the cache values to peers, as requested by Peers loader.
+// Missing values are loaded with GetCtx. parseKey converts key from its string representation,
+// made with fmt.Sprint. Values are encoded with JSON. Load errors are not exposed to the requester,
+// which gets a generic response with http.StatusBadGateway.
+func PeerHandler[K comparable, V any](c Cache[K, V], parseKey func(s string) (K, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := parseKey(r.URL.Query().Get("key"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid key: %v", err), http.StatusBadRequest)
+			return
+		}
+		ctx := r.Context()
+		if r.Header.Get(peerHopHeader) != "" {
+			ctx = context.WithValue(ctx, peerHopKey{}, true)
+		}
+		value, err := c.GetCtx(ctx, key)
+		if err != nil {
+			http.Error(w, "failed to get value", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(value); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode value: %v", err), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeers(t *testing.T) {
+	var originCalls int32
+	origin := func(_ context.Context, key string) (string, error) {
+		atomic.AddInt32(&originCalls, 1)
+		if key == "bad" {
+			return "", errors.New("can't load")
+		}
+		return "val-" + key, nil
+	}
+
+	// start three instances, each with own cache and handler
+	handlers := make([]http.Handler, 3)
+	servers := make([]*httptest.Server, 3)
+	urls := make([]string, 3)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		defer servers[i].Close()
+		urls[i] = servers[i].URL
+	}
+	caches := make([]Cache[string, string], 3)
+	for i := range caches {
+		peers := NewPeers[string, string](urls[i], urls)
+		caches[i] = NewCache[string, string]().WithLoader(peers.Loader(origin))
+		handlers[i] = PeerHandler(caches[i], func(s string) (string, error) { return s, nil })
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, c := range caches {
+			wg.Add(1)
+			go func(c Cache[string, string], i int) {
+				defer wg.Done()
+				v, err := c.GetCtx(context.Background(), fmt.Sprintf("key%d", i))
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("val-key%d", i), v)
+			}(c, i)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), atomic.LoadInt32(&originCalls), "each key loaded from origin once for the fleet")
+
+	// origin error on owner, non-owner falls back to origin as well
+	atomic.StoreInt32(&originCalls, 0)
+	for _, c := range caches {
+		_, err := c.GetCtx(context.Background(), "bad")
+		assert.EqualError(t, err, "loader failed: can't load")
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&originCalls), "owner once, two other peers twice each")
+
+	// owner is down, origin is used
+	peers := NewPeers[string, string]("http://self", []string{"http://self", "http://127.0.0.1:1"})
+	c := NewCache[string, string]().WithLoader(peers.Loader(origin))
+	for i := 0; i < 10; i++ {
+		v, err := c.GetCtx(context.Background(), fmt.Sprintf("key%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("val-key%d", i), v)
+	}
+}
+
+func TestPeerHandler(t *testing.T) {
+	c := NewCache[int, string]().WithLoader(func(_ context.Context, key int) (string, error) {
+		return fmt.Sprintf("val%d", key), nil
+	})
+	h := PeerHandler(c, func(s string) (key int, err error) {
+		_, err = fmt.Sscan(s, &key)
+		return key, err
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/?key=42", http.NoBody))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "\"val42\"\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/?key=abc", http.NoBody))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	PeerHandler(NewCache[int, string](), func(string) (int, error) { return 1, nil }).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/?key=1", http.NoBody))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	assert.Equal(t, "failed to get value\n", rr.Body.String(), "load error not exposed")
+}
+
+func TestPeersNoForwardingLoop(t *testing.T) {
+	var originCalls int32
+	origin := func(_ context.Context, key string) (string, error) {
+		atomic.AddInt32(&originCalls, 1)
+		return "val-" + key, nil
+	}
+
+	// each instance considers the other one the owner of all the keys
+	handlers := make([]http.Handler, 2)
+	urls := make([]string, 2)
+	for i := range handlers {
+		i := i
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+		urls[i] = srv.URL
+	}
+	caches := make([]Cache[string, string], 2)
+	for i := range caches {
+		peers := NewPeers[string, string](urls[i], []string{urls[1-i]})
+		caches[i] = NewCache[string, string]().WithLoader(peers.Loader(origin))
+		handlers[i] = PeerHandler(caches[i], func(s string) (string, error) { return s, nil })
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := caches[0].GetCtx(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "val-key1", v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&originCalls), "loaded by the peer, not forwarded back")
+	_, ok := caches[1].Peek("key1")
+	assert.True(t, ok, "cached by the peer")
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// Range calls fn for each non-expired entry, from the oldest to the newest, until fn returns false.
+// Entries are collected when iteration starts, so the cache is not locked during fn calls, and fn may
+// call the cache. Each entry is read again right before its fn call, and skipped if it was removed,
+// replaced or expired since. The entry passed to fn is pinned until fn returns: it's not evicted
+// to maintain the cache size and not deleted by DeleteExpired, so OnEvicted and OnDemote are not called
+// for it mid-callback. Explicit Remove, Invalidate and Purge still delete pinned entries.
+func (c *cacheImpl[K, V]) Range(fn func(e Entry[K, V]) bool) {
+	for _, item := range c.rangeItems() {
+		if !c.callPinned(item, fn) {
+			return
+		}
+	}
+}
+
+// rangeItems returns non-expired items, from the oldest to the newest
+func (c *cacheImpl[K, V]) rangeItems() []*cacheItem[K, V] {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	now := time.Now()
+	res := make([]*cacheItem[K, V], 0, len(c.items))
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		if item := ent.Value.(*cacheItem[K, V]); !now.After(c.expiration(item)) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// callPinned calls fn for the item with the item key pinned, in case the item is still in the cache
+// and not expired. Returns true for skipped item, to continue iteration.
+func (c *cacheImpl[K, V]) callPinned(item *cacheItem[K, V], fn func(e Entry[K, V]) bool) bool {
+	c.lock(OpOther)
+	if ent, ok := c.items[item.key]; !ok || ent.Value.(*cacheItem[K, V]) != item || time.Now().After(c.expiration(item)) {
+		c.unlock(OpOther)
+		return true
+	}
+	e := Entry[K, V]{Key: item.key, Value: c.copyValue(item.value), ExpiresAt: c.expiration(item)}
+	c.pin(e.Key)
+	c.unlock(OpOther)
+	defer func() {
+		c.lock(OpOther)
+		c.unpin(e.Key)
+		c.unlock(OpOther)
+	}()
+	return fn(e)
+}
+
+// pin keeps the key entry from eviction until unpin. Has to be called with lock!
+func (c *cacheImpl[K, V]) pin(key K) {
+	if c.pins == nil {
+		c.pins = map[K]int{}
+	}
+	c.pins[key]++
+}
+
+// unpin releases the key pinned by pin. Has to be called with lock!
+func (c *cacheImpl[K, V]) unpin(key K) {
+	if c.pins[key]--; c.pins[key] == 0 {
+		delete(c.pins, key)
+	}
+}
+
+// pinned checks if the entry is pinned by Range callback or Txn. Has to be called with lock!
+func (c *cacheImpl[K, V]) pinned(ent *list.Element) bool {
+	if len(c.pins) == 0 {
+		return false
+	}
+	_, ok := c.pins[ent.Value.(*cacheItem[K, V]).key]
+	return ok
+}
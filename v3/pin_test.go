@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheRangePinsEntry(t *testing.T) {
+	var evicted []string
+	lc := NewCache[string, string]().WithMaxKeys(3).WithOnEvicted(func(key, _ string) {
+		evicted = append(evicted, key)
+	})
+	for i := 1; i <= 3; i++ {
+		lc.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("val%d", i), 0)
+	}
+
+	var seen []string
+	lc.Range(func(e Entry[string, string]) bool {
+		seen = append(seen, e.Key+"="+e.Value)
+		if e.Key == "key1" {
+			lc.Set("key4", "val4", 0) // would evict key1, the oldest one
+			assert.Equal(t, []string{"key2"}, evicted, "pinned entry is not evicted mid-callback")
+			assert.True(t, lc.Contains("key1"))
+			lc.Set("key3", "new3", 0)
+		}
+		return e.Key != "key3"
+	})
+	assert.Equal(t, []string{"key1=val1", "key3=new3"}, seen, "evicted key2 skipped, key3 read again")
+
+	lc.Set("key5", "val5", 0)
+	assert.Equal(t, []string{"key2", "key1"}, evicted, "unpinned entry evicted")
+
+	lc = NewCache[string, string]()
+	lc.Set("key1", "val1", 10*time.Millisecond)
+	lc.Range(func(e Entry[string, string]) bool {
+		time.Sleep(15 * time.Millisecond)
+		lc.DeleteExpired()
+		assert.Equal(t, 1, lc.Len(), "pinned entry is not deleted by DeleteExpired")
+		return true
+	})
+	lc.DeleteExpired()
+	assert.Equal(t, 0, lc.Len())
+}
+
+func TestCacheRangeTrimPinned(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	lc.Range(func(Entry[string, string]) bool {
+		assert.Equal(t, 0, lc.TrimToSize(0), "the only entry is pinned")
+		return true
+	})
+	assert.Equal(t, 1, lc.TrimToSize(0))
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"cmp"
+	"container/list"
+	"slices"
+)
+
+// promote moves the entry to the front of the eviction list on Get in LRU mode, or records the move to be
+// applied later in case promotion buffer is set by WithLRUPromotionBuffer. The entry is recorded once until
+// the moves are applied, repeated accesses only update its access sequence. Has to be called with lock!
+func (c *cacheImpl[K, V]) promote(ent *list.Element) {
+	if c.promoteSize <= 0 {
+		c.evictList.MoveToFront(ent)
+		return
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	c.promoteSeq++
+	if item.promoted == 0 {
+		c.promoteBuf = append(c.promoteBuf, ent)
+	}
+	item.promoted = c.promoteSeq
+	if len(c.promoteBuf) >= c.promoteSize {
+		c.applyPromotions()
+	}
+}
+
+// applyPromotions moves entries recorded by promote to the front of the eviction list, in order of the last access.
+// Entries removed since they were recorded are skipped by MoveToFront. Has to be called with lock!
+func (c *cacheImpl[K, V]) applyPromotions() {
+	if len(c.promoteBuf) == 0 {
+		return
+	}
+	slices.SortFunc(c.promoteBuf, func(a, b *list.Element) int {
+		return cmp.Compare(a.Value.(*cacheItem[K, V]).promoted, b.Value.(*cacheItem[K, V]).promoted)
+	})
+	for i, ent := range c.promoteBuf {
+		c.evictList.MoveToFront(ent)
+		ent.Value.(*cacheItem[K, V]).promoted = 0
+		c.promoteBuf[i] = nil
+	}
+	c.promoteBuf = c.promoteBuf[:0]
+}
+
+// oldest returns the oldest entry of the eviction list, with pending promotions applied. Has to be called with lock!
+func (c *cacheImpl[K, V]) oldest() *list.Element {
+	c.applyPromotions()
+	return c.evictList.Back()
+}
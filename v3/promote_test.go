@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_LRUPromotionBuffer(t *testing.T) {
+	lc := NewCache[string, int]().WithLRU().WithMaxKeys(3).WithLRUPromotionBuffer(10)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	lc.Set("key3", 3, 0)
+	lc.Get("key1")
+	lc.Get("key2")
+	assert.Equal(t, []string{"key3", "key1", "key2"}, lc.Keys(), "promotions applied before ordered read")
+
+	lc.Get("key3")
+	lc.Set("key4", 4, 0)
+	assert.Equal(t, []string{"key2", "key3", "key4"}, lc.Keys(), "promotions applied on write")
+
+	lc.Get("key2")
+	lc.Remove("key2")
+	lc.Get("key3")
+	assert.Equal(t, []string{"key4", "key3"}, lc.Keys(), "removed entry skipped")
+
+	lc.Get("key4")
+	lc.Purge()
+	lc.Set("key5", 5, 0)
+	lc.Set("key6", 6, 0)
+	assert.Equal(t, []string{"key5", "key6"}, lc.Keys(), "pending promotions dropped on purge")
+
+	lc = NewCache[string, int]().WithLRU().WithLRUPromotionBuffer(3)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	lc.Set("key3", 3, 0)
+	for i := 0; i < 10; i++ {
+		lc.Get("key1")
+		lc.Get("key2")
+	}
+	lc.Get("key1")
+	impl := lc.(*cacheImpl[string, int])
+	assert.Len(t, impl.promoteBuf, 2, "hot entries recorded once")
+	assert.Equal(t, []string{"key3", "key2", "key1"}, lc.Keys(), "ordered by the last access")
+
+	lc = NewCache[string, int]().WithLRU().WithLRUPromotionBuffer(2)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, 0)
+	lc.Set("key3", 3, 0)
+	lc.Get("key2")
+	lc.Get("key1")
+	impl = lc.(*cacheImpl[string, int])
+	assert.Empty(t, impl.promoteBuf, "applied once the buffer is full")
+	assert.Equal(t, "key1", impl.evictList.Front().Value.(*cacheItem[string, int]).key)
+}
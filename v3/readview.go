@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// readViewMinDelta is the minimal number of changed entries kept in the read view delta before the view is dropped
+const readViewMinDelta = 16
+
+// readView is an immutable copy of the cache entries, used by Peek and Contains without taking the lock.
+// It consists of the base copy made under the lock and the delta of entries changed since, both shared
+// with the next views. A write publishes a new view with the changed entry added to a copy of the delta,
+// and once the delta is larger than square root of the base size, the view is dropped, and the next read
+// makes a new base copy. So a write costs O(sqrt(n)) instead of a full copy for reads interleaved with writes.
+type readView[K comparable, V any] struct {
+	base  map[K]readEntry[V]
+	delta map[K]readEntry[V]
+}
+
+type readEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+	deleted   bool // entry removed since the base copy, delta only
+}
+
+// get returns the entry of the key from the delta or the base copy
+func (v *readView[K, V]) get(key K) (readEntry[V], bool) {
+	if ent, ok := v.delta[key]; ok {
+		return ent, !ent.deleted
+	}
+	ent, ok := v.base[key]
+	return ent, ok
+}
+
+// lockFree holds the state of lock-free reads enabled by WithLockFreeReads
+type lockFree[K comparable, V any] struct {
+	enabled      bool
+	view         atomic.Pointer[readView[K, V]]
+	hits, misses atomic.Uint64 // Peek stats, added to cache stats on Stat call
+}
+
+// peekLockFree returns the key value from the read view, making a new view in case there is none
+func (c *cacheImpl[K, V]) peekLockFree(key K) (V, bool) {
+	ent, ok := c.readView().get(key)
+	if !ok || time.Now().After(ent.expiresAt) {
+		c.lockFree.misses.Add(1)
+		return c.copyValue(ent.value), false
+	}
+	c.lockFree.hits.Add(1)
+	return c.copyValue(ent.value), true
+}
+
+// readView returns the current read view, making a new one under the lock in case there is none
+func (c *cacheImpl[K, V]) readView() *readView[K, V] {
+	if view := c.lockFree.view.Load(); view != nil {
+		return view
+	}
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if view := c.lockFree.view.Load(); view != nil {
+		return view
+	}
+	view := &readView[K, V]{base: make(map[K]readEntry[V], len(c.items))}
+	for key, ent := range c.items {
+		item := ent.Value.(*cacheItem[K, V])
+		view.base[key] = readEntry[V]{value: item.value, expiresAt: c.expiration(item)}
+	}
+	c.lockFree.view.Store(view)
+	return view
+}
+
+// updateReadView publishes a new read view with the current state of the key entry after it changes,
+// or drops the view once its delta is too large. Has to be called with lock!
+func (c *cacheImpl[K, V]) updateReadView(key K) {
+	if !c.lockFree.enabled {
+		return
+	}
+	view := c.lockFree.view.Load()
+	if view == nil {
+		return
+	}
+	if len(view.delta) >= max(readViewMinDelta, int(math.Sqrt(float64(len(view.base))))) {
+		c.lockFree.view.Store(nil)
+		return
+	}
+	delta := make(map[K]readEntry[V], len(view.delta)+1)
+	for k, ent := range view.delta {
+		delta[k] = ent
+	}
+	delta[key] = readEntry[V]{deleted: true}
+	if ent, ok := c.items[key]; ok {
+		item := ent.Value.(*cacheItem[K, V])
+		delta[key] = readEntry[V]{value: item.value, expiresAt: c.expiration(item)}
+	}
+	c.lockFree.view.Store(&readView[K, V]{base: view.base, delta: delta})
+}
+
+// dropReadView drops the read view after changes of many entries. Has to be called with lock!
+func (c *cacheImpl[K, V]) dropReadView() {
+	if c.lockFree.enabled {
+		c.lockFree.view.Store(nil)
+	}
+}
+
+// foldLockFreeStats adds Peek and miss filter stats counted without the lock to the cache stats. Has to be called with lock!
+func (c *cacheImpl[K, V]) foldLockFreeStats() {
+	if c.missFilter != nil {
+		c.stat.Misses += c.missFilter.rejected.Swap(0)
+	}
+	if !c.lockFree.enabled {
+		return
+	}
+	hits, misses := c.lockFree.hits.Swap(0), c.lockFree.misses.Swap(0)
+	c.stat.PeekHits += hits
+	c.stat.PeekMisses += misses
+	if !c.peekApart {
+		c.stat.Hits += hits
+		c.stat.Misses += misses
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_LockFreeReads(t *testing.T) {
+	lc := NewCache[string, string]().WithLockFreeReads()
+	v, ok := lc.Peek("key1")
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", time.Millisecond)
+	v, ok = lc.Peek("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v, "write drops the view")
+	assert.True(t, lc.Contains("key2"))
+
+	lc.Set("key1", "val1-updated", 0)
+	v, ok = lc.Peek("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1-updated", v)
+
+	time.Sleep(time.Millisecond * 5)
+	v, ok = lc.Peek("key2")
+	assert.False(t, ok, "expired")
+	assert.Equal(t, "val2", v)
+
+	lc.Remove("key1")
+	assert.False(t, lc.Contains("key1"))
+	lc.Purge()
+	assert.False(t, lc.Contains("key2"))
+
+	assert.Equal(t, Stats{Hits: 2, Misses: 2, Added: 2, Evicted: 2, PeekHits: 2, PeekMisses: 2}, lc.Stat())
+	assert.Equal(t, Stats{Hits: 2, Misses: 2, Added: 2, Evicted: 2, PeekHits: 2, PeekMisses: 2}, lc.Stat(), "stats not counted twice")
+}
+
+func TestCache_LockFreeReadsConcurrent(t *testing.T) {
+	lc := NewCache[int, string]().WithLockFreeReads().WithMaxKeys(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if i%5 == 0 {
+					lc.Set(j%200, fmt.Sprintf("val%d", j), 0)
+					continue
+				}
+				if v, ok := lc.Peek(j % 200); ok {
+					assert.NotEmpty(t, v)
+				}
+				lc.Contains(j % 200)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 100, lc.Len())
+	st := lc.Stat()
+	assert.Equal(t, uint64(8000), st.PeekHits+st.PeekMisses)
+}
+
+func TestCache_LockFreeReadsDelta(t *testing.T) {
+	lc := NewCache[int, int]().WithLockFreeReads()
+	for i := 0; i < 100; i++ {
+		lc.Set(i, i, 0)
+	}
+	assert.True(t, lc.Contains(1))
+	impl := lc.(*cacheImpl[int, int])
+	base := impl.lockFree.view.Load().base
+
+	lc.Set(1, 10, 0)
+	lc.Set(100, 100, 0)
+	lc.Remove(2)
+	view := impl.lockFree.view.Load()
+	assert.Len(t, view.delta, 3, "writes kept in delta")
+	assert.Equal(t, base, view.base, "base copy shared")
+	v, ok := lc.Peek(1)
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	assert.Equal(t, []bool{true, true, false, true}, lc.ContainsMany(100, 1, 2, 3))
+
+	for i := 0; i < readViewMinDelta; i++ {
+		lc.Set(i, -i, 0)
+	}
+	assert.Nil(t, impl.lockFree.view.Load(), "dropped once delta is too large")
+	v, ok = lc.Peek(5)
+	assert.True(t, ok)
+	assert.Equal(t, -5, v)
+	assert.Empty(t, impl.lockFree.view.Load().delta, "new base copy")
+}
+
+// BenchmarkCache_LockFreeReadsInterleaved measures Peek with writes interleaved with reads
+func BenchmarkCache_LockFreeReadsInterleaved(b *testing.B) {
+	const size, readsPerWrite = 100_000, 10
+	for _, lockFree := range []bool{false, true} {
+		b.Run(fmt.Sprintf("lock-free=%v", lockFree), func(b *testing.B) {
+			lc := NewCache[int, int]()
+			if lockFree {
+				lc = lc.WithLockFreeReads()
+			}
+			for i := 0; i < size; i++ {
+				lc.Set(i, i, 0)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if i%readsPerWrite == 0 {
+					lc.Set(i%size, i, 0)
+					continue
+				}
+				lc.Peek(i % size)
+			}
+		})
+	}
+}
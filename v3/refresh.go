@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// refreshJob is a background refresh waiting for a free worker
+type refreshJob[K comparable, V any] struct {
+	ctx  context.Context
+	key  K
+	load *inflightLoad[V]
+}
+
+// refresh reloads the key in background, unless it's already being loaded. Has to be called with lock!
+func (c *cacheImpl[K, V]) refresh(key K) {
+	if c.loader == nil || c.closed {
+		return
+	}
+	if _, ok := c.inflight[key]; ok {
+		return
+	}
+	if c.refreshWorkers <= 0 {
+		c.startLoad(context.Background(), key)
+		return
+	}
+	if c.refreshQueue == nil {
+		c.startRefreshWorkers()
+	}
+
+	ctx, load := c.newLoad(context.Background(), key)
+	select {
+	case c.refreshQueue <- refreshJob[K, V]{ctx: ctx, key: key, load: load}:
+	default:
+		load.cancel()
+		delete(c.inflight, key)
+		c.logDebug("refresh dropped, queue is full", slog.Any("key", key))
+	}
+}
+
+// startRefreshWorkers starts refresh workers, which run until the cache is closed. Has to be called with lock!
+func (c *cacheImpl[K, V]) startRefreshWorkers() {
+	size := c.refreshQueueSize
+	if size <= 0 {
+		size = c.refreshWorkers
+	}
+	c.refreshQueue = make(chan refreshJob[K, V], size)
+	for i := 0; i < c.refreshWorkers; i++ {
+		go func(queue <-chan refreshJob[K, V]) {
+			for job := range queue {
+				c.runLoad(job.ctx, job.key, job.load)
+			}
+		}(c.refreshQueue)
+	}
+}
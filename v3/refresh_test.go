@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_RefreshAhead(t *testing.T) {
+	var calls int32
+	lc := NewCache[string, string]().WithTTL(time.Hour).WithRefreshAhead(time.Minute).
+		WithLoader(func(_ context.Context, key string) (string, error) {
+			return fmt.Sprintf("val%d", atomic.AddInt32(&calls, 1)), nil
+		})
+
+	lc.Set("key1", "val", 0)
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val", v)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "plenty of TTL remaining")
+
+	lc.Set("key2", "val", 30*time.Second)
+	v, ok = lc.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "val", v, "cached value returned while refreshing")
+	require.Eventually(t, func() bool {
+		v, _ := lc.Peek("key2")
+		return v == "val1"
+	}, time.Second, time.Millisecond)
+	exp, ok := lc.GetExpiration("key2")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Minute)
+}
+
+func TestCache_RefreshConcurrency(t *testing.T) {
+	var running, maxRunning, calls int32
+	release := make(chan struct{})
+	lc := NewCache[int, string]().WithTTL(time.Hour).WithRefreshAhead(time.Minute).
+		WithRefreshConcurrency(2).WithRefreshQueue(3).
+		WithLoader(func(_ context.Context, key int) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			<-release
+			return "new", nil
+		})
+
+	for i := 0; i < 20; i++ {
+		lc.Set(i, "old", time.Second)
+	}
+	for i := 0; i < 20; i++ {
+		v, ok := lc.Get(i)
+		assert.True(t, ok)
+		assert.Equal(t, "old", v)
+		if i == 1 { // let both workers pick up their refreshes, so the rest fill the queue
+			require.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 2 }, time.Second, time.Millisecond)
+		}
+	}
+	close(release)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 5 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls), "refreshes over workers and queue capacity dropped")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+
+	updated := 0
+	for i := 0; i < 20; i++ {
+		if v, _ := lc.Peek(i); v == "new" {
+			updated++
+		}
+	}
+	assert.Equal(t, 5, updated)
+	require.NoError(t, lc.Close())
+}
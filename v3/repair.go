@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"container/list"
+	"log/slog"
+)
+
+// valid checks the entry with the validator set by WithValidator, evicting it if the check fails,
+// unless it's pinned by Range callback, in which case it's only reported invalid. Has to be called with lock!
+func (c *cacheImpl[K, V]) valid(ent *list.Element) bool {
+	if c.validator == nil {
+		return true
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	if c.validator(item.key, item.value) {
+		return true
+	}
+	if c.pinned(ent) {
+		c.logDebug("invalid entry kept, pinned", slog.Any("key", item.key))
+		return false
+	}
+	c.logDebug("invalid entry evicted", slog.Any("key", item.key))
+	c.removeElement(ent)
+	return false
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheWithValidator(t *testing.T) {
+	type versioned struct {
+		version int
+		data    string
+	}
+	version := 1
+	var evicted []string
+	lc := NewCache[string, versioned]().
+		WithValidator(func(_ string, v versioned) bool { return v.version == version }).
+		WithOnEvicted(func(key string, _ versioned) { evicted = append(evicted, key) })
+	lc.Set("key1", versioned{version: 1, data: "val1"}, 0)
+	lc.Set("key2", versioned{version: 1, data: "val2"}, 0)
+
+	v, ok := lc.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "val1", v.data)
+
+	version = 2
+	_, ok = lc.Get("key1")
+	assert.False(t, ok, "outdated entry is a miss")
+	assert.Equal(t, []string{"key1"}, evicted)
+	assert.Equal(t, 1, lc.Len())
+	assert.True(t, lc.Contains("key2"), "not checked until Get")
+	assert.Equal(t, uint64(1), lc.Stat().Hits)
+	assert.Equal(t, uint64(1), lc.Stat().Misses)
+	assert.Equal(t, uint64(1), lc.Stat().Evicted)
+
+	loads := 0
+	lc = lc.WithLoader(func(_ context.Context, key string) (versioned, error) {
+		loads++
+		return versioned{version: version, data: "loaded " + key}, nil
+	})
+	v, err := lc.GetCtx(context.Background(), "key2")
+	require.NoError(t, err)
+	assert.Equal(t, "loaded key2", v.data, "outdated entry loaded again")
+	v, err = lc.GetCtx(context.Background(), "key2")
+	require.NoError(t, err)
+	assert.Equal(t, "loaded key2", v.data)
+	assert.Equal(t, 1, loads)
+}
+
+func TestCacheWithValidatorPinned(t *testing.T) {
+	valid := true
+	var evicted []string
+	lc := NewCache[string, string]().WithValidator(func(string, string) bool { return valid }).
+		WithOnEvicted(func(key, _ string) { evicted = append(evicted, key) })
+	lc.Set("key1", "val1", 0)
+	valid = false
+	lc.Range(func(e Entry[string, string]) bool {
+		_, ok := lc.Get(e.Key)
+		assert.False(t, ok, "invalid entry is a miss")
+		assert.True(t, lc.Contains(e.Key), "pinned entry is not evicted mid-callback")
+		assert.Empty(t, evicted)
+		return true
+	})
+	_, ok := lc.Get("key1")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"key1"}, evicted, "evicted once unpinned")
+}
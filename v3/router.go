@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// routerReplicas is a number of points each node has on the hash ring
+const routerReplicas = 128
+
+// Router routes keys to multiple caches (nodes) using consistent hashing, so adding or removing
+// a node moves only keys of that node. It can spread a very large cache across several instances,
+// e.g. per CPU, or serve as a building block for client-side distributed cache.
+type Router[K comparable, V any] struct {
+	mu    sync.RWMutex
+	nodes map[string]Cache[K, V]
+	ring  *hashRing
+}
+
+// hashRing is a consistent hashing ring of node names
+type hashRing struct {
+	points []uint64          // sorted points of the ring
+	owners map[uint64]string // node name for each point of the ring
+}
+
+// NewRouter returns a new Router for given nodes, keyed by node name.
+func NewRouter[K comparable, V any](nodes map[string]Cache[K, V]) *Router[K, V] {
+	res := &Router[K, V]{nodes: map[string]Cache[K, V]{}}
+	for name, c := range nodes {
+		res.nodes[name] = c
+	}
+	res.rebuild()
+	return res
+}
+
+// AddNode adds node to the router, replacing existing node with the same name.
+func (r *Router[K, V]) AddNode(name string, c Cache[K, V]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nodes[name] = c
+	r.rebuild()
+}
+
+// RemoveNode removes node from the router, returning if the node existed.
+// Entries of the removed node are left in it.
+func (r *Router[K, V]) RemoveNode(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.nodes[name]; !ok {
+		return false
+	}
+	delete(r.nodes, name)
+	r.rebuild()
+	return true
+}
+
+// Node returns name and cache of the node the key is routed to. Returns false if there are no nodes.
+func (r *Router[K, V]) Node(key K) (name string, c Cache[K, V], ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok = r.ring.get(hashKey(key)); !ok {
+		return "", nil, false
+	}
+	return name, r.nodes[name], true
+}
+
+// Nodes returns names of all nodes, sorted.
+func (r *Router[K, V]) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]string, 0, len(r.nodes))
+	for name := range r.nodes {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Set sets the key in the node it's routed to. Does nothing if there are no nodes.
+func (r *Router[K, V]) Set(key K, value V, ttl time.Duration) {
+	if _, c, ok := r.Node(key); ok {
+		c.Set(key, value, ttl)
+	}
+}
+
+// Get returns the key value from the node it's routed to.
+func (r *Router[K, V]) Get(key K) (V, bool) {
+	if _, c, ok := r.Node(key); ok {
+		return c.Get(key)
+	}
+	return *new(V), false
+}
+
+// Peek returns the key value from the node it's routed to, without updating the "recently used"-ness of the key.
+func (r *Router[K, V]) Peek(key K) (V, bool) {
+	if _, c, ok := r.Node(key); ok {
+		return c.Peek(key)
+	}
+	return *new(V), false
+}
+
+// Remove removes the key from the node it's routed to, returning if the key was contained.
+func (r *Router[K, V]) Remove(key K) bool {
+	if _, c, ok := r.Node(key); ok {
+		return c.Remove(key)
+	}
+	return false
+}
+
+// DeleteExpired deletes expired entries in all nodes concurrently, with up to parallelism nodes
+// cleaned at once, or GOMAXPROCS in case parallelism is not positive. Returns once all nodes are cleaned.
+func (r *Router[K, V]) DeleteExpired(parallelism int) {
+	r.mu.RLock()
+	nodes := make([]Cache[K, V], 0, len(r.nodes))
+	for _, c := range r.nodes {
+		nodes = append(nodes, c)
+	}
+	r.mu.RUnlock()
+
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for _, c := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(c Cache[K, V]) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.DeleteExpired()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// StatsByNode returns stats of each node, keyed by node name.
+func (r *Router[K, V]) StatsByNode() map[string]Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make(map[string]Stats, len(r.nodes))
+	for name, c := range r.nodes {
+		res[name] = c.Stat()
+	}
+	return res
+}
+
+// rebuild makes the hash ring for current nodes. Has to be called with lock!
+func (r *Router[K, V]) rebuild() {
+	names := make([]string, 0, len(r.nodes))
+	for name := range r.nodes {
+		names = append(names, name)
+	}
+	r.ring = newHashRing(names)
+}
+
+// newHashRing makes the hash ring for given node names
+func newHashRing(names []string) *hashRing {
+	res := &hashRing{owners: make(map[uint64]string, len(names)*routerReplicas)}
+	for _, name := range names {
+		for i := 0; i < routerReplicas; i++ {
+			h := fnv.New64a()
+			_, _ = fmt.Fprintf(h, "%s#%d", name, i)
+			point := mix64(h.Sum64())
+			if owner, ok := res.owners[point]; ok && owner < name {
+				continue // keep collisions resolution independent of names order
+			}
+			if _, ok := res.owners[point]; !ok {
+				res.points = append(res.points, point)
+			}
+			res.owners[point] = name
+		}
+	}
+	sort.Slice(res.points, func(i, j int) bool { return res.points[i] < res.points[j] })
+	return res
+}
+
+// get returns name of the node owning the hash, false if the ring is empty
+func (r *hashRing) get(h uint64) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	idx := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return r.owners[r.points[idx]], true
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter(t *testing.T) {
+	nodes := map[string]Cache[string, int]{
+		"node1": NewCache[string, int](),
+		"node2": NewCache[string, int](),
+		"node3": NewCache[string, int](),
+	}
+	r := NewRouter(nodes)
+	assert.Equal(t, []string{"node1", "node2", "node3"}, r.Nodes())
+
+	for i := 0; i < 3000; i++ {
+		r.Set(fmt.Sprintf("key%d", i), i, 0)
+	}
+	for name, c := range nodes {
+		assert.InDelta(t, 1000, c.Len(), 300, "keys spread evenly, node %s", name)
+	}
+
+	v, ok := r.Get("key42")
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	v, ok = r.Peek("key43")
+	assert.True(t, ok)
+	assert.Equal(t, 43, v)
+	name, c, ok := r.Node("key42")
+	require.True(t, ok)
+	assert.True(t, c.Contains("key42"))
+	assert.Contains(t, r.Nodes(), name)
+	hits := uint64(0)
+	for _, st := range r.StatsByNode() {
+		hits += st.Hits
+	}
+	assert.Equal(t, uint64(2), hits)
+
+	assert.True(t, r.Remove("key42"))
+	assert.False(t, r.Remove("key42"))
+	_, ok = r.Get("key42")
+	assert.False(t, ok)
+
+	// adding a node moves only about 1/4 of the keys
+	routes := map[string]string{}
+	for i := 0; i < 3000; i++ {
+		routes[fmt.Sprintf("key%d", i)], _, _ = r.Node(fmt.Sprintf("key%d", i))
+	}
+	r.AddNode("node4", NewCache[string, int]())
+	moved := 0
+	for key, was := range routes {
+		now, _, _ := r.Node(key)
+		if now != was {
+			assert.Equal(t, "node4", now, "keys moved only to the new node")
+			moved++
+		}
+	}
+	assert.InDelta(t, 750, moved, 250)
+
+	// removing the node moves its keys back
+	assert.True(t, r.RemoveNode("node4"))
+	assert.False(t, r.RemoveNode("node4"))
+	for key, was := range routes {
+		now, _, _ := r.Node(key)
+		assert.Equal(t, was, now)
+	}
+
+	empty := NewRouter[string, int](nil)
+	empty.Set("key1", 1, 0)
+	_, ok = empty.Get("key1")
+	assert.False(t, ok)
+	_, ok = empty.Peek("key1")
+	assert.False(t, ok)
+	assert.False(t, empty.Remove("key1"))
+	assert.Empty(t, empty.StatsByNode())
+}
+
+func TestRouter_DeleteExpired(t *testing.T) {
+	nodes := map[string]Cache[string, int]{}
+	for i := 0; i < 8; i++ {
+		nodes[fmt.Sprintf("node%d", i)] = NewCache[string, int]()
+	}
+	r := NewRouter(nodes)
+	for i := 0; i < 1000; i++ {
+		ttl := time.Hour
+		if i%2 == 0 {
+			ttl = time.Millisecond
+		}
+		r.Set(fmt.Sprintf("key%d", i), i, ttl)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	r.DeleteExpired(2)
+	total := 0
+	for _, c := range nodes {
+		total += c.Len()
+	}
+	assert.Equal(t, 500, total)
+
+	r.DeleteExpired(0) // nothing left to delete
+	total = 0
+	for _, c := range nodes {
+		total += c.Len()
+	}
+	assert.Equal(t, 500, total)
+}
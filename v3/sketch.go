@@ -0,0 +1,86 @@
+package cache
+
+import "math"
+
+// EstimateFrequency returns estimated number of recent accesses (Get, Peek, Set and Add) of the key,
+// including accesses of keys not present in the cache. The estimate may exceed the real number
+// but is never below it, except for periodic halving of all counters. Returns 0 without WithFrequencySketch.
+func (c *cacheImpl[K, V]) EstimateFrequency(key K) int {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.sketch == nil {
+		return 0
+	}
+	return c.sketch.estimate(hashKey(key))
+}
+
+// recordAccess increments key frequency in sketch, if it's set. Has to be called with lock!
+func (c *cacheImpl[K, V]) recordAccess(key K) {
+	if c.sketch != nil {
+		c.sketch.increment(hashKey(key))
+	}
+}
+
+// sketchDepth is a number of count-min sketch rows
+const sketchDepth = 4
+
+// countMinSketch estimates key access frequency in fixed memory. Counters are halved
+// after each sampleSize increments, so the estimate reflects recent popularity.
+type countMinSketch struct {
+	rows       [sketchDepth][]uint32
+	mask       uint64
+	increments int
+	sampleSize int
+}
+
+// newCountMinSketch makes sketch with width of the nearest power of two not smaller than expectedKeys
+func newCountMinSketch(expectedKeys int) *countMinSketch {
+	width := 16
+	for width < expectedKeys && width < math.MaxInt32/2 {
+		width *= 2
+	}
+	res := &countMinSketch{mask: uint64(width - 1), sampleSize: 10 * width}
+	for i := range res.rows {
+		res.rows[i] = make([]uint32, width)
+	}
+	return res
+}
+
+// increment adds one to the counters of the hash
+func (s *countMinSketch) increment(h uint64) {
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < math.MaxUint32 {
+			s.rows[i][idx]++
+		}
+	}
+	s.increments++
+	if s.increments >= s.sampleSize {
+		s.age()
+	}
+}
+
+// estimate returns the minimal counter of the hash, which is an upper bound of its frequency
+func (s *countMinSketch) estimate(h uint64) int {
+	res := uint32(math.MaxUint32)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(h, i)]; v < res {
+			res = v
+		}
+	}
+	return int(res)
+}
+
+// age halves all counters
+func (s *countMinSketch) age() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.increments /= 2
+}
+
+func (s *countMinSketch) index(h uint64, row int) uint64 {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	return (h1 + uint64(row)*h2) & s.mask
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEstimateFrequency(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxKeys(1).WithFrequencySketch(100)
+	assert.Equal(t, 0, lc.EstimateFrequency("key1"))
+
+	lc.Set("key1", "val1", 0)
+	lc.Get("key1")
+	lc.Peek("key1")
+	assert.Equal(t, 3, lc.EstimateFrequency("key1"))
+
+	lc.Get("key2") // miss is counted as well
+	lc.Set("key3", "val3", 0)
+	assert.Equal(t, 1, lc.EstimateFrequency("key2"))
+	assert.Equal(t, 3, lc.EstimateFrequency("key1"), "key1 evicted, but frequency is kept")
+
+	assert.Equal(t, 0, NewCache[string, string]().EstimateFrequency("key1"), "no sketch")
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(5000)
+	assert.Equal(t, uint64(8191), s.mask)
+	for i := 0; i < 1000; i++ {
+		for j := 0; j <= i%5; j++ {
+			s.increment(hashKey(fmt.Sprintf("key%d", i)))
+		}
+	}
+	overestimated := 0
+	for i := 0; i < 1000; i++ {
+		est := s.estimate(hashKey(fmt.Sprintf("key%d", i)))
+		assert.GreaterOrEqual(t, est, i%5+1)
+		if est > i%5+1 {
+			overestimated++
+		}
+	}
+	assert.Less(t, overestimated, 20)
+
+	s = newCountMinSketch(1) // minimal width 16, sample size 160
+	for i := 0; i < 159; i++ {
+		s.increment(hashKey("key"))
+	}
+	assert.Equal(t, 159, s.estimate(hashKey("key")))
+	s.increment(hashKey("key"))
+	assert.Equal(t, 80, s.estimate(hashKey("key")), "counters halved")
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// encodedEntry is a snapshot entry with the key encoded by the codec set with WithKeyCodec
+type encodedEntry[V any] struct {
+	Key       []byte
+	Value     V
+	ExpiresAt time.Time
+}
+
+// WriteSnapshot writes all non-expired cache entries to w, from oldest to newest, using gob encoding.
+// In case snapshot encryption is set, the encoded stream is encrypted with AES-GCM.
+// Values have to be gob-encodable, as well as keys unless key codec is set with WithKeyCodec.
+func (c *cacheImpl[K, V]) WriteSnapshot(w io.Writer) error {
+	c.lock(OpOther)
+	items := make([]Entry[K, V], 0, len(c.items))
+	now := time.Now()
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		item := ent.Value.(*cacheItem[K, V])
+		if now.After(c.expiration(item)) {
+			continue
+		}
+		items = append(items, Entry[K, V]{Key: item.key, Value: item.value, ExpiresAt: c.expiration(item)})
+	}
+	encKey, keyEncode, sum := c.snapshotKey, c.keyEncode, c.checksum
+	c.unlock(OpOther)
+
+	var buf bytes.Buffer
+	var err error
+	if keyEncode != nil {
+		err = encodeEntries(&buf, items, keyEncode, sum)
+	} else {
+		err = encodeList(&buf, items, sum)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	data := buf.Bytes()
+	if encKey != nil {
+		if data, err = encryptSnapshot(encKey, data); err != nil {
+			return fmt.Errorf("failed to encrypt snapshot: %w", err)
+		}
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot reads cache entries written by WriteSnapshot from r and adds them to the cache,
+// keeping their expiration time. Entries expired since the snapshot was made are skipped.
+func (c *cacheImpl[K, V]) ReadSnapshot(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	c.lock(OpOther)
+	encKey, keyDecode, sum, closed := c.snapshotKey, c.keyDecode, c.checksum, c.closed
+	c.unlock(OpOther)
+	if closed {
+		return ErrClosed
+	}
+	if encKey != nil {
+		if data, err = decryptSnapshot(encKey, data); err != nil {
+			return fmt.Errorf("failed to decrypt snapshot: %w", err)
+		}
+	}
+
+	var items []Entry[K, V]
+	var corrupted int
+	if keyDecode != nil {
+		items, corrupted, err = decodeEntries[K, V](bytes.NewReader(data), keyDecode, sum)
+	} else {
+		items, corrupted, err = decodeList[Entry[K, V]](bytes.NewReader(data), sum)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if corrupted > 0 {
+		c.logError("corrupted snapshot entries skipped", slog.Int("corrupted", corrupted))
+	}
+
+	for _, item := range items {
+		ttl := time.Until(item.ExpiresAt)
+		if ttl <= 0 {
+			continue
+		}
+		c.addWithTTL(item.Key, item.Value, ttl, false)
+	}
+	return nil
+}
+
+// encodeEntries encodes entries with keys encoded by keyEncode
+func encodeEntries[K comparable, V any](w io.Writer, items []Entry[K, V], keyEncode func(K) ([]byte, error), sum Checksum) error {
+	encoded := make([]encodedEntry[V], 0, len(items))
+	for _, item := range items {
+		key, err := keyEncode(item.Key)
+		if err != nil {
+			return fmt.Errorf("failed to encode key %v: %w", item.Key, err)
+		}
+		encoded = append(encoded, encodedEntry[V]{Key: key, Value: item.Value, ExpiresAt: item.ExpiresAt})
+	}
+	return encodeList(w, encoded, sum)
+}
+
+// decodeEntries decodes entries written by encodeEntries, with keys decoded by keyDecode,
+// returning number of entries skipped as corrupted
+func decodeEntries[K comparable, V any](r io.Reader, keyDecode func([]byte) (K, error), sum Checksum) ([]Entry[K, V], int, error) {
+	encoded, corrupted, err := decodeList[encodedEntry[V]](r, sum)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]Entry[K, V], 0, len(encoded))
+	for _, item := range encoded {
+		key, err := keyDecode(item.Key)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode key: %w", err)
+		}
+		items = append(items, Entry[K, V]{Key: key, Value: item.Value, ExpiresAt: item.ExpiresAt})
+	}
+	return items, corrupted, nil
+}
+
+// encryptSnapshot encrypts data with AES-GCM, random nonce is prepended to the result
+func encryptSnapshot(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to make nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// decryptSnapshot decrypts data made by encryptSnapshot
+func decryptSnapshot(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted snapshot is too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
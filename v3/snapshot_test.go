@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSnapshot(t *testing.T) {
+	lc := NewCache[string, int]().WithTTL(time.Hour)
+	lc.Set("key1", 1, 0)
+	lc.Set("key2", 2, time.Millisecond*5)
+	lc.Set("key3", 3, time.Minute)
+	time.Sleep(time.Millisecond * 10)
+
+	var buf bytes.Buffer
+	require.NoError(t, lc.WriteSnapshot(&buf))
+
+	restored := NewCache[string, int]()
+	require.NoError(t, restored.ReadSnapshot(&buf))
+	assert.Equal(t, []string{"key1", "key3"}, restored.Keys(), "expired key2 not in snapshot")
+	v, ok := restored.Get("key3")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	exp, ok := lc.GetExpiration("key3")
+	require.True(t, ok)
+	restoredExp, ok := restored.GetExpiration("key3")
+	require.True(t, ok)
+	assert.WithinDuration(t, exp, restoredExp, time.Millisecond*10)
+
+	// restore into limited cache
+	limited := NewCache[string, int]().WithMaxKeys(1)
+	buf.Reset()
+	require.NoError(t, lc.WriteSnapshot(&buf))
+	require.NoError(t, limited.ReadSnapshot(&buf))
+	assert.Equal(t, []string{"key3"}, limited.Keys())
+
+	assert.Error(t, restored.ReadSnapshot(bytes.NewBufferString("garbage")))
+}
+
+func TestCacheSnapshotEncryption(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	lc := NewCache[string, string]().WithSnapshotEncryption(key)
+	lc.Set("token", "secret-value", 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, lc.WriteSnapshot(&buf))
+	assert.NotContains(t, buf.String(), "secret-value")
+	assert.NotContains(t, buf.String(), "token")
+	encrypted := buf.Bytes()
+
+	restored := NewCache[string, string]().WithSnapshotEncryption(key)
+	require.NoError(t, restored.ReadSnapshot(bytes.NewReader(encrypted)))
+	v, ok := restored.Get("token")
+	assert.True(t, ok)
+	assert.Equal(t, "secret-value", v)
+
+	wrongKey := NewCache[string, string]().WithSnapshotEncryption([]byte("fedcba9876543210fedcba9876543210"))
+	assert.ErrorContains(t, wrongKey.ReadSnapshot(bytes.NewReader(encrypted)), "failed to decrypt snapshot")
+
+	noKey := NewCache[string, string]()
+	assert.ErrorContains(t, noKey.ReadSnapshot(bytes.NewReader(encrypted)), "failed to decode snapshot")
+
+	badKey := NewCache[string, string]().WithSnapshotEncryption([]byte("short"))
+	assert.ErrorContains(t, badKey.WriteSnapshot(&buf), "invalid key size")
+	assert.ErrorContains(t, badKey.ReadSnapshot(bytes.NewReader(encrypted)), "invalid key size")
+	assert.ErrorContains(t, lc.ReadSnapshot(bytes.NewReader([]byte{1, 2})), "too short")
+}
+
+func TestCacheSnapshotKeyCodec(t *testing.T) {
+	type point struct{ x, y int }
+	encode := func(p point) ([]byte, error) { return []byte(fmt.Sprintf("%d:%d", p.x, p.y)), nil }
+	decode := func(data []byte) (p point, err error) {
+		_, err = fmt.Sscanf(string(data), "%d:%d", &p.x, &p.y)
+		return p, err
+	}
+
+	lc := NewCache[point, string]().WithTTL(time.Hour)
+	lc.Set(point{1, 2}, "a", 0)
+	lc.Set(point{3, 4}, "b", 0)
+	var buf bytes.Buffer
+	require.Error(t, lc.WriteSnapshot(&buf), "point has no exported fields")
+
+	lc = lc.WithKeyCodec(encode, decode)
+	buf.Reset()
+	require.NoError(t, lc.WriteSnapshot(&buf))
+
+	restored := NewCache[point, string]().WithKeyCodec(encode, decode)
+	require.NoError(t, restored.ReadSnapshot(&buf))
+	assert.Equal(t, []point{{1, 2}, {3, 4}}, restored.Keys())
+	v, ok := restored.Get(point{3, 4})
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+
+	lc = NewCache[point, string]().WithKeyCodec(func(point) ([]byte, error) { return nil, errors.New("bad key") }, decode)
+	lc.Set(point{1, 2}, "a", 0)
+	assert.EqualError(t, lc.WriteSnapshot(&buf), "failed to encode snapshot: failed to encode key {1 2}: bad key")
+}
+
+func TestCacheSnapshotChecksum(t *testing.T) {
+	var logs bytes.Buffer
+	lc := NewCache[string, string]().WithTTL(time.Hour).WithChecksum(CRC32)
+	lc.Set("key1", "value-one", 0)
+	lc.Set("key2", "value-two", 0)
+	lc.Set("key3", "value-three", 0)
+	var buf bytes.Buffer
+	require.NoError(t, lc.WriteSnapshot(&buf))
+
+	data := buf.Bytes()
+	i := bytes.Index(data, []byte("value-two"))
+	require.Positive(t, i)
+	data[i] = 'V' // corrupt the entry data, not the snapshot structure
+
+	restored := NewCache[string, string]().WithChecksum(CRC32).
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	require.NoError(t, restored.ReadSnapshot(bytes.NewReader(data)))
+	assert.Equal(t, []string{"key1", "key3"}, restored.Keys(), "corrupted entry skipped")
+	assert.Contains(t, logs.String(), `level=ERROR msg="corrupted snapshot entries skipped" corrupted=1`)
+
+	withoutChecksum := NewCache[string, string]()
+	_ = withoutChecksum.ReadSnapshot(bytes.NewReader(data))
+	assert.Zero(t, withoutChecksum.Len(), "snapshot with checksum needs the same checksum")
+
+	// the same with key codec
+	lc = lc.WithKeyCodec(func(key string) ([]byte, error) { return []byte(key), nil },
+		func(data []byte) (string, error) { return string(data), nil })
+	buf.Reset()
+	require.NoError(t, lc.WriteSnapshot(&buf))
+	data = buf.Bytes()
+	data[bytes.Index(data, []byte("value-three"))] = 'V'
+	restored = NewCache[string, string]().WithChecksum(CRC32).WithKeyCodec(func(key string) ([]byte, error) { return []byte(key), nil },
+		func(data []byte) (string, error) { return string(data), nil })
+	require.NoError(t, restored.ReadSnapshot(bytes.NewReader(data)))
+	assert.Equal(t, []string{"key1", "key2"}, restored.Keys())
+}
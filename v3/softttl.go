@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// SetWithSoftHardTTL sets the key value with soft and hard TTL. Once soft TTL passes the entry is stale:
+// Get and GetCtx treat it as expired, while GetStale still returns it, refreshing it in background with
+// the loader if set, until hard TTL passes and the entry is deleted the usual way, e.g. by DeleteExpired.
+// Entry refreshed by the loader keeps both TTLs, while other writes of the key make it an ordinary entry.
+// Hard TTL shorter than soft one is treated as equal to it.
+func (c *cacheImpl[K, V]) SetWithSoftHardTTL(key K, value V, soft, hard time.Duration) {
+	defer c.writeThrough()
+	if c.observer != nil {
+		defer c.observe(OpSet, time.Now())
+	}
+	c.lock(OpSet)
+	defer c.unlock(OpSet)
+	c.add(key, value, soft, true)
+	if ent, ok := c.items[key]; ok {
+		c.setGrace(key, hard-ent.Value.(*cacheItem[K, V]).ttl)
+	}
+}
+
+// GetStale returns the key value if its hard TTL set by SetWithSoftHardTTL didn't pass, with stale set
+// once soft TTL passed. Stale value is counted as a hit and refreshed in background with the loader if set,
+// the same way WithRefreshAhead does. For other entries it works the same way as Get, with stale always false.
+func (c *cacheImpl[K, V]) GetStale(key K) (value V, stale, ok bool) {
+	c.lock(OpGet)
+	defer c.unlock(OpGet)
+	ent, found := c.items[key]
+	if !found || time.Now().After(c.hardExpiration(ent.Value.(*cacheItem[K, V]))) {
+		if value, ok = c.parentLookup(key, false, OpGet); ok {
+			return value, false, true
+		}
+		ent, found = c.items[key] // the lock was released by parentLookup
+	}
+	if !found || ent.Value.(*cacheItem[K, V]).grace <= 0 {
+		value, ok = c.get(key)
+		return value, false, ok
+	}
+	item := ent.Value.(*cacheItem[K, V])
+	now := time.Now()
+	if !now.After(c.expiration(item)) || now.After(c.hardExpiration(item)) || !c.valid(ent) {
+		value, ok = c.get(key)
+		return value, false, ok
+	}
+	c.recordAccess(key)
+	c.refresh(key)
+	c.updateStat(key, func(s *Stats) { s.Hits++ })
+	c.logDebug("stale entry returned", slog.Any("key", key), slog.Time("expires_at", c.hardExpiration(item)))
+	return c.copyValue(item.value), true, true
+}
+
+// hardExpiration returns time the item is deleted at, which is later than its expiration for items
+// set by SetWithSoftHardTTL, unless they were written before the epoch bump. Has to be called with lock!
+func (c *cacheImpl[K, V]) hardExpiration(item *cacheItem[K, V]) time.Time {
+	if c.staleEpoch(item) {
+		return c.expiration(item)
+	}
+	return item.expiresAt.Add(item.grace)
+}
+
+// softHardTTL returns soft TTL and the time between soft and hard expiration of the key set by SetWithSoftHardTTL,
+// zero grace for other keys. Has to be called with lock!
+func (c *cacheImpl[K, V]) softHardTTL(key K) (soft, grace time.Duration) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*cacheItem[K, V]).ttl, ent.Value.(*cacheItem[K, V]).grace
+	}
+	return 0, 0
+}
+
+// setGrace sets the time between soft and hard expiration of the key, if it's in the cache. Has to be called with lock!
+func (c *cacheImpl[K, V]) setGrace(key K, grace time.Duration) {
+	if ent, ok := c.items[key]; ok {
+		ent.Value.(*cacheItem[K, V]).grace = max(grace, 0)
+	}
+}
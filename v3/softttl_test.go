@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSetWithSoftHardTTL(t *testing.T) {
+	lc := NewCache[string, string]().WithTTL(time.Hour)
+	lc.SetWithSoftHardTTL("key1", "val1", time.Millisecond*50, time.Millisecond*150)
+	lc.Set("key2", "val2", time.Millisecond*50)
+
+	v, stale, ok := lc.GetStale("key1")
+	assert.True(t, ok)
+	assert.False(t, stale)
+	assert.Equal(t, "val1", v)
+
+	time.Sleep(time.Millisecond * 70)
+	_, ok = lc.Get("key1")
+	assert.False(t, ok, "soft TTL passed")
+	v, stale, ok = lc.GetStale("key1")
+	assert.True(t, ok)
+	assert.True(t, stale)
+	assert.Equal(t, "val1", v)
+	_, stale, ok = lc.GetStale("key2")
+	assert.False(t, ok, "ordinary entry expired")
+	assert.False(t, stale)
+
+	lc.DeleteExpired()
+	assert.Equal(t, []string{"key1"}, lc.Keys(), "stale entry kept until hard TTL")
+	assert.True(t, lc.NextSuggestedCleanup().After(time.Now()))
+
+	time.Sleep(time.Millisecond * 100)
+	_, _, ok = lc.GetStale("key1")
+	assert.False(t, ok, "hard TTL passed")
+	lc.DeleteExpired()
+	assert.Equal(t, 0, lc.Len())
+	assert.Equal(t, uint64(2), lc.Stat().Hits)
+	assert.Equal(t, uint64(3), lc.Stat().Misses)
+
+	lc.SetWithSoftHardTTL("key3", "val3", time.Millisecond*50, time.Millisecond)
+	time.Sleep(time.Millisecond * 70)
+	_, _, ok = lc.GetStale("key3")
+	assert.False(t, ok, "hard TTL shorter than soft one")
+	lc.SetWithSoftHardTTL("key3", "val3", time.Millisecond*50, time.Hour)
+	lc.Set("key3", "val3", time.Millisecond*50)
+	time.Sleep(time.Millisecond * 70)
+	_, _, ok = lc.GetStale("key3")
+	assert.False(t, ok, "overwrite makes it ordinary entry")
+}
+
+func TestCacheGetStaleRefresh(t *testing.T) {
+	var loads atomic.Int32
+	lc := NewCache[string, string]().WithLoader(func(context.Context, string) (string, error) {
+		loads.Add(1)
+		return "refreshed", nil
+	})
+	lc.SetWithSoftHardTTL("key1", "val1", time.Millisecond*50, time.Hour)
+	time.Sleep(time.Millisecond * 70)
+
+	v, stale, ok := lc.GetStale("key1")
+	require.True(t, ok)
+	assert.True(t, stale)
+	assert.Equal(t, "val1", v)
+	assert.Eventually(t, func() bool {
+		v, stale, ok = lc.GetStale("key1")
+		return ok && !stale && v == "refreshed"
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, int32(1), loads.Load())
+
+	time.Sleep(time.Millisecond * 70)
+	_, stale, ok = lc.GetStale("key1")
+	assert.True(t, ok, "refreshed entry keeps hard TTL")
+	assert.True(t, stale, "refreshed entry keeps soft TTL")
+}
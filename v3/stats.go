@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// histogramBounds are upper bounds of Histogram buckets, the last bucket has no upper bound
+var histogramBounds = []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute,
+	time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// HitRatio returns ratio of hits to all Get calls, 0 if there were no calls
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// TimeSaved returns time saved by the cache, estimated as hits multiplied by the average miss cost.
+// It's 0 until miss costs are counted, see WithMissCost.
+func (s Stats) TimeSaved() time.Duration {
+	if s.CostedMisses == 0 {
+		return 0
+	}
+	return time.Duration(s.Hits) * (s.MissCost / time.Duration(s.CostedMisses))
+}
+
+// Sub returns stats counted since prev, taken from the same cache earlier. Stats reset by Purge
+// with WithStatsResetOnPurge are detected by changed Resets, in which case all the stats since the reset
+// are returned. Counters wrapped around on overflow need no special care, as unsigned subtraction handles it.
+func (s Stats) Sub(prev Stats) Stats {
+	if s.Resets != prev.Resets {
+		s.Resets -= prev.Resets
+		return s
+	}
+	return Stats{Hits: s.Hits - prev.Hits, Misses: s.Misses - prev.Misses, Added: s.Added - prev.Added,
+		Evicted: s.Evicted - prev.Evicted, PeekHits: s.PeekHits - prev.PeekHits, PeekMisses: s.PeekMisses - prev.PeekMisses,
+		EvictedEarly: s.EvictedEarly - prev.EvictedEarly, EvictedOnOverwrite: s.EvictedOnOverwrite - prev.EvictedOnOverwrite,
+		RejectedTooLarge: s.RejectedTooLarge - prev.RejectedTooLarge, MissCost: s.MissCost - prev.MissCost,
+		CostedMisses: s.CostedMisses - prev.CostedMisses}
+}
+
+// Add returns stats summed with other, e.g. to aggregate stats of shards or caches.
+// Resets are summed as well, so Sub of aggregated stats detects a reset of any of them.
+func (s Stats) Add(other Stats) Stats {
+	return Stats{Hits: s.Hits + other.Hits, Misses: s.Misses + other.Misses, Added: s.Added + other.Added,
+		Evicted: s.Evicted + other.Evicted, PeekHits: s.PeekHits + other.PeekHits, PeekMisses: s.PeekMisses + other.PeekMisses,
+		EvictedEarly: s.EvictedEarly + other.EvictedEarly, EvictedOnOverwrite: s.EvictedOnOverwrite + other.EvictedOnOverwrite,
+		RejectedTooLarge: s.RejectedTooLarge + other.RejectedTooLarge, MissCost: s.MissCost + other.MissCost,
+		CostedMisses: s.CostedMisses + other.CostedMisses, Resets: s.Resets + other.Resets}
+}
+
+// LogValue implements slog.LogValuer, logging all the stats fields and hit ratio as a group
+func (s Stats) LogValue() slog.Value {
+	return slog.GroupValue(slog.Uint64("hits", s.Hits), slog.Uint64("misses", s.Misses), slog.Float64("hit_ratio", s.HitRatio()),
+		slog.Uint64("added", s.Added), slog.Uint64("evicted", s.Evicted), slog.Uint64("peek_hits", s.PeekHits),
+		slog.Uint64("peek_misses", s.PeekMisses), slog.Uint64("evicted_early", s.EvictedEarly),
+		slog.Uint64("evicted_on_overwrite", s.EvictedOnOverwrite), slog.Uint64("rejected_too_large", s.RejectedTooLarge),
+		slog.Uint64("resets", s.Resets))
+}
+
+// DetailedStats provides stats with distribution of entries age and remaining TTL
+type DetailedStats struct {
+	Stats
+	Size         int       // number of entries, including expired
+	Expired      int       // number of expired entries not deleted yet
+	Age          Histogram // time since entries were added
+	RemainingTTL Histogram // time left until expiration of not expired entries
+}
+
+// Histogram counts durations in buckets. Counts[i] is a number of durations not greater than Bounds[i]
+// and greater than the previous bound, the last element of Counts is for durations above all bounds.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []int
+}
+
+// String returns histogram as a list of buckets with counts
+func (h Histogram) String() string {
+	res := ""
+	for i, cnt := range h.Counts {
+		if i > 0 {
+			res += " "
+		}
+		if i < len(h.Bounds) {
+			res += fmt.Sprintf("<=%v:%d", h.Bounds[i], cnt)
+			continue
+		}
+		res += fmt.Sprintf(">%v:%d", h.Bounds[len(h.Bounds)-1], cnt)
+	}
+	return res
+}
+
+func newHistogram() Histogram {
+	return Histogram{Bounds: histogramBounds, Counts: make([]int, len(histogramBounds)+1)}
+}
+
+func (h *Histogram) add(d time.Duration) {
+	for i, b := range h.Bounds {
+		if d <= b {
+			h.Counts[i]++
+			return
+		}
+	}
+	h.Counts[len(h.Counts)-1]++
+}
+
+// StatsDetailed returns stats with distribution of entries age and remaining TTL. It scans all entries,
+// so it's more expensive than Stat. Many entries with short age and remaining TTL mean TTL is too short
+// and entries churn, many young entries with long remaining TTL and a lot of evictions mean the cache
+// is evicting entries by size long before they expire.
+func (c *cacheImpl[K, V]) StatsDetailed() DetailedStats {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	c.foldLockFreeStats()
+	res := DetailedStats{Stats: c.stat, Size: c.evictList.Len(), Age: newHistogram(), RemainingTTL: newHistogram()}
+	now := time.Now()
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		item := ent.Value.(*cacheItem[K, V])
+		res.Age.add(now.Sub(item.createdAt))
+		if now.After(c.expiration(item)) {
+			res.Expired++
+			continue
+		}
+		res.RemainingTTL.add(c.expiration(item).Sub(now))
+	}
+	return res
+}
+
+// ExpirationBuckets returns number of non-expired entries expiring in each upcoming time bucket of width,
+// keyed by the bucket start, to predict expiration storms. Entries without expiration, i.e. with no TTL set,
+// are not counted. Returns nil if width is not positive.
+func (c *cacheImpl[K, V]) ExpirationBuckets(width time.Duration) map[time.Time]int {
+	if width <= 0 {
+		return nil
+	}
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	res := map[time.Time]int{}
+	now := time.Now()
+	for _, ent := range c.items {
+		item := ent.Value.(*cacheItem[K, V])
+		if item.ttl == noEvictionTTL || now.After(c.expiration(item)) {
+			continue
+		}
+		res[c.expiration(item).Truncate(width)]++
+	}
+	return res
+}
+
+// StatsByNamespace returns stats for each namespace, as defined by WithNamespace function.
+// Returns empty map in case namespaces are not set.
+func (c *cacheImpl[K, V]) StatsByNamespace() map[string]Stats {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	res := make(map[string]Stats, len(c.nsStat))
+	for ns, s := range c.nsStat {
+		res[ns] = *s
+	}
+	return res
+}
+
+// updateStat applies fn to the cache-wide stats and to the stats of the key's namespace. Has to be called with lock!
+func (c *cacheImpl[K, V]) updateStat(key K, fn func(s *Stats)) {
+	fn(&c.stat)
+	c.pushStats()
+	c.sampleStats()
+	if c.namespaceFn == nil {
+		return
+	}
+	ns := c.namespaceFn(key)
+	s, ok := c.nsStat[ns]
+	if !ok {
+		s = &Stats{Resets: c.stat.Resets} // namespace stats are reset along with the cache-wide ones
+		c.nsStat[ns] = s
+	}
+	fn(s)
+}
+
+// updatePeekStat counts Peek hit or miss, in Hits and Misses as well unless Peek stats are separated.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) updatePeekStat(key K, hit bool) {
+	c.updateStat(key, func(s *Stats) {
+		switch {
+		case hit && c.peekApart:
+			s.PeekHits++
+		case hit:
+			s.PeekHits++
+			s.Hits++
+		case c.peekApart:
+			s.PeekMisses++
+		default:
+			s.PeekMisses++
+			s.Misses++
+		}
+	})
+}
+
+// countMissCost counts cost of the key miss, set by WithMissCost or measured loader call duration d,
+// the latter only in case WithMissCost is not set. Has to be called with lock!
+func (c *cacheImpl[K, V]) countMissCost(key K, d time.Duration) {
+	switch {
+	case c.missCost != nil && d == 0:
+		d = c.missCost(key)
+	case c.missCost != nil || d == 0:
+		return
+	}
+	c.updateStat(key, func(s *Stats) {
+		s.MissCost += d
+		s.CostedMisses++
+	})
+}
+
+// countEarlyEviction counts the item evicted to maintain the size in EvictedEarly stats
+// in case it has large enough part of its TTL remaining. Has to be called with lock!
+func (c *cacheImpl[K, V]) countEarlyEviction(item *cacheItem[K, V]) {
+	if float64(time.Until(c.expiration(item))) >= c.earlyRate*float64(item.ttl) {
+		c.updateStat(item.key, func(s *Stats) { s.EvictedEarly++ })
+	}
+}
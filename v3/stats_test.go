@@ -0,0 +1,284 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStatsByNamespace(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxKeys(3).WithNamespace(func(key string) string {
+		return strings.Split(key, ":")[0]
+	})
+	assert.Empty(t, lc.StatsByNamespace())
+
+	lc.Set("tenant1:key1", "val1", 0)
+	lc.Set("tenant1:key2", "val2", 0)
+	lc.Set("tenant2:key1", "val1", 0)
+	lc.Set("tenant2:key2", "val2", 0) // evicts tenant1:key1
+	lc.Get("tenant1:key1")
+	lc.Get("tenant1:key2")
+	lc.Peek("tenant2:key1")
+	lc.Get("tenant3:key1")
+
+	assert.Equal(t, map[string]Stats{
+		"tenant1": {Hits: 1, Misses: 1, Added: 2, Evicted: 1, EvictedEarly: 1},
+		"tenant2": {Hits: 1, Added: 2, PeekHits: 1},
+		"tenant3": {Misses: 1},
+	}, lc.StatsByNamespace())
+	assert.Equal(t, Stats{Hits: 2, Misses: 2, Added: 4, Evicted: 1, PeekHits: 1, EvictedEarly: 1}, lc.Stat())
+
+	lc.Purge()
+	assert.Equal(t, uint64(2), lc.StatsByNamespace()["tenant1"].Evicted)
+	assert.Equal(t, uint64(2), lc.StatsByNamespace()["tenant2"].Evicted)
+
+	assert.Empty(t, NewCache[string, string]().StatsByNamespace())
+}
+
+func TestCachePeekStats(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	lc.Peek("key1")
+	lc.Peek("key2")
+	lc.Get("key1")
+	assert.Equal(t, Stats{Hits: 2, Misses: 1, Added: 1, PeekHits: 1, PeekMisses: 1}, lc.Stat())
+
+	lc = NewCache[string, string]().WithPeekStatsSeparated()
+	lc.Set("key1", "val1", 0)
+	lc.Peek("key1")
+	lc.Peek("key2")
+	lc.Get("key1")
+	assert.Equal(t, Stats{Hits: 1, Added: 1, PeekHits: 1, PeekMisses: 1}, lc.Stat())
+}
+
+func TestCacheStatsDetailed(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("key1", "val1", time.Millisecond)
+	lc.Set("key2", "val2", time.Minute*5)
+	lc.Set("key3", "val3", time.Hour*48)
+	lc.Set("key4", "val4", 0)
+	time.Sleep(time.Millisecond * 2)
+
+	st := lc.StatsDetailed()
+	assert.Equal(t, Stats{Added: 4}, st.Stats)
+	assert.Equal(t, 4, st.Size)
+	assert.Equal(t, 1, st.Expired)
+	assert.Equal(t, []int{4, 0, 0, 0, 0, 0, 0, 0}, st.Age.Counts)
+	assert.Equal(t, []int{0, 0, 0, 1, 0, 0, 0, 2}, st.RemainingTTL.Counts)
+	assert.Equal(t, "<=1s:0 <=10s:0 <=1m0s:0 <=10m0s:1 <=1h0m0s:0 <=6h0m0s:0 <=24h0m0s:0 >24h0m0s:2",
+		st.RemainingTTL.String())
+}
+
+func TestCacheEvictedEarly(t *testing.T) {
+	lc := NewCache[string, string]().WithMaxKeys(1)
+	lc.Set("key1", "val1", time.Millisecond*20)
+	lc.Set("key2", "val2", time.Millisecond*20) // key1 evicted with all TTL remaining
+	time.Sleep(time.Millisecond * 15)
+	lc.Set("key3", "val3", 0) // key2 evicted with a quarter of TTL remaining
+	assert.Equal(t, uint64(1), lc.Stat().EvictedEarly)
+	assert.Equal(t, uint64(2), lc.Stat().Evicted)
+
+	lc.Invalidate("key3")
+	assert.Equal(t, uint64(1), lc.Stat().EvictedEarly, "not counted for invalidation")
+
+	lc = NewCache[string, string]().WithMaxKeys(1).WithEarlyEvictionThreshold(0.1)
+	lc.Set("key1", "val1", time.Millisecond*20)
+	time.Sleep(time.Millisecond * 15)
+	lc.Set("key2", "val2", 0)
+	assert.Equal(t, uint64(1), lc.Stat().EvictedEarly)
+}
+
+func TestCacheMissCost(t *testing.T) {
+	lc := NewCache[string, string]().WithMissCost(func(key string) time.Duration {
+		return time.Duration(len(key)) * time.Millisecond
+	})
+	assert.Equal(t, time.Duration(0), lc.Stat().TimeSaved(), "no misses counted yet")
+	lc.Set("key1", "val1", 0)
+	lc.Get("key1")
+	lc.Get("key1")
+	lc.Get("missing")
+	lc.Get("k")
+	lc.Peek("nope") // peek misses don't cost anything
+	stats := lc.Stat()
+	assert.Equal(t, 8*time.Millisecond, stats.MissCost)
+	assert.Equal(t, uint64(2), stats.CostedMisses)
+	assert.Equal(t, 8*time.Millisecond, stats.TimeSaved())
+
+	// loader call durations are counted without WithMissCost
+	lc = NewCache[string, string]().WithLoader(func(_ context.Context, key string) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "val-" + key, nil
+	})
+	for i := 0; i < 3; i++ {
+		_, err := lc.GetCtx(context.Background(), "key1")
+		assert.NoError(t, err)
+	}
+	stats = lc.Stat()
+	assert.Equal(t, uint64(1), stats.CostedMisses)
+	assert.GreaterOrEqual(t, stats.MissCost, 10*time.Millisecond)
+	assert.Equal(t, 2*stats.MissCost, stats.TimeSaved())
+}
+
+func TestStats_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	lc := NewCache[string, string]().WithName("users").WithMaxKeys(1)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Get("key2")
+	lc.Get("key1")
+	lc.Get("key2")
+
+	logger.Info("state", "cache", lc)
+	assert.Contains(t, buf.String(), "cache.name=users cache.len=1 cache.hit_ratio=0.6666666666666666 cache.hits=2 cache.misses=1 cache.evicted=1")
+	buf.Reset()
+	logger.Info("state", "stats", lc.Stat())
+	assert.Contains(t, buf.String(), "stats.hits=2 stats.misses=1 stats.hit_ratio=0.6666666666666666 stats.added=2 stats.evicted=1 "+
+		"stats.peek_hits=0 stats.peek_misses=0 stats.evicted_early=1")
+	assert.Equal(t, 0.0, Stats{}.HitRatio())
+}
+
+func TestCache_ExpirationBuckets(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("no-ttl", "val", 0)
+	lc.Set("expired", "val", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	lc.Set("key1", "val", time.Minute)
+	lc.Set("key2", "val", time.Minute)
+	lc.Set("key3", "val", 2*time.Hour)
+	lc.Set("key4", "val", 2*time.Hour+time.Second)
+
+	buckets := lc.ExpirationBuckets(time.Hour)
+	exp1, _ := lc.GetExpiration("key1")
+	exp3, _ := lc.GetExpiration("key3")
+	exp4, _ := lc.GetExpiration("key4")
+	expected := map[time.Time]int{exp1.Truncate(time.Hour): 2}
+	expected[exp3.Truncate(time.Hour)]++
+	expected[exp4.Truncate(time.Hour)]++
+	assert.Equal(t, expected, buckets)
+
+	total := 0
+	for _, cnt := range lc.ExpirationBuckets(time.Minute) {
+		total += cnt
+	}
+	assert.Equal(t, 4, total)
+	assert.Nil(t, lc.ExpirationBuckets(0))
+}
+
+func TestCacheStatsSink(t *testing.T) {
+	var pushed []Stats
+	lc := NewCache[string, string]().WithStatsSink(func(s Stats) { pushed = append(pushed, s) }, 0, 3)
+	lc.Set("key1", "val1", 0)
+	lc.Get("key1")
+	assert.Empty(t, pushed)
+	lc.Get("key2")
+	assert.Equal(t, []Stats{{Hits: 1, Misses: 1, Added: 1}}, pushed)
+	lc.Get("key1")
+	lc.Get("key1")
+	lc.Get("key1")
+	assert.Equal(t, []Stats{{Hits: 1, Misses: 1, Added: 1}, {Hits: 4, Misses: 1, Added: 1}}, pushed)
+
+	pushed = nil
+	lc = NewCache[string, string]().WithStatsSink(func(s Stats) { pushed = append(pushed, s) }, 20*time.Millisecond, 0)
+	lc.Get("key1")
+	lc.Get("key1")
+	assert.Empty(t, pushed)
+	time.Sleep(25 * time.Millisecond)
+	lc.Get("key1")
+	assert.Equal(t, []Stats{{Misses: 3}}, pushed)
+
+	var buf bytes.Buffer
+	var length []int
+	lc = NewCache[string, string]().WithLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	lc = lc.WithStatsSink(func(s Stats) {
+		length = append(length, lc.Len()) // called without the lock
+		if s.Added == 2 {
+			panic("sink failed")
+		}
+	}, 0, 1)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Set("key3", "val3", 0)
+	assert.Equal(t, []int{1, 2, 3}, length)
+	assert.Contains(t, buf.String(), "panic in stats sink recovered")
+}
+
+func TestStats_Sub(t *testing.T) {
+	prev := Stats{Hits: 10, Misses: 5, Added: 7, Evicted: 2, MissCost: time.Second, CostedMisses: 5}
+	cur := Stats{Hits: 15, Misses: 6, Added: 9, Evicted: 2, PeekHits: 1, MissCost: 2 * time.Second, CostedMisses: 6}
+	assert.Equal(t, Stats{Hits: 5, Misses: 1, Added: 2, PeekHits: 1, MissCost: time.Second, CostedMisses: 1}, cur.Sub(prev))
+
+	reset := Stats{Hits: 3, Misses: 1, Resets: 1}
+	assert.Equal(t, reset, reset.Sub(prev), "stats since reset")
+
+	prev = Stats{Hits: math.MaxUint64 - 1, Misses: 5, EvictedEarly: 3, MissCost: time.Second, Resets: 2}
+	cur = Stats{Hits: 2, Misses: 5, EvictedEarly: 4, MissCost: 3 * time.Second, Resets: 2}
+	assert.Equal(t, Stats{Hits: 4, EvictedEarly: 1, MissCost: 2 * time.Second}, cur.Sub(prev), "wrapped counter")
+}
+
+func TestStats_Add(t *testing.T) {
+	s := Stats{Hits: 10, Misses: 5, Added: 7, Evicted: 2, PeekHits: 1, PeekMisses: 2, EvictedEarly: 1,
+		EvictedOnOverwrite: 3, RejectedTooLarge: 4, MissCost: time.Second, CostedMisses: 5, Resets: 1}
+	assert.Equal(t, Stats{Hits: 20, Misses: 10, Added: 14, Evicted: 4, PeekHits: 2, PeekMisses: 4, EvictedEarly: 2,
+		EvictedOnOverwrite: 6, RejectedTooLarge: 8, MissCost: 2 * time.Second, CostedMisses: 10, Resets: 2}, s.Add(s))
+	assert.Equal(t, s, Stats{}.Add(s))
+}
+
+func TestCacheStatsResetOnPurge(t *testing.T) {
+	lc := NewCache[string, string]().WithStatsResetOnPurge().WithLockFreeReads().WithNamespace(func(key string) string {
+		return strings.Split(key, ":")[0]
+	})
+	lc.Set("tenant1:key1", "val1", 0)
+	lc.Get("tenant1:key1")
+	lc.Peek("tenant1:key1")
+	lc.Purge()
+	assert.Equal(t, Stats{Resets: 1}, lc.Stat(), "purged entries and not folded Peek stats dropped")
+	assert.Empty(t, lc.StatsByNamespace())
+
+	prev := lc.Stat()
+	lc.Set("tenant1:key1", "val1", 0)
+	assert.Equal(t, Stats{Added: 1, Resets: 1}, lc.Stat())
+	assert.Equal(t, Stats{Added: 1}, lc.Stat().Sub(prev))
+	assert.Equal(t, map[string]Stats{"tenant1": {Added: 1, Resets: 1}}, lc.StatsByNamespace())
+
+	lc = NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	lc.Purge()
+	assert.Equal(t, Stats{Added: 1, Evicted: 1}, lc.Stat(), "stats kept by default")
+}
+
+func TestCacheStatsRate(t *testing.T) {
+	assert.Equal(t, Rates{}, NewCache[string, string]().StatsRate(), "window not set")
+
+	lc := NewCache[string, string]().WithStatsRateWindow(100 * time.Millisecond)
+	assert.Equal(t, Rates{}, lc.StatsRate(), "no samples yet")
+	lc.Set("key1", "val1", 0)
+	for i := 0; i < 10; i++ {
+		lc.Get("key1")
+		lc.Get("key2")
+	}
+	time.Sleep(50 * time.Millisecond)
+	r := lc.StatsRate()
+	assert.InDelta(t, 0.5, r.HitRatio, 0.001)
+	assert.InDelta(t, float64(10)/r.Window.Seconds(), r.Hits, 0.001)
+	assert.InDelta(t, float64(10)/r.Window.Seconds(), r.Misses, 0.001)
+	assert.GreaterOrEqual(t, r.Window, 50*time.Millisecond)
+
+	// the window slides past the burst of hits
+	time.Sleep(150 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		lc.Get("key2")
+		time.Sleep(10 * time.Millisecond)
+	}
+	r = lc.StatsRate()
+	assert.Zero(t, r.Hits, "hits are out of the window")
+	assert.Zero(t, r.HitRatio)
+	assert.Positive(t, r.Misses)
+	assert.Less(t, r.Window, 150*time.Millisecond)
+}
@@ -0,0 +1,84 @@
+package cache
+
+import "time"
+
+// rateSamples is a number of stats samples taken within the window set by WithStatsRateWindow
+const rateSamples = 10
+
+// Rates are per-second rates of stats counters, returned by StatsRate
+type Rates struct {
+	Hits, Misses   float64
+	Added, Evicted float64
+	HitRatio       float64       // ratio of hits to all Get calls within the window
+	Window         time.Duration // time rates are computed over, longer than the window set if the cache was idle
+}
+
+// statsWindow keeps stats samples for StatsRate, set by WithStatsRateWindow
+type statsWindow struct {
+	window  time.Duration
+	samples []statsSample // ring buffer, with the next sample written to samples[next]
+	next    int
+}
+
+// statsSample is the cache-wide stats at the time
+type statsSample struct {
+	at   time.Time
+	stat Stats
+}
+
+// StatsRate returns per-second rates of stats counters over the sliding window set by WithStatsRateWindow.
+// Returns zero rates if the window is not set or there is not enough samples yet.
+func (c *cacheImpl[K, V]) StatsRate() Rates {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.rates.window <= 0 {
+		return Rates{}
+	}
+	c.foldLockFreeStats()
+	now := time.Now()
+	n := len(c.rates.samples)
+	if n == 0 {
+		c.sampleStats()
+		return Rates{}
+	}
+	// the latest sample at the window start or before it, or the oldest one if all samples are within the window
+	base := c.rates.samples[c.rates.next%n]
+	for i := 1; i < n; i++ {
+		s := c.rates.samples[(c.rates.next+i)%n]
+		if s.at.After(now.Add(-c.rates.window)) {
+			break
+		}
+		base = s
+	}
+	if !now.After(base.at) {
+		return Rates{}
+	}
+	d, delta := now.Sub(base.at), c.stat.Sub(base.stat)
+	sec := d.Seconds()
+	return Rates{Hits: float64(delta.Hits) / sec, Misses: float64(delta.Misses) / sec, Added: float64(delta.Added) / sec,
+		Evicted: float64(delta.Evicted) / sec, HitRatio: delta.HitRatio(), Window: d}
+}
+
+// sampleStats records the cache-wide stats in case the last sample is older than the sampling interval.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) sampleStats() {
+	if c.rates.window <= 0 {
+		return
+	}
+	now := time.Now()
+	if n := len(c.rates.samples); n > 0 {
+		last := c.rates.samples[(c.rates.next+n-1)%n]
+		if now.Sub(last.at) < c.rates.window/rateSamples {
+			return
+		}
+	}
+	c.foldLockFreeStats()
+	sample := statsSample{at: now, stat: c.stat}
+	// one more sample than fits the window, so there is a sample at the window start
+	if len(c.rates.samples) < rateSamples+1 {
+		c.rates.samples = append(c.rates.samples, sample)
+		return
+	}
+	c.rates.samples[c.rates.next] = sample
+	c.rates.next = (c.rates.next + 1) % len(c.rates.samples)
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// statsSink pushes stats to the function set by WithStatsSink
+type statsSink struct {
+	fn       func(s Stats)
+	interval time.Duration // push once interval passed since the last push, 0 to disable
+	ops      int           // push once ops stats updates are made since the last push, 0 to disable
+	lastPush time.Time
+	pending  int    // stats updates since the last push
+	due      *Stats // snapshot to be pushed once the lock is released
+}
+
+// pushStats takes stats snapshot to be pushed by unlock, in case the interval passed or enough stats updates
+// are made since the last push. Has to be called with lock!
+func (c *cacheImpl[K, V]) pushStats() {
+	if c.sink.fn == nil {
+		return
+	}
+	c.sink.pending++
+	now := time.Now()
+	if c.sink.lastPush.IsZero() {
+		c.sink.lastPush = now
+	}
+	intervalDue := c.sink.interval > 0 && now.Sub(c.sink.lastPush) >= c.sink.interval
+	opsDue := c.sink.ops > 0 && c.sink.pending >= c.sink.ops
+	if !intervalDue && !opsDue {
+		return
+	}
+	c.sink.lastPush, c.sink.pending = now, 0
+	c.foldLockFreeStats()
+	snapshot := c.stat
+	c.sink.due = &snapshot
+}
+
+// takeDueStats returns stats snapshot taken by pushStats, if any. Has to be called with lock!
+func (c *cacheImpl[K, V]) takeDueStats() *Stats {
+	due := c.sink.due
+	c.sink.due = nil
+	return due
+}
+
+// sendStats calls stats sink with the snapshot, recovering from panic in it. Has to be called without lock.
+func (c *cacheImpl[K, V]) sendStats(s *Stats) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logError("panic in stats sink recovered", slog.Any("panic", r))
+		}
+	}()
+	c.sink.fn(*s)
+}
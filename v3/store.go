@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Entry is a cache entry, as written to the backing store and snapshots
+type Entry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+	Meta      map[string]string // metadata set by SetWithMeta, returned by GetEntry only
+}
+
+// Backend is a durable store for cache entries, used in write-through and write-behind modes
+type Backend[K comparable, V any] interface {
+	Store(ctx context.Context, entries []Entry[K, V]) error
+}
+
+// writeBehind keeps entries waiting to be written to the backing store. In write-through mode entries
+// wait only until the writing call releases the lock.
+type writeBehind[K comparable, V any] struct {
+	enabled   bool
+	interval  time.Duration
+	batchSize int
+	pending   []Entry[K, V]
+	index     map[K]int // position of the key in pending
+	lastFlush time.Time
+	scheduled bool        // background flush is scheduled but has not taken pending entries yet
+	timer     *time.Timer // timer of the scheduled flush
+}
+
+// Flush writes entries waiting to be written to the backing store in write-behind mode.
+// Should be called before the application exit, and could be called periodically to make sure
+// pending entries are written in case there are no new writes to trigger the background flush.
+func (c *cacheImpl[K, V]) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	c.lock(OpOther)
+	batch := c.takePending()
+	c.unlock(OpOther)
+	if len(batch) == 0 {
+		return nil
+	}
+	return c.store.Store(ctx, batch)
+}
+
+// persist queues the item to be written to the backing store: by writeThrough once the writing call releases
+// the lock in write-through mode, or in background batches in write-behind mode. Has to be called with lock!
+func (c *cacheImpl[K, V]) persist(item *cacheItem[K, V]) {
+	if c.store == nil {
+		return
+	}
+	wb := &c.writeBehind
+	entry := Entry[K, V]{Key: item.key, Value: item.value, ExpiresAt: item.expiresAt}
+	if idx, ok := wb.index[item.key]; ok {
+		wb.pending[idx] = entry // not flushed yet, write the latest value only
+	} else {
+		wb.index[item.key] = len(wb.pending)
+		wb.pending = append(wb.pending, entry)
+	}
+	if !wb.enabled {
+		return
+	}
+
+	due := len(wb.pending) >= wb.batchSize || time.Since(wb.lastFlush) >= wb.interval
+	switch {
+	case !wb.scheduled:
+		wb.scheduled = true
+		delay := time.Duration(0)
+		if !due {
+			delay = wb.interval - time.Since(wb.lastFlush)
+		}
+		wb.timer = time.AfterFunc(delay, c.flushInBackground)
+	case due:
+		wb.timer.Reset(0)
+	}
+}
+
+// flushInBackground flushes write-behind entries, called by the flush timer
+func (c *cacheImpl[K, V]) flushInBackground() {
+	if err := c.Flush(context.Background()); err != nil {
+		c.logError("failed to flush entries to store", slog.Any("error", err))
+	}
+}
+
+// writeThrough writes entries queued by persist to the backing store in write-through mode.
+// Has to be called by write methods after they release the lock, so the store I/O doesn't block other callers.
+func (c *cacheImpl[K, V]) writeThrough() {
+	if c.store == nil || c.writeBehind.enabled {
+		return
+	}
+	c.flushMu.Lock() // held while storing, so the entries of concurrent writes are stored in order
+	defer c.flushMu.Unlock()
+	c.lock(OpOther)
+	batch := c.takePending()
+	c.unlock(OpOther)
+	if len(batch) == 0 {
+		return // written by the concurrent call
+	}
+	if err := c.store.Store(context.Background(), batch); err != nil {
+		for _, e := range batch {
+			c.logError("failed to write entry to store", slog.Any("key", e.Key), slog.Any("error", err))
+		}
+	}
+}
+
+// takePending returns entries waiting to be written and resets the write-behind buffer. Has to be called with lock!
+func (c *cacheImpl[K, V]) takePending() []Entry[K, V] {
+	wb := &c.writeBehind
+	res := wb.pending
+	wb.pending, wb.index = nil, map[K]int{}
+	wb.lastFlush, wb.scheduled = time.Now(), false
+	return res
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBackend keeps all written batches
+type memBackend struct {
+	sync.Mutex
+	batches [][]Entry[string, string]
+	err     error
+}
+
+func (b *memBackend) Store(_ context.Context, entries []Entry[string, string]) error {
+	b.Lock()
+	defer b.Unlock()
+	b.batches = append(b.batches, entries)
+	return b.err
+}
+
+func (b *memBackend) keys() (res [][]string) {
+	b.Lock()
+	defer b.Unlock()
+	for _, batch := range b.batches {
+		keys := make([]string, 0, len(batch))
+		for _, e := range batch {
+			keys = append(keys, e.Key+":"+e.Value)
+		}
+		res = append(res, keys)
+	}
+	return res
+}
+
+func TestCacheWithWriteThrough(t *testing.T) {
+	store := &memBackend{}
+	lc := NewCache[string, string]().WithWriteThrough(store).WithLoader(func(_ context.Context, key string) (string, error) {
+		return "loaded-" + key, nil
+	})
+
+	lc.Set("key1", "val1", time.Minute)
+	lc.Add("key2", "val2")
+	lc.Set("key1", "val3", 0)
+	assert.Equal(t, [][]string{{"key1:val1"}, {"key2:val2"}, {"key1:val3"}}, store.keys())
+	exp, _ := lc.GetExpiration("key1")
+	assert.Equal(t, exp, store.batches[2][0].ExpiresAt)
+
+	_, err := lc.GetCtx(context.Background(), "key3")
+	require.NoError(t, err)
+	assert.Len(t, store.keys(), 3, "loaded values are not written")
+
+	var buf bytes.Buffer
+	store.err = errors.New("store failed")
+	lc = NewCache[string, string]().WithWriteThrough(store).WithLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	lc.Set("key1", "val1", 0)
+	assert.Contains(t, buf.String(), `level=ERROR msg="failed to write entry to store" key=key1 error="store failed"`)
+	assert.True(t, lc.Contains("key1"), "entry is cached regardless of store error")
+}
+
+func TestCacheWithWriteBehind(t *testing.T) {
+	store := &memBackend{}
+	lc := NewCache[string, string]().WithWriteBehind(store, time.Hour, 3)
+
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Set("key1", "val3", 0)
+	assert.Empty(t, store.keys())
+
+	lc.Set("key3", "val4", 0) // batch size reached
+	assert.Eventually(t, func() bool { return len(store.keys()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, [][]string{{"key1:val3", "key2:val2", "key3:val4"}}, store.keys(), "only the latest value written")
+
+	lc.Set("key4", "val5", 0)
+	require.NoError(t, lc.Flush(context.Background()))
+	assert.Equal(t, [][]string{{"key1:val3", "key2:val2", "key3:val4"}, {"key4:val5"}}, store.keys())
+	require.NoError(t, lc.Flush(context.Background()), "nothing to flush")
+	assert.Len(t, store.keys(), 2)
+
+	// flush by interval, without further writes
+	store = &memBackend{}
+	lc = NewCache[string, string]().WithWriteBehind(store, time.Millisecond*10, 100)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	assert.Eventually(t, func() bool { return len(store.keys()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, [][]string{{"key1:val1", "key2:val2"}}, store.keys())
+
+	store.err = errors.New("store failed")
+	lc.Set("key3", "val3", 0)
+	assert.EqualError(t, lc.Flush(context.Background()), "store failed")
+
+	assert.NoError(t, NewCache[string, string]().Flush(context.Background()), "no store set")
+}
+
+// slowBackend blocks Store until released
+type slowBackend struct {
+	memBackend
+	release chan struct{}
+}
+
+func (b *slowBackend) Store(ctx context.Context, entries []Entry[string, string]) error {
+	<-b.release
+	return b.memBackend.Store(ctx, entries)
+}
+
+func TestCacheWriteThroughWithoutLock(t *testing.T) {
+	store := &slowBackend{release: make(chan struct{})}
+	lc := NewCache[string, string]().WithWriteThrough(store)
+	done := make(chan struct{})
+	go func() {
+		lc.Set("key1", "val1", 0)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return lc.Contains("key1") }, time.Second, time.Millisecond,
+		"cache is not locked while the store is written")
+	select {
+	case <-done:
+		t.Fatal("Set returned before the store was written")
+	default:
+	}
+	close(store.release)
+	<-done
+	assert.Equal(t, [][]string{{"key1:val1"}}, store.keys())
+}
+
+func TestCacheWriteCoalescingWithStore(t *testing.T) {
+	store := &memBackend{}
+	lc := NewCache[string, string]().WithWriteThrough(store).WithWriteCoalescing(time.Millisecond * 20)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key1", "val2", 0)
+	lc.Set("key1", "val3", 0)
+	assert.Equal(t, [][]string{{"key1:val1"}}, store.keys(), "writes coalesced")
+	assert.Eventually(t, func() bool { return len(store.keys()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, [][]string{{"key1:val1"}, {"key1:val3"}}, store.keys(), "latest value written once window closed")
+
+	lc.Set("key2", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.Remove("key2")
+	time.Sleep(time.Millisecond * 40)
+	assert.Len(t, store.keys(), 3, "removed key is not written")
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// tombstones keeps recently invalidated keys, set by WithTombstones
+type tombstones[K comparable] struct {
+	window time.Duration
+	until  map[K]time.Time // key to the time its tombstone expires
+}
+
+// addTombstone records tombstone of the invalidated key. Has to be called with lock!
+func (c *cacheImpl[K, V]) addTombstone(key K) {
+	if c.tombstones.window <= 0 {
+		return
+	}
+	if c.tombstones.until == nil {
+		c.tombstones.until = map[K]time.Time{}
+	}
+	c.tombstones.until[key] = time.Now().Add(c.tombstones.window)
+}
+
+// tombstoned checks if the key has not expired tombstone, deleting the expired one. Has to be called with lock!
+func (c *cacheImpl[K, V]) tombstoned(key K, now time.Time) bool {
+	until, ok := c.tombstones.until[key]
+	if !ok {
+		return false
+	}
+	if now.After(until) {
+		delete(c.tombstones.until, key)
+		return false
+	}
+	c.logDebug("write rejected by tombstone", slog.Any("key", key), slog.Time("until", until))
+	return true
+}
+
+// deleteExpiredTombstones deletes expired tombstones, returning the number of deleted ones.
+// Has to be called with lock!
+func (c *cacheImpl[K, V]) deleteExpiredTombstones(now time.Time) int {
+	deleted := 0
+	for key, until := range c.tombstones.until {
+		if now.After(until) {
+			delete(c.tombstones.until, key)
+			deleted++
+		}
+	}
+	return deleted
+}
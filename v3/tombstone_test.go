@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheTombstones(t *testing.T) {
+	lc := NewCache[string, string]().WithTombstones(50 * time.Millisecond)
+	lc.Set("key1", "val1", 0)
+	lc.Set("key2", "val2", 0)
+	lc.SetWithDeps("key3", "val3", 0, "key2")
+
+	assert.True(t, lc.Remove("key1"))
+	lc.Set("key1", "late", 0)
+	_, ok := lc.Peek("key1")
+	assert.False(t, ok, "set of removed key rejected")
+
+	lc.Invalidate("key2")
+	lc.Set("key3", "late", 0)
+	_, ok = lc.Peek("key3")
+	assert.False(t, ok, "set of key invalidated by dependency rejected")
+
+	lc.Invalidate("key4")
+	lc.Set("key4", "late", 0)
+	_, ok = lc.Peek("key4")
+	assert.False(t, ok, "tombstone recorded for missing key as well")
+
+	lc.Set("key5", "val5", 0)
+	v, ok := lc.Peek("key5")
+	assert.True(t, ok, "other keys not affected")
+	assert.Equal(t, "val5", v)
+
+	time.Sleep(60 * time.Millisecond)
+	lc.Set("key1", "new", 0)
+	v, ok = lc.Peek("key1")
+	assert.True(t, ok, "set allowed once tombstone expired")
+	assert.Equal(t, "new", v)
+
+	lc.DeleteExpired()
+	lc.Remove("key1")
+	lc.Purge()
+	lc.Set("key1", "val1", 0)
+	_, ok = lc.Peek("key1")
+	assert.True(t, ok, "purge deletes tombstones")
+
+	// without tombstones removed key can be set right away
+	lc = NewCache[string, string]()
+	lc.Set("key1", "val1", 0)
+	lc.Remove("key1")
+	lc.Set("key1", "val2", 0)
+	_, ok = lc.Peek("key1")
+	assert.True(t, ok)
+}
+
+func TestCacheTombstonesDeleteExpired(t *testing.T) {
+	lc := NewCache[string, string]().WithTombstones(20 * time.Millisecond)
+	c := lc.(*cacheImpl[string, string])
+	lc.Invalidate("key1")
+	lc.Invalidate("key2")
+	assert.Len(t, c.tombstones.until, 2)
+	time.Sleep(30 * time.Millisecond)
+	lc.Invalidate("key3")
+	lc.DeleteExpired()
+	assert.Len(t, c.tombstones.until, 1)
+	assert.Contains(t, c.tombstones.until, "key3")
+}
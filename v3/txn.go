@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Tx is a transaction on the cache, made by Txn
+type Tx[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V, ttl time.Duration)
+	Delete(key K)
+}
+
+// Txn calls fn with a transaction holding the cache lock, so multiple keys are read and updated atomically,
+// e.g. to keep forward and reverse mappings consistent. Writes of the transaction are buffered and applied
+// in order once fn returns nil, and discarded in case it returns error, which is returned by Txn.
+// Get of the transaction sees its own writes. fn must not call methods of the cache, as the lock is held.
+// In case the transaction sets more keys than MaxKeys, nothing is applied and ErrTxnTooLarge is returned.
+// Written keys are kept from eviction while the writes are applied, so a later write of the transaction
+// doesn't evict an earlier one to maintain the cache size or cost, and other entries are evicted instead,
+// unless the written entries alone exceed the cost limit (WithMaxCost).
+// Each write is still applied the way Set does it, so writes rejected by admission (WithAdmission),
+// entry cost limit (WithMaxEntryCost) or tombstones (WithTombstones) are skipped, and the rest of the transaction is applied.
+func (c *cacheImpl[K, V]) Txn(fn func(tx Tx[K, V]) error) error {
+	defer c.writeThrough()
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	if c.closed {
+		return ErrClosed
+	}
+	tx := &txn[K, V]{cache: c, pending: map[K]int{}}
+	if err := fn(tx); err != nil {
+		c.logDebug("transaction rolled back")
+		return err
+	}
+	if set := tx.setKeys(); c.maxKeys > 0 && set > c.maxKeys {
+		c.logDebug("transaction rejected, too many keys", slog.Int("keys", set), slog.Int("max_keys", c.maxKeys))
+		return ErrTxnTooLarge
+	}
+	written := make([]K, 0, len(tx.ops))
+	for _, op := range tx.ops {
+		if op.del {
+			c.invalidate(op.key)
+			continue
+		}
+		c.pin(op.key) // pinned before add, so the new entry is not the one evicted in place of older ones
+		c.add(op.key, op.value, op.ttl, true)
+		written = append(written, op.key)
+	}
+	for _, key := range written {
+		c.unpin(key)
+	}
+	// writes deleted later in the transaction could leave the cache above the limits while being pinned
+	for c.maxKeys > 0 && len(c.items) > c.maxKeys && c.removeOldest() {
+	}
+	c.evictOverCost(0)
+	return nil
+}
+
+// txn is a transaction with buffered writes, used with the cache lock held
+type txn[K comparable, V any] struct {
+	cache   *cacheImpl[K, V]
+	ops     []txnOp[K, V]
+	pending map[K]int // key to index of its last write in ops
+}
+
+type txnOp[K comparable, V any] struct {
+	key   K
+	value V
+	ttl   time.Duration
+	del   bool
+}
+
+// setKeys returns the number of keys set by the transaction
+func (t *txn[K, V]) setKeys() int {
+	res := 0
+	for _, idx := range t.pending {
+		if !t.ops[idx].del {
+			res++
+		}
+	}
+	return res
+}
+
+// Get returns the key value written by the transaction, or the cached value, the same way cache Get does,
+// except for a child cache, where the parent is not read, as the cache lock is held
+func (t *txn[K, V]) Get(key K) (V, bool) {
+	if idx, ok := t.pending[key]; ok {
+		if t.ops[idx].del {
+			return *new(V), false
+		}
+		return t.cache.copyValue(t.ops[idx].value), true
+	}
+	return t.cache.get(key)
+}
+
+// Set sets the key value with ttl once the transaction is applied, ttl of 0 means the cache default TTL
+func (t *txn[K, V]) Set(key K, value V, ttl time.Duration) {
+	t.pending[key] = len(t.ops)
+	t.ops = append(t.ops, txnOp[K, V]{key: key, value: value, ttl: ttl})
+}
+
+// Delete removes the key once the transaction is applied, the same way cache Remove does
+func (t *txn[K, V]) Delete(key K) {
+	t.pending[key] = len(t.ops)
+	t.ops = append(t.ops, txnOp[K, V]{key: key, del: true})
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Txn(t *testing.T) {
+	lc := NewCache[string, string]()
+	lc.Set("user:1", "alice", 0)
+	lc.Set("name:alice", "user:1", 0)
+
+	// rename alice to bob, keeping forward and reverse mappings consistent
+	err := lc.Txn(func(tx Tx[string, string]) error {
+		name, ok := tx.Get("user:1")
+		if !ok {
+			return errors.New("not found")
+		}
+		tx.Delete("name:" + name)
+		tx.Set("user:1", "bob", 0)
+		tx.Set("name:bob", "user:1", 0)
+		v, ok := tx.Get("user:1")
+		assert.True(t, ok)
+		assert.Equal(t, "bob", v, "own write visible")
+		_, ok = tx.Get("name:alice")
+		assert.False(t, ok, "own delete visible")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "name:bob"}, lc.Keys())
+
+	err = lc.Txn(func(tx Tx[string, string]) error {
+		tx.Set("user:1", "carol", 0)
+		tx.Delete("name:bob")
+		return errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+	v, _ := lc.Get("user:1")
+	assert.Equal(t, "bob", v, "rolled back")
+	assert.True(t, lc.Contains("name:bob"))
+
+	require.NoError(t, lc.Close())
+	assert.ErrorIs(t, lc.Txn(func(Tx[string, string]) error { return nil }), ErrClosed)
+}
+
+func TestCache_TxnAtomic(t *testing.T) {
+	lc := NewCache[string, int]()
+	lc.Set("a", 100, 0)
+	lc.Set("b", 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err := lc.Txn(func(tx Tx[string, int]) error {
+					a, _ := tx.Get("a")
+					b, _ := tx.Get("b")
+					if a+b != 100 {
+						return fmt.Errorf("invariant broken: %d+%d", a, b)
+					}
+					if a == 0 {
+						a, b = b, a
+					}
+					tx.Set("a", a-1, 0)
+					tx.Set("b", b+1, 0)
+					return nil
+				})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	a, _ := lc.Get("a")
+	b, _ := lc.Get("b")
+	assert.Equal(t, 100, a+b)
+}
+
+func TestCache_TxnCapacity(t *testing.T) {
+	lc := NewCache[string, int]().WithMaxKeys(3)
+	lc.Set("old1", 1, 0)
+	lc.Set("old2", 2, 0)
+	lc.Set("old3", 3, 0)
+	err := lc.Txn(func(tx Tx[string, int]) error {
+		tx.Set("key1", 1, 0)
+		tx.Set("key2", 2, 0)
+		tx.Set("key3", 3, 0)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key1", "key2", "key3"}, lc.Keys(), "older entries evicted, all writes kept")
+
+	err = lc.Txn(func(tx Tx[string, int]) error {
+		for i := 0; i < 4; i++ {
+			tx.Set(fmt.Sprintf("new%d", i), i, 0)
+		}
+		tx.Delete("new0")
+		tx.Set("new1", 10, 0)
+		return nil
+	})
+	require.NoError(t, err, "three keys set, one deleted")
+	assert.Equal(t, []string{"new2", "new3", "new1"}, lc.Keys())
+
+	err = lc.Txn(func(tx Tx[string, int]) error {
+		for i := 0; i < 4; i++ {
+			tx.Set(fmt.Sprintf("big%d", i), i, 0)
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrTxnTooLarge)
+	assert.Equal(t, []string{"new2", "new3", "new1"}, lc.Keys(), "nothing applied")
+
+	cc := NewCache[string, string]().WithMaxCost(10, func(_ string, value string) int64 { return int64(len(value)) })
+	cc.Set("old", "aaaa", 0)
+	err = cc.Txn(func(tx Tx[string, string]) error {
+		tx.Set("key1", "bbbb", 0)
+		tx.Set("key2", "cccc", 0)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key1", "key2"}, cc.Keys(), "old entry evicted by cost")
+}
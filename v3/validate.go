@@ -0,0 +1,61 @@
+package cache
+
+// Warning is a configuration issue found by Validate
+type Warning struct {
+	Code    string // short identifier of the issue, e.g. "unbounded"
+	Message string // what's wrong and how to fix it
+}
+
+// String returns warning code and message
+func (w Warning) String() string {
+	return w.Code + ": " + w.Message
+}
+
+// Validate inspects the cache configuration and returns warnings about suspicious combinations of options,
+// e.g. unlimited size with default 10 years TTL, which makes an accidentally unbounded cache.
+// It's intended to be called once on startup, with warnings logged. Returns nil if nothing is found.
+func (c *cacheImpl[K, V]) Validate() []Warning {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	var res []Warning
+	add := func(code, msg string) { res = append(res, Warning{Code: code, Message: msg}) }
+
+	unlimited := c.maxKeys <= 0 && c.maxCost <= 0
+	switch {
+	case unlimited && (c.ttl >= noEvictionTTL || c.zeroTTL == NoTTL):
+		add("unbounded", "no size limit and entries never expire, set WithMaxKeys, WithMaxCost or WithTTL")
+	case unlimited:
+		add("no-size-limit", "expired entries are kept until DeleteExpired or Maintain, call it periodically or set WithMaxKeys")
+	}
+	if c.ttl <= 0 {
+		add("non-positive-ttl", "entries expire immediately, set positive WithTTL")
+	}
+	if unlimited && (c.isLRU || c.isClock || c.lruK.k > 0) {
+		add("policy-without-limit", "eviction policy has no effect without WithMaxKeys or WithMaxCost")
+	}
+	if unlimited && (c.doorkeeper != nil || c.admission != nil) {
+		add("admission-without-limit", "admission is checked only when the cache is full, set WithMaxKeys or WithMaxCost")
+	}
+	if c.maxKeys <= 0 && c.ghost.enabled {
+		add("ghost-without-limit", "WouldHaveHit estimates need WithMaxKeys")
+	}
+	if c.loader == nil && (c.loaderLimiter != nil || c.refreshAhead > 0 || c.burst.misses > 0) {
+		add("no-loader", "WithLoaderLimiter, WithRefreshAhead and WithBurstExtension have no effect without WithLoader")
+	}
+	if c.refreshAhead > 0 && c.refreshAhead >= c.ttl {
+		add("refresh-window-too-long", "WithRefreshAhead window is not shorter than TTL, every Get triggers refresh")
+	}
+	if c.coalesceWindow > 0 && c.store == nil {
+		add("coalescing-without-store", "WithWriteCoalescing has no effect without WithWriteThrough or WithWriteBehind")
+	}
+	if c.earlyRate < 0 || c.earlyRate > 1 {
+		add("early-eviction-threshold", "WithEarlyEvictionThreshold should be between 0 and 1")
+	}
+	if c.maxEntryCost > 0 && c.maxCost > 0 && c.maxEntryCost > c.maxCost {
+		add("entry-cost-above-limit", "WithMaxEntryCost is above WithMaxCost, entries of such cost evict the whole cache")
+	}
+	if c.missFilter != nil && c.parent != nil {
+		add("miss-filter-with-parent", "WithMissFilter is not used by child cache, as keys of the parent are not in the filter")
+	}
+	return res
+}
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheValidate(t *testing.T) {
+	codes := func(ws []Warning) []string {
+		res := []string{}
+		for _, w := range ws {
+			res = append(res, w.Code)
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{"unbounded"}, codes(NewCache[string, int]().Validate()))
+	assert.Equal(t, []string{"no-size-limit", "policy-without-limit"}, codes(NewCache[string, int]().WithTTL(time.Minute).WithLRU().Validate()))
+	assert.Equal(t, []string{"unbounded"}, codes(NewCache[string, int]().WithTTL(time.Minute).WithZeroTTL(NoTTL).Validate()))
+	assert.Nil(t, NewCache[string, int]().WithMaxKeys(100).WithTTL(time.Minute).WithLRU().Validate())
+	assert.Equal(t, []string{"non-positive-ttl"}, codes(NewCache[string, int]().WithMaxKeys(100).WithTTL(-1).Validate()))
+
+	lc := NewCache[string, int]().WithMaxKeys(100).WithTTL(time.Minute).WithRefreshAhead(time.Hour).WithWriteCoalescing(time.Second)
+	assert.Equal(t, []string{"no-loader", "refresh-window-too-long", "coalescing-without-store"}, codes(lc.Validate()))
+	lc = NewCache[string, int]().WithMaxCost(100, nil).WithGhostCache().WithLoader(func(context.Context, string) (int, error) {
+		return 0, nil
+	})
+	assert.Equal(t, []string{"ghost-without-limit"}, codes(lc.Validate()))
+	lc = NewCache[string, int]().WithTTL(time.Minute).WithMaxCost(100, nil).WithMaxEntryCost(200)
+	assert.Equal(t, []string{"entry-cost-above-limit"}, codes(lc.Validate()))
+
+	w := NewCache[string, int]().Validate()[0]
+	assert.Equal(t, "unbounded: no size limit and entries never expire, set WithMaxKeys, WithMaxCost or WithTTL", w.String())
+}
@@ -0,0 +1,67 @@
+package cache
+
+import "time"
+
+// View is a point-in-time copy of the cache entries made by SnapshotView. It's not affected by
+// further changes of the cache and can be read concurrently without locking the cache.
+type View[K comparable, V any] struct {
+	entries []Entry[K, V] // from the oldest to the newest
+	index   map[K]int
+	at      time.Time
+}
+
+// SnapshotView returns a copy of all non-expired entries, made under the lock in one pass, so long-running
+// exports don't block writers and see the consistent state. Values are copied with the function set
+// by WithCopyOnGet, so the view doesn't share mutable values with the cache.
+func (c *cacheImpl[K, V]) SnapshotView() View[K, V] {
+	c.lock(OpOther)
+	defer c.unlock(OpOther)
+	now := time.Now()
+	view := View[K, V]{entries: make([]Entry[K, V], 0, len(c.items)), index: make(map[K]int, len(c.items)), at: now}
+	for ent := c.oldest(); ent != nil; ent = ent.Prev() {
+		item := ent.Value.(*cacheItem[K, V])
+		if now.After(c.expiration(item)) {
+			continue
+		}
+		view.index[item.key] = len(view.entries)
+		view.entries = append(view.entries, Entry[K, V]{Key: item.key, Value: c.copyValue(item.value), ExpiresAt: c.expiration(item)})
+	}
+	return view
+}
+
+// Get returns the key value as of the view time
+func (v View[K, V]) Get(key K) (V, bool) {
+	idx, ok := v.index[key]
+	if !ok {
+		return *new(V), false
+	}
+	return v.entries[idx].Value, true
+}
+
+// Keys returns keys of the view, from the oldest to the newest
+func (v View[K, V]) Keys() []K {
+	res := make([]K, len(v.entries))
+	for i, e := range v.entries {
+		res[i] = e.Key
+	}
+	return res
+}
+
+// Len returns number of entries in the view
+func (v View[K, V]) Len() int {
+	return len(v.entries)
+}
+
+// Time returns time the view was made at
+func (v View[K, V]) Time() time.Time {
+	return v.at
+}
+
+// Range calls fn for each entry of the view, from the oldest to the newest, until fn returns false
+func (v View[K, V]) Range(fn func(e Entry[K, V]) bool) {
+	for _, e := range v.entries {
+		if !fn(e) {
+			return
+		}
+	}
+}
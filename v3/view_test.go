@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_SnapshotView(t *testing.T) {
+	lc := NewCache[string, []int]().WithCopyOnGet(func(v []int) []int { return append([]int(nil), v...) })
+	lc.Set("key1", []int{1}, 0)
+	lc.Set("key2", []int{2}, time.Hour)
+	lc.Set("key3", []int{3}, time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+
+	view := lc.SnapshotView()
+	assert.WithinDuration(t, time.Now(), view.Time(), time.Second)
+	lc.Set("key4", []int{4}, 0)
+	lc.Remove("key1")
+	v, _ := lc.Peek("key2")
+	v[0] = 20
+
+	assert.Equal(t, 2, view.Len())
+	assert.Equal(t, []string{"key1", "key2"}, view.Keys(), "expired skipped, later changes not visible")
+	v, ok := view.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, []int{2}, v)
+	_, ok = view.Get("key4")
+	assert.False(t, ok)
+
+	var entries []Entry[string, []int]
+	view.Range(func(e Entry[string, []int]) bool {
+		entries = append(entries, e)
+		return false
+	})
+	assert.Len(t, entries, 1, "stopped by fn")
+	assert.Equal(t, "key1", entries[0].Key)
+	assert.Equal(t, []int{1}, entries[0].Value)
+}
@@ -0,0 +1,38 @@
+//go:build go1.24
+
+package cache
+
+import (
+	"time"
+	"weak"
+)
+
+// WeakCache keeps weak pointers to values, so values can be reclaimed by GC when not referenced
+// elsewhere, while the keys stay in the underlying cache. Reclaimed value is reported as a miss and
+// its key is removed. Useful for large objects which are cheap to make again. Requires Go 1.24.
+type WeakCache[K comparable, T any] struct {
+	Cache[K, weak.Pointer[T]]
+}
+
+// NewWeakCache returns WeakCache on top of the given cache, which defines TTL, size and other options.
+func NewWeakCache[K comparable, T any](c Cache[K, weak.Pointer[T]]) *WeakCache[K, T] {
+	return &WeakCache[K, T]{Cache: c}
+}
+
+// SetValue sets weak pointer to value under the key, ttl of 0 would use cache-wide TTL
+func (w *WeakCache[K, T]) SetValue(key K, value *T, ttl time.Duration) {
+	w.Cache.Set(key, weak.Make(value), ttl)
+}
+
+// GetValue returns the key value if it's not expired and not reclaimed by GC
+func (w *WeakCache[K, T]) GetValue(key K) (*T, bool) {
+	p, ok := w.Cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if v := p.Value(); v != nil {
+		return v, true
+	}
+	w.Cache.Remove(key)
+	return nil, false
+}
@@ -0,0 +1,37 @@
+//go:build go1.24
+
+package cache
+
+import (
+	"runtime"
+	"testing"
+	"weak"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeakCache(t *testing.T) {
+	type blob struct{ data [1024]byte }
+	wc := NewWeakCache[string, blob](NewCache[string, weak.Pointer[blob]]().WithMaxKeys(10))
+
+	kept := &blob{}
+	kept.data[0] = 1
+	wc.SetValue("kept", kept, 0)
+	wc.SetValue("dropped", &blob{}, 0)
+	assert.Equal(t, 2, wc.Len())
+
+	runtime.GC()
+	runtime.GC()
+
+	v, ok := wc.GetValue("kept")
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), v.data[0])
+
+	_, ok = wc.GetValue("dropped")
+	assert.False(t, ok, "reclaimed by GC")
+	assert.Equal(t, []string{"kept"}, wc.Keys(), "reclaimed key removed")
+
+	_, ok = wc.GetValue("missing")
+	assert.False(t, ok)
+	runtime.KeepAlive(kept)
+}